@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/mkaganm/algo-trade/collector/internal/adapters/binance"
 	"github.com/mkaganm/algo-trade/collector/internal/adapters/healthcheck"
 	"github.com/mkaganm/algo-trade/collector/internal/adapters/mongodb"
+	"github.com/mkaganm/algo-trade/collector/internal/adapters/recorder"
+	"github.com/mkaganm/algo-trade/collector/internal/adapters/wal"
 	"github.com/mkaganm/algo-trade/collector/internal/config"
 	"github.com/mkaganm/algo-trade/collector/internal/core"
 	"github.com/mkaganm/algo-trade/collector/internal/helpers"
@@ -31,15 +34,118 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Open the write-ahead log used when MongoDB is unavailable
+	walLog, err := wal.Open(cfg.WALDir, cfg.WALSegmentSize)
+	if err != nil {
+		log.Fatalf("Failed to open write-ahead log: %v", err)
+	}
+	defer walLog.Close()
+
+	batch := mongodb.BatchConfig{
+		Size:          cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		QueueSize:     cfg.QueueSize,
+		Overflow:      mongodb.OverflowPolicy(cfg.OverflowPolicy),
+		Spool:         walLog,
+	}
+
 	// Initialize MongoDB repository
-	repo, err := mongodb.NewMongoOrderBookRepository(cfg.MongoURI, cfg.DatabaseName, cfg.CollectionName)
+	repo, err := mongodb.NewMongoOrderBookRepository(
+		cfg.MongoURI,
+		cfg.DatabaseName,
+		cfg.CollectionName,
+		cfg.DepthTTL,
+		batch,
+	)
 	if err != nil {
 		log.Fatalf("Failed to create MongoDB repository: %v", err)
 	}
 	defer repo.Close()
 
+	// Drain spooled documents back into MongoDB in the background
+	replayer := mongodb.NewReplayer(repo.Client, cfg.DatabaseName, walLog, cfg.WALReplayInterval, cfg.BatchSize)
+	go replayer.Run(ctx)
+
+	snapshotRepo := mongodb.NewMongoBookSnapshotRepository(repo.Client, cfg.DatabaseName, cfg.SnapshotCollection)
+
+	eventRepo, err := mongodb.NewMongoStreamEventRepository(
+		repo.Client,
+		cfg.DatabaseName,
+		cfg.EventCollectionPrefix,
+		batch,
+	)
+	if err != nil {
+		log.Fatalf("Failed to create MongoDB stream event repository: %v", err)
+	}
+	defer eventRepo.Close()
+
+	tradeRepo, err := mongodb.NewMongoTradeRepository(
+		repo.Client,
+		cfg.DatabaseName,
+		cfg.TradeCollection,
+		cfg.AggTradeCollection,
+		batch,
+	)
+	if err != nil {
+		log.Fatalf("Failed to create MongoDB trade repository: %v", err)
+	}
+	defer tradeRepo.Close()
+
+	gapRepo, err := mongodb.NewMongoGapRepository(repo.Client, cfg.DatabaseName, cfg.GapCollection)
+	if err != nil {
+		log.Fatalf("Failed to create MongoDB gap repository: %v", err)
+	}
+
+	// Downsample and expire old depth data in the background
+	retentionRepo, err := mongodb.NewMongoRetentionRepository(
+		repo.Client,
+		cfg.DatabaseName,
+		cfg.CollectionName,
+		cfg.SnapshotCollection,
+		cfg.CandleCollection,
+	)
+	if err != nil {
+		log.Fatalf("Failed to create MongoDB retention repository: %v", err)
+	}
+
+	retention := core.NewRetentionService(
+		retentionRepo,
+		cfg.Symbols,
+		cfg.Retention,
+		cfg.RetentionOverrides,
+		cfg.RetentionInterval,
+	)
+	go retention.Run(ctx)
+
 	// Initialize Binance WebSocket client
-	wsClient := binance.NewBinanceWebSocket(cfg.BinanceWSURL, cfg.MaxConnectionRetry, cfg.RetryDelay)
+	wsClient := binance.NewBinanceWebSocket(
+		cfg.BinanceWSURL,
+		cfg.MaxConnectionRetry,
+		helpers.Backoff{Initial: cfg.RetryDelay, Max: cfg.RetryMaxDelay},
+		cfg.MaxConnectionAge,
+		cfg.ReadTimeout,
+	)
+
+	// Initialize Binance REST client
+	restClient := binance.NewBinanceRestClient(cfg.BinanceRestURL, cfg.RestTimeout)
+
+	var (
+		streamClient     core.WebSocketClient       = wsClient
+		snapshotProvider core.DepthSnapshotProvider = restClient
+	)
+
+	// Optionally record every raw frame and depth snapshot for offline replay
+	if cfg.RecordDir != "" {
+		frameRecorder, err := recorder.NewRecorder(cfg.RecordDir, cfg.RecordRotate)
+		if err != nil {
+			log.Fatalf("Failed to create frame recorder: %v", err)
+		}
+
+		streamClient = recorder.NewRecordingClient(wsClient, frameRecorder)
+		snapshotProvider = recorder.NewRecordingSnapshotProvider(restClient, frameRecorder)
+	}
+
+	books := core.NewOrderBookManager(snapshotProvider, cfg.DepthSnapshotLimit)
 
 	// Create a new Fiber app
 	app := fiber.New(fiber.Config{
@@ -49,17 +155,26 @@ func main() {
 	})
 
 	// Register health check endpoint
-	app.Get("/healthcheck", func(_ *fiber.Ctx) error {
-		healthcheck.CheckHandler(repo.Client)
-
-		return nil
-	})
+	app.Get("/healthcheck", adaptor.HTTPHandlerFunc(healthcheck.CheckHandler(repo.Client, wsClient, walLog)))
 
 	// Start health check endpoint
 	go startHealthCheckEndpoint(app)
 
 	// Create and run service
-	service := core.NewDataCollectorService(wsClient, repo)
+	service := core.NewDataCollectorService(
+		streamClient,
+		repo,
+		tradeRepo,
+		eventRepo,
+		gapRepo,
+		snapshotRepo,
+		books,
+		core.ServiceConfig{
+			Streams:          core.StreamNames(cfg.Symbols, cfg.StreamTypes),
+			SnapshotInterval: cfg.SnapshotInterval,
+			SnapshotDepth:    cfg.SnapshotDepth,
+		},
+	)
 	if err := service.Run(ctx); err != nil {
 		log.Printf("Service failed: %v", err)
 
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mkaganm/algo-trade/collector/internal/adapters/replay"
+)
+
+const readHeaderTimeout = 5 * time.Second
+
+// The replay command serves recordings made with RECORD_DIR over a local
+// WebSocket endpoint, together with the depth snapshots recorded next to the
+// frames. Point the collector at it with BINANCE_WS_URL=ws://localhost:9443/stream
+// and BINANCE_REST_URL=http://localhost:9443 to run the pipeline offline.
+func main() {
+	dir := flag.String("dir", "./recordings", "directory containing frames-*.ndjson.gz recordings")
+	addr := flag.String("addr", ":9443", "address to listen on")
+	speed := flag.Float64("speed", 1, "replay speed multiplier, 0 replays as fast as possible")
+	loop := flag.Bool("loop", false, "start the recordings over once they end instead of idling")
+	flag.Parse()
+
+	files, err := replay.Files(*dir)
+	if err != nil {
+		log.Fatalf("Failed to list recordings: %v", err)
+	}
+
+	if len(files) == 0 {
+		log.Fatalf("No recordings found in %s", *dir)
+	}
+
+	replayServer, err := replay.NewServer(files, *speed, *loop)
+	if err != nil {
+		log.Fatalf("Failed to load recordings: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/stream", replayServer)
+	mux.Handle("/ws", replayServer)
+	mux.HandleFunc("/api/v3/depth", replayServer.ServeDepth)
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	log.Printf("Replaying %d recordings from %s on %s", len(files), *dir, *addr)
+
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatalf("Replay server failed: %v", err)
+	}
+}
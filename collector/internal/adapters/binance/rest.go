@@ -0,0 +1,59 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mkaganm/algo-trade/collector/internal/core"
+)
+
+const depthPath = "/api/v3/depth"
+
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+type RestClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewBinanceRestClient(baseURL string, timeout time.Duration) *RestClient {
+	return &RestClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// GetDepthSnapshot fetches the current order book of symbol from the REST API.
+func (c *RestClient) GetDepthSnapshot(ctx context.Context, symbol string, limit int) (core.DepthSnapshot, error) {
+	query := url.Values{}
+	query.Set("symbol", symbol)
+	query.Set("limit", strconv.Itoa(limit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+depthPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return core.DepthSnapshot{}, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return core.DepthSnapshot{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return core.DepthSnapshot{}, fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+
+	var snapshot core.DepthSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return core.DepthSnapshot{}, fmt.Errorf("failed to decode depth snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
@@ -1,70 +1,364 @@
 package binance
 
 import (
+	"errors"
+	"log"
+	"net"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/mkaganm/algo-trade/collector/internal/core"
 	"github.com/mkaganm/algo-trade/collector/internal/helpers"
 )
 
+const (
+	methodSubscribe   = "SUBSCRIBE"
+	methodUnsubscribe = "UNSUBSCRIBE"
+	controlWriteWait  = 10 * time.Second
+)
+
+var (
+	ErrNotConnected     = errors.New("websocket is not connected")
+	errConnectionClosed = errors.New("websocket closed")
+)
+
+// WebSocket is a self-healing Binance stream connection. A supervisor goroutine
+// reconnects with jittered exponential backoff whenever the connection drops,
+// re-sends all subscriptions and proactively replaces the connection before it
+// reaches Binance's 24h connection limit.
 type WebSocket struct {
-	url        string
-	conn       *websocket.Conn
-	maxRetries int
-	retryDelay time.Duration
+	url              string
+	maxRetries       int
+	backoff          helpers.Backoff
+	maxConnectionAge time.Duration
+	readTimeout      time.Duration
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	streams []string
+	status  core.ConnectionStatus
+	closed  bool
+	done    chan struct{}
+
+	writeMu   sync.Mutex
+	requestID int64
+}
+
+// request is a Binance WebSocket stream control request.
+type request struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int64    `json:"id"`
 }
 
-func NewBinanceWebSocket(url string, maxRetries int, retryDelay time.Duration) *WebSocket {
+func NewBinanceWebSocket(
+	url string,
+	maxRetries int,
+	backoff helpers.Backoff,
+	maxConnectionAge time.Duration,
+	readTimeout time.Duration,
+) *WebSocket {
 	return &WebSocket{
-		url:        url,
-		conn:       nil,
-		maxRetries: maxRetries,
-		retryDelay: retryDelay,
+		url:              url,
+		maxRetries:       maxRetries,
+		backoff:          backoff,
+		maxConnectionAge: maxConnectionAge,
+		readTimeout:      readTimeout,
+		conn:             nil,
+		status:           core.ConnectionStatus{State: core.ConnectionConnecting},
+		done:             make(chan struct{}),
 	}
 }
 
+// Connect dials the initial connection, giving up after maxRetries attempts.
 func (b *WebSocket) Connect() error {
-	conn, err := helpers.RetryWebSocket(b.maxRetries, b.retryDelay, b.url)
+	conn, err := helpers.RetryWebSocket(b.maxRetries, b.backoff.Initial, b.url)
 	if err != nil {
+		b.setState(core.ConnectionClosed, err)
+
 		return err
 	}
 
+	b.mu.Lock()
 	b.conn = conn
+	b.mu.Unlock()
+
+	b.activate(conn)
 
 	return nil
 }
 
+// Subscribe subscribes the connection to the given "<symbol>@<type>" streams.
+// Subscriptions are remembered and re-sent after every reconnect.
+func (b *WebSocket) Subscribe(streams []string) error {
+	b.mu.Lock()
+
+	for _, stream := range streams {
+		if !slices.Contains(b.streams, stream) {
+			b.streams = append(b.streams, stream)
+		}
+	}
+
+	conn := b.conn
+	b.mu.Unlock()
+
+	return b.send(conn, methodSubscribe, streams)
+}
+
+// Unsubscribe removes the given streams from the connection.
+func (b *WebSocket) Unsubscribe(streams []string) error {
+	b.mu.Lock()
+
+	b.streams = slices.DeleteFunc(b.streams, func(stream string) bool {
+		return slices.Contains(streams, stream)
+	})
+
+	conn := b.conn
+	b.mu.Unlock()
+
+	return b.send(conn, methodUnsubscribe, streams)
+}
+
+func (b *WebSocket) send(conn *websocket.Conn, method string, streams []string) error {
+	if conn == nil {
+		return ErrNotConnected
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	b.requestID++
+
+	return conn.WriteJSON(request{
+		Method: method,
+		Params: streams,
+		ID:     b.requestID,
+	})
+}
+
+// ReadMessages starts the supervisor. The message channel stays open across
+// reconnects and is only closed once the client is closed.
 func (b *WebSocket) ReadMessages() (<-chan []byte, <-chan error) {
 	msgChan := make(chan []byte)
 	errChan := make(chan error)
 
-	go b.readMessagesGoroutine(msgChan, errChan)
+	go b.supervise(msgChan, errChan)
 
 	return msgChan, errChan
 }
 
-func (b *WebSocket) readMessagesGoroutine(msgChan chan<- []byte, errChan chan<- error) {
+func (b *WebSocket) supervise(msgChan chan<- []byte, errChan chan<- error) {
 	defer close(msgChan)
 	defer close(errChan)
 	defer helpers.RecoverRoutine(errChan)
 
 	for {
-		_, message, err := b.conn.ReadMessage()
-		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				errChan <- err
-			}
+		conn := b.currentConn()
+		err := b.readLoop(conn, msgChan)
+
+		if b.isClosed() {
+			return
+		}
+
+		// The connection was replaced by a proactive rotation, keep reading the new one.
+		if b.currentConn() != conn {
+			continue
+		}
+
+		log.Printf("WebSocket connection lost: %v", err)
+		b.setState(core.ConnectionReconnecting, err)
 
+		_ = conn.Close()
+
+		next, ok := b.dial()
+		if !ok {
 			return
 		}
 
-		msgChan <- message
+		if !b.swap(conn, next) {
+			_ = next.Close()
+
+			continue
+		}
+
+		b.resubscribe(next)
+	}
+}
+
+func (b *WebSocket) readLoop(conn *websocket.Conn, msgChan chan<- []byte) error {
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(b.readTimeout))
+
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case msgChan <- message:
+		case <-b.done:
+			return errConnectionClosed
+		}
+	}
+}
+
+// dial reconnects forever with jittered exponential backoff until it succeeds
+// or the client is closed.
+func (b *WebSocket) dial() (*websocket.Conn, bool) {
+	for attempt := 0; ; attempt++ {
+		conn, _, err := websocket.DefaultDialer.Dial(b.url, nil)
+		if err == nil {
+			return conn, true
+		}
+
+		delay := b.backoff.Delay(attempt)
+		log.Printf("Reconnect attempt %d failed: %v, retrying in %s", attempt+1, err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-b.done:
+			return nil, false
+		}
 	}
 }
 
+// rotate replaces old with a fresh, already subscribed connection before
+// Binance drops it at the connection age limit.
+func (b *WebSocket) rotate(old *websocket.Conn) {
+	if b.isClosed() || b.currentConn() != old {
+		return
+	}
+
+	log.Printf("WebSocket connection reached max age %s, rotating", b.maxConnectionAge)
+
+	next, ok := b.dial()
+	if !ok {
+		return
+	}
+
+	b.resubscribe(next)
+
+	if !b.swap(old, next) {
+		_ = next.Close()
+
+		return
+	}
+
+	_ = old.Close()
+}
+
+// swap makes next the active connection if old is still the active one.
+func (b *WebSocket) swap(old, next *websocket.Conn) bool {
+	b.mu.Lock()
+
+	if b.closed || b.conn != old {
+		b.mu.Unlock()
+
+		return false
+	}
+
+	b.conn = next
+	b.status.Reconnects++
+	b.mu.Unlock()
+
+	b.activate(next)
+
+	return true
+}
+
+// activate marks conn as connected, answers its pings and schedules its rotation.
+func (b *WebSocket) activate(conn *websocket.Conn) {
+	conn.SetPingHandler(func(data string) error {
+		_ = conn.SetReadDeadline(time.Now().Add(b.readTimeout))
+
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(controlWriteWait))
+
+		var netErr net.Error
+		if errors.Is(err, websocket.ErrCloseSent) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return nil
+		}
+
+		return err
+	})
+
+	b.mu.Lock()
+	b.status.State = core.ConnectionConnected
+	b.status.ConnectedSince = time.Now()
+	b.mu.Unlock()
+
+	time.AfterFunc(b.maxConnectionAge, func() { b.rotate(conn) })
+}
+
+func (b *WebSocket) resubscribe(conn *websocket.Conn) {
+	b.mu.Lock()
+	streams := slices.Clone(b.streams)
+	b.mu.Unlock()
+
+	if len(streams) == 0 {
+		return
+	}
+
+	if err := b.send(conn, methodSubscribe, streams); err != nil {
+		log.Printf("Failed to resubscribe to streams: %v", err)
+
+		return
+	}
+
+	log.Printf("Resubscribed to %d streams", len(streams))
+}
+
+// Status returns the current connection state.
+func (b *WebSocket) Status() core.ConnectionStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.status
+}
+
+func (b *WebSocket) setState(state core.ConnectionState, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.status.State = state
+	if err != nil {
+		b.status.LastError = err.Error()
+	}
+}
+
+func (b *WebSocket) currentConn() *websocket.Conn {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.conn
+}
+
+func (b *WebSocket) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.closed
+}
+
 func (b *WebSocket) Close() error {
-	if b.conn != nil {
-		return b.conn.Close()
+	b.mu.Lock()
+
+	if b.closed {
+		b.mu.Unlock()
+
+		return nil
+	}
+
+	b.closed = true
+	b.status.State = core.ConnectionClosed
+	close(b.done)
+
+	conn := b.conn
+	b.mu.Unlock()
+
+	if conn != nil {
+		return conn.Close()
 	}
 
 	return nil
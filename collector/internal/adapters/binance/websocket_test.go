@@ -0,0 +1,88 @@
+package binance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mkaganm/algo-trade/collector/internal/core"
+	"github.com/mkaganm/algo-trade/collector/internal/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDroppingServer accepts connections, waits for a SUBSCRIBE request, sends a
+// single message and then drops the connection.
+func newDroppingServer(t *testing.T, requests chan<- request) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var req request
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+
+		requests <- req
+
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"btcusdt@trade","data":{}}`))
+	}))
+}
+
+func TestWebSocketReconnectsAndResubscribes(t *testing.T) {
+	requests := make(chan request, 10)
+	server := newDroppingServer(t, requests)
+	defer server.Close()
+
+	client := NewBinanceWebSocket(
+		"ws"+strings.TrimPrefix(server.URL, "http"),
+		1,
+		helpers.Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond},
+		time.Hour,
+		time.Second,
+	)
+	defer client.Close()
+
+	require.NoError(t, client.Connect())
+
+	msgChan, _ := client.ReadMessages()
+
+	require.NoError(t, client.Subscribe([]string{"btcusdt@trade"}))
+
+	for range 2 {
+		select {
+		case req := <-requests:
+			assert.Equal(t, methodSubscribe, req.Method)
+			assert.Equal(t, []string{"btcusdt@trade"}, req.Params)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for subscription")
+		}
+
+		select {
+		case message := <-msgChan:
+			assert.Contains(t, string(message), "btcusdt@trade")
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+	}
+
+	assert.Positive(t, client.Status().Reconnects)
+}
+
+func TestWebSocketCloseReportsClosedState(t *testing.T) {
+	client := NewBinanceWebSocket("ws://localhost", 1, helpers.Backoff{}, time.Hour, time.Second)
+
+	require.NoError(t, client.Close())
+
+	assert.Equal(t, core.ConnectionClosed, client.Status().State)
+}
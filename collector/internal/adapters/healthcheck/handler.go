@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/mkaganm/algo-trade/collector/internal/core"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -14,21 +15,62 @@ const (
 )
 
 type Response struct {
-	Status  string `json:"status"`
-	Details string `json:"details,omitempty"`
+	Status     string                 `json:"status"`
+	Details    string                 `json:"details,omitempty"`
+	Connection *core.ConnectionStatus `json:"connection,omitempty"`
+	Backlog    *Backlog               `json:"backlog,omitempty"`
 }
 
-func CheckHandler(client *mongo.Client) http.HandlerFunc {
+// Backlog is the amount of data spilled to disk that is not yet in MongoDB.
+type Backlog struct {
+	Documents int64 `json:"documents"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// ConnectionStatusProvider reports the state of the stream connection.
+type ConnectionStatusProvider interface {
+	Status() core.ConnectionStatus
+}
+
+// BacklogProvider reports the size of the write-ahead log.
+type BacklogProvider interface {
+	Pending() int64
+	Size() int64
+}
+
+func CheckHandler(
+	client *mongo.Client,
+	connection ConnectionStatusProvider,
+	backlog BacklogProvider,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), timeout)
 		defer cancel()
 
+		status := connection.Status()
+		spooled := &Backlog{Documents: backlog.Pending(), Bytes: backlog.Size()}
+
 		// Check MongoDB connection
 		if err := client.Ping(ctx, nil); err != nil {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			_ = json.NewEncoder(w).Encode(Response{
-				Status:  "unhealthy",
-				Details: "Cannot connect to MongoDB",
+				Status:     "unhealthy",
+				Details:    "Cannot connect to MongoDB",
+				Connection: &status,
+				Backlog:    spooled,
+			})
+
+			return
+		}
+
+		// Check Binance stream connection
+		if status.State != core.ConnectionConnected {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(Response{
+				Status:     "unhealthy",
+				Details:    "Binance stream is " + string(status.State),
+				Connection: &status,
+				Backlog:    spooled,
 			})
 
 			return
@@ -37,8 +79,10 @@ func CheckHandler(client *mongo.Client) http.HandlerFunc {
 		// If all checks pass
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(Response{
-			Status:  "healthy",
-			Details: "All systems operational",
+			Status:     "healthy",
+			Details:    "All systems operational",
+			Connection: &status,
+			Backlog:    spooled,
 		})
 	}
 }
@@ -0,0 +1,217 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const flushTimeout = 10 * time.Second
+
+type OverflowPolicy string
+
+const (
+	// OverflowBlock makes writers wait for free space in the queue.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDrop discards documents while the queue is full.
+	OverflowDrop OverflowPolicy = "drop"
+)
+
+var (
+	ErrQueueFull          = errors.New("batch writer queue is full")
+	ErrWriterClosed       = errors.New("batch writer is closed")
+	ErrInvalidBatchConfig = errors.New("invalid batch writer config")
+)
+
+// Spool is a durable fallback that keeps documents Mongo failed to accept.
+type Spool interface {
+	Append(collection string, docs []interface{}) error
+}
+
+type BatchConfig struct {
+	Size          int
+	FlushInterval time.Duration
+	QueueSize     int
+	Overflow      OverflowPolicy
+	Spool         Spool // optional
+}
+
+func (c BatchConfig) Validate() error {
+	if c.Size <= 0 || c.FlushInterval <= 0 || c.QueueSize <= 0 {
+		return fmt.Errorf("%w: size, flush interval and queue size must be positive", ErrInvalidBatchConfig)
+	}
+
+	if c.Overflow != OverflowBlock && c.Overflow != OverflowDrop {
+		return fmt.Errorf("%w: unknown overflow policy %q", ErrInvalidBatchConfig, c.Overflow)
+	}
+
+	return nil
+}
+
+// Inserter is the part of *mongo.Collection used by the batch writer.
+type Inserter interface {
+	Name() string
+	InsertMany(ctx context.Context, documents []interface{},
+		opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+}
+
+// BatchWriter queues documents and inserts them with InsertMany from its own
+// goroutine whenever the batch is full or the flush interval elapses, so slow
+// inserts never block the WebSocket read loop. Every batch is inserted
+// directly and spilled to the spool only when the insert fails, so the
+// replayer restores the backlog alongside the live writes. Documents get
+// their _id before the first attempt, which makes the replay idempotent.
+type BatchWriter struct {
+	inserter Inserter
+	config   BatchConfig
+	queue    chan interface{}
+	done     chan struct{}
+	dropped  atomic.Int64
+	mu       sync.RWMutex
+	closed   bool
+	onError  func(docs []interface{}, err error)
+}
+
+func NewBatchWriter(inserter Inserter, config BatchConfig) *BatchWriter {
+	w := &BatchWriter{
+		inserter: inserter,
+		config:   config,
+		queue:    make(chan interface{}, config.QueueSize),
+		done:     make(chan struct{}),
+		onError: func(docs []interface{}, err error) {
+			log.Printf("Failed to insert batch of %d documents: %v", len(docs), err)
+		},
+	}
+
+	go w.run()
+
+	return w
+}
+
+// Write enqueues a document, blocking or dropping according to the overflow policy.
+func (w *BatchWriter) Write(ctx context.Context, doc interface{}) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	if w.config.Overflow == OverflowDrop {
+		select {
+		case w.queue <- doc:
+			return nil
+		default:
+			w.dropped.Add(1)
+
+			return ErrQueueFull
+		}
+	}
+
+	select {
+	case w.queue <- doc:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dropped returns the number of documents discarded by the drop policy.
+func (w *BatchWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Close stops accepting documents and waits until the queue is flushed.
+func (w *BatchWriter) Close() error {
+	w.mu.Lock()
+
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+
+	w.mu.Unlock()
+
+	<-w.done
+
+	return nil
+}
+
+func (w *BatchWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]interface{}, 0, w.config.Size)
+
+	for {
+		select {
+		case doc, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+
+				return
+			}
+
+			batch = append(batch, doc)
+
+			if len(batch) >= w.config.Size {
+				w.flush(batch)
+				batch = make([]interface{}, 0, w.config.Size)
+			}
+
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = make([]interface{}, 0, w.config.Size)
+			}
+		}
+	}
+}
+
+func (w *BatchWriter) flush(batch []interface{}) {
+	if len(batch) == 0 {
+		return
+	}
+
+	for i, doc := range batch {
+		batch[i] = withID(doc)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	rejected, err := insertDocuments(ctx, w.inserter, batch)
+	if err == nil {
+		// Spilling rejected documents would only fail again on replay
+		for _, index := range rejected {
+			w.onError(batch[index:index+1], ErrDocumentRejected)
+		}
+
+		return
+	}
+
+	if w.config.Spool == nil {
+		w.onError(batch, err)
+
+		return
+	}
+
+	log.Printf("Failed to insert batch into %s, spilling %d documents to disk: %v",
+		w.inserter.Name(), len(batch), err)
+	w.spill(batch)
+}
+
+func (w *BatchWriter) spill(batch []interface{}) {
+	if err := w.config.Spool.Append(w.inserter.Name(), batch); err != nil {
+		w.onError(batch, err)
+	}
+}
@@ -0,0 +1,156 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/collector/internal/adapters/wal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type fakeInserter struct {
+	mu      sync.Mutex
+	batches [][]interface{}
+	block   chan struct{}
+	err     error
+}
+
+var errInsertFailed = errors.New("insert failed")
+
+func (f *fakeInserter) Name() string {
+	return "fake"
+}
+
+func (f *fakeInserter) InsertMany(
+	_ context.Context,
+	documents []interface{},
+	_ ...*options.InsertManyOptions,
+) (*mongo.InsertManyResult, error) {
+	if f.block != nil {
+		<-f.block
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	f.batches = append(f.batches, documents)
+
+	return &mongo.InsertManyResult{}, nil
+}
+
+func (f *fakeInserter) fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = err
+}
+
+func (f *fakeInserter) sizes() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sizes := make([]int, 0, len(f.batches))
+	for _, batch := range f.batches {
+		sizes = append(sizes, len(batch))
+	}
+
+	return sizes
+}
+
+func TestBatchWriterFlushesOnSizeAndClose(t *testing.T) {
+	inserter := &fakeInserter{}
+	writer := NewBatchWriter(inserter, BatchConfig{
+		Size: 3, FlushInterval: time.Hour, QueueSize: 10, Overflow: OverflowBlock,
+	})
+
+	for i := range 7 {
+		require.NoError(t, writer.Write(context.Background(), i))
+	}
+
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, []int{3, 3, 1}, inserter.sizes())
+	assert.ErrorIs(t, writer.Write(context.Background(), 8), ErrWriterClosed)
+}
+
+func TestBatchWriterFlushesOnInterval(t *testing.T) {
+	inserter := &fakeInserter{}
+	writer := NewBatchWriter(inserter, BatchConfig{
+		Size: 100, FlushInterval: 10 * time.Millisecond, QueueSize: 10, Overflow: OverflowBlock,
+	})
+	defer writer.Close()
+
+	require.NoError(t, writer.Write(context.Background(), 1))
+
+	assert.Eventually(t, func() bool {
+		return len(inserter.sizes()) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBatchWriterDropPolicy(t *testing.T) {
+	inserter := &fakeInserter{block: make(chan struct{})}
+	writer := NewBatchWriter(inserter, BatchConfig{
+		Size: 1, FlushInterval: time.Hour, QueueSize: 1, Overflow: OverflowDrop,
+	})
+
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = writer.Write(context.Background(), i)
+	}
+
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.Equal(t, int64(1), writer.Dropped())
+
+	close(inserter.block)
+	require.NoError(t, writer.Close())
+}
+
+func TestBatchWriterSpillsOnlyFailedBatches(t *testing.T) {
+	spool, err := wal.Open(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+
+	inserter := &fakeInserter{}
+	writer := NewBatchWriter(inserter, BatchConfig{
+		Size: 2, FlushInterval: time.Hour, QueueSize: 10, Overflow: OverflowBlock, Spool: spool,
+	})
+
+	inserter.fail(errInsertFailed)
+
+	for i := range 2 {
+		require.NoError(t, writer.Write(context.Background(), bson.M{"n": i}))
+	}
+
+	assert.Eventually(t, func() bool {
+		return spool.Pending() == 2
+	}, time.Second, 5*time.Millisecond)
+
+	// With a backlog on disk the next batch still goes to Mongo first
+	inserter.fail(nil)
+
+	for i := range 2 {
+		require.NoError(t, writer.Write(context.Background(), bson.M{"n": i + 2}))
+	}
+
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, []int{2}, inserter.sizes())
+	assert.Equal(t, int64(2), spool.Pending())
+	assert.NotNil(t, inserter.batches[0][0].(bson.M)["_id"])
+}
+
+func TestBatchConfigValidate(t *testing.T) {
+	assert.NoError(t, BatchConfig{Size: 1, FlushInterval: time.Second, QueueSize: 1, Overflow: OverflowDrop}.Validate())
+	assert.ErrorIs(t, BatchConfig{Size: 1, FlushInterval: time.Second, QueueSize: 1}.Validate(), ErrInvalidBatchConfig)
+	assert.ErrorIs(t, BatchConfig{Overflow: OverflowBlock}.Validate(), ErrInvalidBatchConfig)
+}
@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mkaganm/algo-trade/collector/internal/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoStreamEventRepository stores every stream type in its own collection,
+// with one batch writer per collection.
+type MongoStreamEventRepository struct {
+	Client           *mongo.Client
+	Database         string
+	CollectionPrefix string
+	batch            BatchConfig
+	mu               sync.Mutex
+	writers          map[string]*BatchWriter
+}
+
+func NewMongoStreamEventRepository(
+	client *mongo.Client,
+	database, collectionPrefix string,
+	batch BatchConfig,
+) (*MongoStreamEventRepository, error) {
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &MongoStreamEventRepository{
+		Client:           client,
+		Database:         database,
+		CollectionPrefix: collectionPrefix,
+		batch:            batch,
+		writers:          make(map[string]*BatchWriter),
+	}, nil
+}
+
+// SaveEvent queues the event for the next batched insert into its stream collection.
+func (m *MongoStreamEventRepository) SaveEvent(ctx context.Context, event core.StreamEvent) error {
+	return m.writer(m.CollectionPrefix+event.StreamType).Write(ctx, bson.M{
+		"symbol":    event.Symbol,
+		"data":      event.Data,
+		"timestamp": event.Timestamp,
+	})
+}
+
+func (m *MongoStreamEventRepository) writer(collection string) *BatchWriter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writer, ok := m.writers[collection]
+	if !ok {
+		writer = NewBatchWriter(m.Client.Database(m.Database).Collection(collection), m.batch)
+		m.writers[collection] = writer
+	}
+
+	return writer
+}
+
+// Close flushes pending writes. The client is closed by the order book repository.
+func (m *MongoStreamEventRepository) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for _, writer := range m.writers {
+		errs = append(errs, writer.Close())
+	}
+
+	return errors.Join(errs...)
+}
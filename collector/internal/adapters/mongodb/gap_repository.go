@@ -0,0 +1,47 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/mkaganm/algo-trade/collector/internal/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoGapRepository struct {
+	Client     *mongo.Client
+	Database   string
+	Collection string
+}
+
+// NewMongoGapRepository shares the client of the order book repository and
+// indexes gaps by symbol and window so readers can exclude them cheaply.
+func NewMongoGapRepository(client *mongo.Client, database, collection string) (*MongoGapRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "symbol", Value: 1}, {Key: "from", Value: 1}},
+		Options: options.Index(),
+	}
+
+	_, err := client.Database(database).Collection(collection).Indexes().CreateOne(ctx, indexModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoGapRepository{
+		Client:     client,
+		Database:   database,
+		Collection: collection,
+	}, nil
+}
+
+func (m *MongoGapRepository) SaveGap(ctx context.Context, gap core.SequenceGap) error {
+	coll := m.Client.Database(m.Database).Collection(m.Collection)
+
+	_, err := coll.InsertOne(ctx, gap)
+
+	return err
+}
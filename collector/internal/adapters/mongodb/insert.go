@@ -0,0 +1,107 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+)
+
+const duplicateKeyCode = 11000
+
+var ErrDocumentRejected = errors.New("document rejected by MongoDB")
+
+// withID returns doc with an _id of its own, so that inserting it again after
+// an insert with an unknown outcome fails as a duplicate instead of storing
+// it twice. Documents that do not encode are returned as they are.
+func withID(doc interface{}) interface{} {
+	if m, ok := doc.(bson.M); ok {
+		if _, found := m["_id"]; !found {
+			m["_id"] = primitive.NewObjectID()
+		}
+
+		return m
+	}
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return doc
+	}
+
+	if _, err := bson.Raw(raw).LookupErr("_id"); err == nil {
+		return bson.Raw(raw)
+	}
+
+	var fields bson.D
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return doc
+	}
+
+	return append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, fields...)
+}
+
+// insertDocuments inserts docs unordered and skips the ones whose _id exists
+// already, so a batch can be inserted again safely. It returns the indexes of
+// the documents MongoDB rejects for good; err is only set when the whole
+// batch may succeed on a retry.
+func insertDocuments(ctx context.Context, inserter Inserter, docs []interface{}) ([]int, error) {
+	_, err := inserter.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if errors.Is(err, driver.ErrDocumentTooLarge) {
+		return insertEach(ctx, inserter, docs)
+	}
+
+	return rejectedDocuments(err)
+}
+
+// insertEach inserts docs one at a time to single out the ones too large to
+// insert at all.
+func insertEach(ctx context.Context, inserter Inserter, docs []interface{}) ([]int, error) {
+	var rejected []int
+
+	for i := range docs {
+		_, err := inserter.InsertMany(ctx, docs[i:i+1], options.InsertMany().SetOrdered(false))
+		if errors.Is(err, driver.ErrDocumentTooLarge) {
+			rejected = append(rejected, i)
+
+			continue
+		}
+
+		failed, err := rejectedDocuments(err)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(failed) > 0 {
+			rejected = append(rejected, i)
+		}
+	}
+
+	return rejected, nil
+}
+
+// rejectedDocuments splits the error of an unordered insert into the
+// documents the server rejected, ignoring duplicates, and errors worth a retry.
+func rejectedDocuments(err error) ([]int, error) {
+	if err == nil {
+		return nil, nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return nil, err
+	}
+
+	var rejected []int
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != duplicateKeyCode {
+			rejected = append(rejected, writeErr.Index)
+		}
+	}
+
+	return rejected, nil
+}
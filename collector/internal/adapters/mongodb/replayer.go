@@ -0,0 +1,113 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mkaganm/algo-trade/collector/internal/adapters/wal"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Replayer drains the write-ahead log into Mongo, oldest segment first, once
+// the database answers pings again.
+type Replayer struct {
+	client     *mongo.Client
+	collection func(name string) Inserter
+	wal        *wal.Log
+	interval   time.Duration
+	batchSize  int
+}
+
+func NewReplayer(client *mongo.Client, database string, log *wal.Log, interval time.Duration, batchSize int) *Replayer {
+	return &Replayer{
+		client: client,
+		collection: func(name string) Inserter {
+			return client.Database(database).Collection(name)
+		},
+		wal:       log,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+func (r *Replayer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.drain(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Replayer) drain(ctx context.Context) {
+	pending := r.wal.Pending()
+	if pending == 0 {
+		return
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, closeTimeout)
+	defer cancel()
+
+	if err := r.client.Ping(pingCtx, nil); err != nil {
+		log.Printf("MongoDB unavailable, %d spooled documents waiting: %v", pending, err)
+
+		return
+	}
+
+	r.replay(ctx)
+}
+
+// replay inserts the sealed segments, stopping at the first batch that may
+// succeed on a later attempt.
+func (r *Replayer) replay(ctx context.Context) {
+	if err := r.wal.Rotate(); err != nil {
+		log.Printf("Failed to rotate write-ahead log: %v", err)
+
+		return
+	}
+
+	segments, err := r.wal.Sealed()
+	if err != nil {
+		log.Printf("Failed to list write-ahead log segments: %v", err)
+
+		return
+	}
+
+	for _, segment := range segments {
+		if err := r.wal.Replay(segment, r.batchSize, r.insert(ctx)); err != nil {
+			log.Printf("Failed to replay write-ahead log segment %s: %v", segment, err)
+
+			return
+		}
+	}
+
+	log.Printf("Replayed spooled documents, %d still pending", r.wal.Pending())
+}
+
+// insert inserts a batch of records. Records inserted before a crash are
+// skipped as duplicates and rejected ones end up in the dead-letter file.
+func (r *Replayer) insert(ctx context.Context) func(records []wal.Record) ([]int, error) {
+	return func(records []wal.Record) ([]int, error) {
+		docs := make([]interface{}, len(records))
+		for i, record := range records {
+			docs[i] = record.Document
+		}
+
+		insertCtx, cancel := context.WithTimeout(ctx, flushTimeout)
+		defer cancel()
+
+		rejected, err := insertDocuments(insertCtx, r.collection(records[0].Collection), docs)
+		if len(rejected) > 0 {
+			log.Printf("MongoDB rejected %d spooled documents of %s, moved to %s",
+				len(rejected), records[0].Collection, r.wal.DeadLetterPath())
+		}
+
+		return rejected, err
+	}
+}
@@ -0,0 +1,119 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mkaganm/algo-trade/collector/internal/adapters/wal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const invalidDocumentCode = 2
+
+// uniqueInserter stores documents by _id like a collection, reporting
+// duplicates and documents marked as invalid as write errors.
+type uniqueInserter struct {
+	stored map[interface{}]bson.Raw
+}
+
+func (u *uniqueInserter) Name() string {
+	return "unique"
+}
+
+func (u *uniqueInserter) InsertMany(
+	_ context.Context,
+	documents []interface{},
+	_ ...*options.InsertManyOptions,
+) (*mongo.InsertManyResult, error) {
+	var bulkErr mongo.BulkWriteException
+
+	for i, doc := range documents {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		id := bson.Raw(raw).Lookup("_id").String()
+
+		switch {
+		case bson.Raw(raw).Lookup("invalid").Type == bson.TypeBoolean:
+			bulkErr.WriteErrors = append(bulkErr.WriteErrors, mongo.BulkWriteError{
+				WriteError: mongo.WriteError{Index: i, Code: invalidDocumentCode},
+			})
+		case u.stored[id] != nil:
+			bulkErr.WriteErrors = append(bulkErr.WriteErrors, mongo.BulkWriteError{
+				WriteError: mongo.WriteError{Index: i, Code: duplicateKeyCode},
+			})
+		default:
+			u.stored[id] = raw
+		}
+	}
+
+	if len(bulkErr.WriteErrors) > 0 {
+		return nil, bulkErr
+	}
+
+	return &mongo.InsertManyResult{}, nil
+}
+
+func TestReplayerInsertsSpooledDocumentsOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		docs     []interface{}
+		replayed []interface{}
+		stored   int
+		rejected int
+	}{
+		{
+			name:   "new documents",
+			docs:   []interface{}{bson.M{"_id": 1}, bson.M{"_id": 2}},
+			stored: 2,
+		},
+		{
+			name:     "inserted before a crash",
+			docs:     []interface{}{bson.M{"_id": 1}, bson.M{"_id": 2}, bson.M{"_id": 3}},
+			replayed: []interface{}{bson.M{"_id": 1}, bson.M{"_id": 2}},
+			stored:   3,
+		},
+		{
+			name:     "rejected document",
+			docs:     []interface{}{bson.M{"_id": 1}, bson.M{"_id": 2, "invalid": true}, bson.M{"_id": 3}},
+			stored:   2,
+			rejected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, err := wal.Open(t.TempDir(), 1<<20)
+			require.NoError(t, err)
+			require.NoError(t, log.Append("depth", tt.docs))
+
+			inserter := &uniqueInserter{stored: make(map[interface{}]bson.Raw)}
+			if tt.replayed != nil {
+				_, err := inserter.InsertMany(context.Background(), tt.replayed)
+				require.NoError(t, err)
+			}
+
+			replayer := NewReplayer(nil, "test", log, 0, 10)
+			replayer.collection = func(string) Inserter {
+				return inserter
+			}
+
+			replayer.replay(context.Background())
+
+			assert.Len(t, inserter.stored, tt.stored)
+			assert.Equal(t, int64(0), log.Pending())
+
+			if tt.rejected > 0 {
+				assert.FileExists(t, log.DeadLetterPath())
+			} else {
+				assert.NoFileExists(t, log.DeadLetterPath())
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/mkaganm/algo-trade/collector/internal/core"
@@ -13,16 +14,30 @@ import (
 const (
 	closeTimeout  = 5 * time.Second
 	cancelTimeout = 10 * time.Second
-	expireTTL     = 7 * 24 * 60 * 60 // 7 days
+	// legacyTTLIndex expired on "createdAt", a field documents never had.
+	legacyTTLIndex = "createdAt_1"
+
+	namespaceNotFoundCode    = 26
+	indexNotFoundCode        = 27
+	indexOptionsConflictCode = 85
 )
 
 type MongoOrderBookRepository struct {
 	Client     *mongo.Client
 	Database   string
 	Collection string
+	writer     *BatchWriter
 }
 
-func NewMongoOrderBookRepository(uri, database, collection string) (*MongoOrderBookRepository, error) {
+func NewMongoOrderBookRepository(
+	uri, database, collection string,
+	ttl time.Duration,
+	batch BatchConfig,
+) (*MongoOrderBookRepository, error) {
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
 	defer cancel()
 
@@ -35,37 +50,71 @@ func NewMongoOrderBookRepository(uri, database, collection string) (*MongoOrderB
 		Client:     client,
 		Database:   database,
 		Collection: collection,
+		writer:     NewBatchWriter(client.Database(database).Collection(collection), batch),
 	}
 
-	// create collection with TTL index
-	indexModel := mongo.IndexModel{
-		Keys:    bson.M{"createdAt": 1},
-		Options: options.Index().SetExpireAfterSeconds(expireTTL), // TTL time
-	}
-
-	_, err = client.Database(database).Collection(collection).Indexes().CreateOne(ctx, indexModel)
-	if err != nil {
+	if err := ensureTTLIndex(ctx, client.Database(database).Collection(collection), ttl); err != nil {
 		return nil, err
 	}
 
 	return repo, nil
 }
 
+// Save queues the update for the next batched insert.
 func (m *MongoOrderBookRepository) Save(ctx context.Context, update core.OrderBookUpdate) error {
-	coll := m.Client.Database(m.Database).Collection(m.Collection)
-
-	_, err := coll.InsertOne(ctx, bson.M{
+	return m.writer.Write(ctx, bson.M{
 		"data":       update.Data,
 		"timestamp":  update.Timestamp,
 		"created_at": time.Now(),
 	})
-
-	return err
 }
 
+// Close flushes pending writes and disconnects the shared client.
 func (m *MongoOrderBookRepository) Close() error {
+	_ = m.writer.Close()
+
 	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
 	defer cancel()
 
 	return m.Client.Disconnect(ctx)
 }
+
+// ensureTTLIndex expires documents ttl after their created_at time as a hard
+// upper bound on top of the per-symbol retention policy. A zero ttl disables it.
+func ensureTTLIndex(ctx context.Context, coll *mongo.Collection, ttl time.Duration) error {
+	indexes := coll.Indexes()
+
+	if _, err := indexes.DropOne(ctx, legacyTTLIndex); err != nil && !isIndexNotFound(err) {
+		return err
+	}
+
+	if ttl <= 0 {
+		return nil
+	}
+
+	seconds := int32(ttl.Seconds())
+
+	_, err := indexes.CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"created_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(seconds),
+	})
+	if !hasErrorCode(err, indexOptionsConflictCode) {
+		return err
+	}
+
+	// The index exists with a different expiry, update it in place.
+	return coll.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: coll.Name()},
+		{Key: "index", Value: bson.M{"keyPattern": bson.M{"created_at": 1}, "expireAfterSeconds": seconds}},
+	}).Err()
+}
+
+func isIndexNotFound(err error) bool {
+	return hasErrorCode(err, indexNotFoundCode) || hasErrorCode(err, namespaceNotFoundCode)
+}
+
+func hasErrorCode(err error, code int32) bool {
+	var cmdErr mongo.CommandError
+
+	return errors.As(err, &cmdErr) && cmdErr.Code == code
+}
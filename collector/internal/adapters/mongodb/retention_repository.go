@@ -0,0 +1,195 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mkaganm/algo-trade/collector/internal/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRetentionRepository expires depth updates and book snapshots and stores
+// the one-minute candles they are downsampled into.
+type MongoRetentionRepository struct {
+	Client             *mongo.Client
+	Database           string
+	DepthCollection    string
+	SnapshotCollection string
+	CandleCollection   string
+}
+
+// NewMongoRetentionRepository shares the client of the order book repository and
+// creates the indexes the retention queries rely on.
+func NewMongoRetentionRepository(
+	client *mongo.Client,
+	database, depthCollection, snapshotCollection, candleCollection string,
+) (*MongoRetentionRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	db := client.Database(database)
+
+	indexes := map[string]mongo.IndexModel{
+		depthCollection: {
+			Keys: bson.D{{Key: "data.symbol", Value: 1}, {Key: "timestamp", Value: 1}},
+		},
+		snapshotCollection: {
+			Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "timestamp", Value: 1}},
+		},
+		candleCollection: {
+			Keys:    bson.D{{Key: "symbol", Value: 1}, {Key: "openTime", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	for collection, index := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateOne(ctx, index); err != nil {
+			return nil, err
+		}
+	}
+
+	return &MongoRetentionRepository{
+		Client:             client,
+		Database:           database,
+		DepthCollection:    depthCollection,
+		SnapshotCollection: snapshotCollection,
+		CandleCollection:   candleCollection,
+	}, nil
+}
+
+func (m *MongoRetentionRepository) DeleteDepthBefore(
+	ctx context.Context,
+	symbol string,
+	before time.Time,
+) (int64, error) {
+	coll := m.Client.Database(m.Database).Collection(m.DepthCollection)
+
+	result, err := coll.DeleteMany(ctx, bson.M{
+		"data.symbol": symbol,
+		"timestamp":   bson.M{"$lt": before},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// NextBookSnapshotTime returns the time of the first snapshot at or after from.
+func (m *MongoRetentionRepository) NextBookSnapshotTime(
+	ctx context.Context,
+	symbol string,
+	from time.Time,
+) (time.Time, bool, error) {
+	coll := m.Client.Database(m.Database).Collection(m.SnapshotCollection)
+
+	filter := bson.M{
+		"symbol":    symbol,
+		"timestamp": bson.M{"$gte": from},
+	}
+
+	return findTime(ctx, coll, filter, "timestamp", 1)
+}
+
+func (m *MongoRetentionRepository) GetBookSnapshots(
+	ctx context.Context,
+	symbol string,
+	from, to time.Time,
+) ([]core.BookSnapshot, error) {
+	coll := m.Client.Database(m.Database).Collection(m.SnapshotCollection)
+
+	filter := bson.M{
+		"symbol":    symbol,
+		"timestamp": bson.M{"$gte": from, "$lt": to},
+	}
+
+	cursor, err := coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var snapshots []core.BookSnapshot
+	if err := cursor.All(ctx, &snapshots); err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+func (m *MongoRetentionRepository) DeleteBookSnapshotsBefore(
+	ctx context.Context,
+	symbol string,
+	before time.Time,
+) (int64, error) {
+	coll := m.Client.Database(m.Database).Collection(m.SnapshotCollection)
+
+	result, err := coll.DeleteMany(ctx, bson.M{
+		"symbol":    symbol,
+		"timestamp": bson.M{"$lt": before},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+func (m *MongoRetentionRepository) LatestBookCandleTime(ctx context.Context, symbol string) (time.Time, bool, error) {
+	coll := m.Client.Database(m.Database).Collection(m.CandleCollection)
+
+	return findTime(ctx, coll, bson.M{"symbol": symbol}, "openTime", -1)
+}
+
+// SaveBookCandles upserts candles so that a retried run does not duplicate them.
+func (m *MongoRetentionRepository) SaveBookCandles(ctx context.Context, candles []core.BookCandle) error {
+	coll := m.Client.Database(m.Database).Collection(m.CandleCollection)
+
+	models := make([]mongo.WriteModel, 0, len(candles))
+	for _, candle := range candles {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"symbol": candle.Symbol, "openTime": candle.OpenTime}).
+			SetReplacement(candle).
+			SetUpsert(true))
+	}
+
+	_, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+
+	return err
+}
+
+// findTime returns the time field of the first document matching filter in the
+// given sort direction.
+func findTime(
+	ctx context.Context,
+	coll *mongo.Collection,
+	filter bson.M,
+	field string,
+	order int,
+) (time.Time, bool, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: field, Value: order}}).
+		SetProjection(bson.M{field: 1})
+
+	var doc bson.M
+
+	err := coll.FindOne(ctx, filter, opts).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, false, nil
+	}
+
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	value, ok := doc[field].(primitive.DateTime)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	return value.Time(), true, nil
+}
@@ -0,0 +1,38 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/mkaganm/algo-trade/collector/internal/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoBookSnapshotRepository struct {
+	Client     *mongo.Client
+	Database   string
+	Collection string
+}
+
+// NewMongoBookSnapshotRepository shares the client of the order book repository.
+func NewMongoBookSnapshotRepository(client *mongo.Client, database, collection string) *MongoBookSnapshotRepository {
+	return &MongoBookSnapshotRepository{
+		Client:     client,
+		Database:   database,
+		Collection: collection,
+	}
+}
+
+func (m *MongoBookSnapshotRepository) SaveBookSnapshot(ctx context.Context, snapshot core.BookSnapshot) error {
+	coll := m.Client.Database(m.Database).Collection(m.Collection)
+
+	_, err := coll.InsertOne(ctx, bson.M{
+		"symbol":       snapshot.Symbol,
+		"lastUpdateId": snapshot.LastUpdateID,
+		"bids":         snapshot.Bids,
+		"asks":         snapshot.Asks,
+		"timestamp":    snapshot.Timestamp,
+	})
+
+	return err
+}
@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mkaganm/algo-trade/collector/internal/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoTradeRepository stores raw trades and aggregated trades in separate collections.
+type MongoTradeRepository struct {
+	Client             *mongo.Client
+	Database           string
+	TradeCollection    string
+	AggTradeCollection string
+	tradeWriter        *BatchWriter
+	aggTradeWriter     *BatchWriter
+}
+
+// NewMongoTradeRepository shares the client of the order book repository and
+// indexes both collections by symbol and trade time for range queries.
+func NewMongoTradeRepository(
+	client *mongo.Client,
+	database, tradeCollection, aggTradeCollection string,
+	batch BatchConfig,
+) (*MongoTradeRepository, error) {
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "symbol", Value: 1}, {Key: "tradeTime", Value: 1}},
+		Options: options.Index(),
+	}
+
+	for _, collection := range []string{tradeCollection, aggTradeCollection} {
+		_, err := client.Database(database).Collection(collection).Indexes().CreateOne(ctx, indexModel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &MongoTradeRepository{
+		Client:             client,
+		Database:           database,
+		TradeCollection:    tradeCollection,
+		AggTradeCollection: aggTradeCollection,
+		tradeWriter:        NewBatchWriter(client.Database(database).Collection(tradeCollection), batch),
+		aggTradeWriter:     NewBatchWriter(client.Database(database).Collection(aggTradeCollection), batch),
+	}, nil
+}
+
+// SaveTrade queues the trade for the next batched insert.
+func (m *MongoTradeRepository) SaveTrade(ctx context.Context, trade core.Trade) error {
+	if trade.Aggregated {
+		return m.aggTradeWriter.Write(ctx, trade)
+	}
+
+	return m.tradeWriter.Write(ctx, trade)
+}
+
+// Close flushes pending writes. The client is closed by the order book repository.
+func (m *MongoTradeRepository) Close() error {
+	return errors.Join(m.tradeWriter.Close(), m.aggTradeWriter.Close())
+}
@@ -0,0 +1,200 @@
+package recorder
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mkaganm/algo-trade/collector/internal/core"
+)
+
+const (
+	filePrefix  = "frames-"
+	fileExt     = ".ndjson.gz"
+	fileTimeFmt = "20060102T150405Z"
+	dirPerm     = 0o755
+	filePerm    = 0o644
+)
+
+// Frame is one line of a recording: a raw WebSocket frame and when it arrived.
+// Frames with a symbol hold a REST depth snapshot of that symbol instead.
+type Frame struct {
+	ReceivedAt time.Time       `json:"ts"`
+	Symbol     string          `json:"symbol,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Recorder writes raw frames to gzip-compressed NDJSON files, starting a new
+// file every rotate interval.
+type Recorder struct {
+	dir    string
+	rotate time.Duration
+
+	mu       sync.Mutex
+	file     *os.File
+	gzip     *gzip.Writer
+	buf      *bufio.Writer
+	openedAt time.Time
+}
+
+func NewRecorder(dir string, rotate time.Duration) (*Recorder, error) {
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	return &Recorder{
+		dir:    dir,
+		rotate: rotate,
+	}, nil
+}
+
+// Record appends a frame received at the given time.
+func (r *Recorder) Record(data []byte, receivedAt time.Time) error {
+	return r.write(Frame{ReceivedAt: receivedAt, Data: data})
+}
+
+// RecordSnapshot appends the REST depth snapshot of symbol received at the
+// given time, so that a replay can serve it alongside the frames.
+func (r *Recorder) RecordSnapshot(symbol string, data []byte, receivedAt time.Time) error {
+	return r.write(Frame{ReceivedAt: receivedAt, Symbol: symbol, Data: data})
+}
+
+func (r *Recorder) write(frame Frame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil || frame.ReceivedAt.Sub(r.openedAt) >= r.rotate {
+		if err := r.openLocked(frame.ReceivedAt); err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(frame)
+	if err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
+	}
+
+	if _, err := r.buf.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.closeLocked()
+}
+
+func (r *Recorder) openLocked(now time.Time) error {
+	if err := r.closeLocked(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(r.dir, filePrefix+now.UTC().Format(fileTimeFmt)+fileExt)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed to open recording file: %w", err)
+	}
+
+	r.file = file
+	r.gzip = gzip.NewWriter(file)
+	r.buf = bufio.NewWriter(r.gzip)
+	r.openedAt = now
+
+	log.Printf("Recording raw frames to %s", path)
+
+	return nil
+}
+
+func (r *Recorder) closeLocked() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := errors.Join(r.buf.Flush(), r.gzip.Close(), r.file.Close())
+	r.file, r.gzip, r.buf = nil, nil, nil
+
+	return err
+}
+
+// Client wraps a WebSocketClient and records every frame it receives.
+type Client struct {
+	core.WebSocketClient
+	recorder *Recorder
+}
+
+func NewRecordingClient(client core.WebSocketClient, recorder *Recorder) *Client {
+	return &Client{
+		WebSocketClient: client,
+		recorder:        recorder,
+	}
+}
+
+func (c *Client) ReadMessages() (<-chan []byte, <-chan error) {
+	source, errChan := c.WebSocketClient.ReadMessages()
+	msgChan := make(chan []byte)
+
+	go func() {
+		defer close(msgChan)
+
+		for message := range source {
+			if err := c.recorder.Record(message, time.Now()); err != nil {
+				log.Printf("Failed to record frame: %v", err)
+			}
+
+			msgChan <- message
+		}
+	}()
+
+	return msgChan, errChan
+}
+
+func (c *Client) Close() error {
+	return errors.Join(c.WebSocketClient.Close(), c.recorder.Close())
+}
+
+// SnapshotProvider wraps a DepthSnapshotProvider and records every snapshot it
+// returns.
+type SnapshotProvider struct {
+	core.DepthSnapshotProvider
+	recorder *Recorder
+}
+
+func NewRecordingSnapshotProvider(provider core.DepthSnapshotProvider, recorder *Recorder) *SnapshotProvider {
+	return &SnapshotProvider{
+		DepthSnapshotProvider: provider,
+		recorder:              recorder,
+	}
+}
+
+func (p *SnapshotProvider) GetDepthSnapshot(ctx context.Context, symbol string, limit int) (core.DepthSnapshot, error) {
+	snapshot, err := p.DepthSnapshotProvider.GetDepthSnapshot(ctx, symbol, limit)
+	if err != nil {
+		return snapshot, err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		log.Printf("Failed to encode depth snapshot of %s: %v", symbol, err)
+
+		return snapshot, nil
+	}
+
+	if err := p.recorder.RecordSnapshot(symbol, data, time.Now()); err != nil {
+		log.Printf("Failed to record depth snapshot of %s: %v", symbol, err)
+	}
+
+	return snapshot, nil
+}
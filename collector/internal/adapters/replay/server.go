@@ -0,0 +1,338 @@
+package replay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mkaganm/algo-trade/collector/internal/adapters/recorder"
+)
+
+const (
+	methodSubscribe   = "SUBSCRIBE"
+	methodUnsubscribe = "UNSUBSCRIBE"
+	methodList        = "LIST_SUBSCRIPTIONS"
+	maxLineSize       = 16 << 20
+)
+
+var errSessionClosed = errors.New("replay session closed")
+
+// Files returns the recordings in dir in chronological order.
+func Files(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "frames-*.ndjson.gz"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// ReadFrames calls fn for every frame of a recording file.
+func ReadFrames(path string, fn func(frame recorder.Frame) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	for scanner.Scan() {
+		var frame recorder.Frame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return fmt.Errorf("failed to decode frame in %s: %w", path, err)
+		}
+
+		if err := fn(frame); err != nil {
+			return err
+		}
+	}
+
+	// A recording cut short by a crash ends with a truncated gzip stream.
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	return nil
+}
+
+// Server replays recorded frames over WebSocket speaking the Binance stream
+// protocol: streams are selected with ?streams=a/b or SUBSCRIBE requests and
+// frames are paced by their recorded timestamps divided by speed (0 = max speed).
+// Once the recording ends the connection stays open and idle unless loop is
+// set, so that a reconnecting client does not see the recording twice. The
+// recorded REST depth snapshots are served by ServeDepth.
+type Server struct {
+	files     []string
+	speed     float64
+	loop      bool
+	snapshots map[string][]recorder.Frame
+	upgrader  websocket.Upgrader
+
+	mu       sync.Mutex
+	position time.Time
+}
+
+func NewServer(files []string, speed float64, loop bool) (*Server, error) {
+	snapshots := make(map[string][]recorder.Frame)
+
+	for _, file := range files {
+		err := ReadFrames(file, func(frame recorder.Frame) error {
+			if frame.Symbol != "" {
+				snapshots[frame.Symbol] = append(snapshots[frame.Symbol], frame)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Server{
+		files:     files,
+		speed:     speed,
+		loop:      loop,
+		snapshots: snapshots,
+		upgrader:  websocket.Upgrader{},
+	}, nil
+}
+
+// ServeDepth answers /api/v3/depth requests with the first snapshot of the
+// symbol recorded at or after the replay position, which is the one the live
+// collector received when it synced its book there. Past the last snapshot the
+// last one is served.
+func (s *Server) ServeDepth(w http.ResponseWriter, r *http.Request) {
+	snapshots := s.snapshots[strings.ToUpper(r.URL.Query().Get("symbol"))]
+	if len(snapshots) == 0 {
+		http.Error(w, "no depth snapshot recorded for symbol", http.StatusNotFound)
+
+		return
+	}
+
+	s.mu.Lock()
+	position := s.position
+	s.mu.Unlock()
+
+	index, _ := slices.BinarySearchFunc(snapshots, position, func(frame recorder.Frame, at time.Time) int {
+		return frame.ReceivedAt.Compare(at)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(snapshots[min(index, len(snapshots)-1)].Data); err != nil {
+		log.Printf("Failed to write depth snapshot: %v", err)
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade replay connection: %v", err)
+
+		return
+	}
+	defer conn.Close()
+
+	sess := &session{
+		conn:       conn,
+		subscribed: make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if streams := r.URL.Query().Get("streams"); streams != "" {
+		sess.subscribe(strings.Split(streams, "/"))
+	}
+
+	go sess.readRequests()
+
+	select {
+	case <-sess.subscribed:
+	case <-sess.done:
+		return
+	}
+
+	log.Printf("Replaying %d recordings to %s at speed %gx", len(s.files), r.RemoteAddr, s.speed)
+
+	for {
+		if err := s.stream(sess); err != nil {
+			if !errors.Is(err, errSessionClosed) {
+				log.Printf("Replay to %s stopped: %v", r.RemoteAddr, err)
+			}
+
+			return
+		}
+
+		if !s.loop {
+			break
+		}
+
+		log.Printf("Replay to %s finished, starting over", r.RemoteAddr)
+	}
+
+	log.Printf("Replay to %s finished, keeping the connection idle", r.RemoteAddr)
+
+	<-sess.done
+}
+
+func (s *Server) stream(sess *session) error {
+	var first time.Time
+
+	start := time.Now()
+
+	for _, file := range s.files {
+		err := ReadFrames(file, func(frame recorder.Frame) error {
+			var envelope struct {
+				Stream string `json:"stream"`
+			}
+
+			// Control responses of the recorded session are not replayed.
+			if err := json.Unmarshal(frame.Data, &envelope); err != nil || envelope.Stream == "" {
+				return nil
+			}
+
+			if first.IsZero() {
+				first = frame.ReceivedAt
+			}
+
+			if s.speed > 0 {
+				offset := time.Duration(float64(frame.ReceivedAt.Sub(first)) / s.speed)
+
+				select {
+				case <-time.After(time.Until(start.Add(offset))):
+				case <-sess.done:
+					return errSessionClosed
+				}
+			}
+
+			s.mu.Lock()
+			s.position = frame.ReceivedAt
+			s.mu.Unlock()
+
+			if !sess.isSubscribed(envelope.Stream) {
+				return nil
+			}
+
+			return sess.write(websocket.TextMessage, frame.Data)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// session is one replay connection.
+type session struct {
+	conn       *websocket.Conn
+	writeMu    sync.Mutex
+	mu         sync.Mutex
+	streams    []string
+	subscribed chan struct{}
+	once       sync.Once
+	done       chan struct{}
+}
+
+type request struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int64    `json:"id"`
+}
+
+type response struct {
+	Result interface{} `json:"result"`
+	ID     int64       `json:"id"`
+}
+
+func (s *session) readRequests() {
+	defer close(s.done)
+
+	for {
+		var req request
+		if err := s.conn.ReadJSON(&req); err != nil {
+			return
+		}
+
+		var result interface{}
+
+		switch req.Method {
+		case methodSubscribe:
+			s.subscribe(req.Params)
+		case methodUnsubscribe:
+			s.unsubscribe(req.Params)
+		case methodList:
+			s.mu.Lock()
+			result = slices.Clone(s.streams)
+			s.mu.Unlock()
+		}
+
+		data, err := json.Marshal(response{Result: result, ID: req.ID})
+		if err != nil {
+			return
+		}
+
+		if err := s.write(websocket.TextMessage, data); err != nil {
+			return
+		}
+	}
+}
+
+func (s *session) subscribe(streams []string) {
+	s.mu.Lock()
+
+	for _, stream := range streams {
+		if !slices.Contains(s.streams, stream) {
+			s.streams = append(s.streams, stream)
+		}
+	}
+
+	s.mu.Unlock()
+
+	s.once.Do(func() { close(s.subscribed) })
+}
+
+func (s *session) unsubscribe(streams []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.streams = slices.DeleteFunc(s.streams, func(stream string) bool {
+		return slices.Contains(streams, stream)
+	})
+}
+
+func (s *session) isSubscribed(stream string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Contains(s.streams, stream)
+}
+
+func (s *session) write(messageType int, data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return s.conn.WriteMessage(messageType, data)
+}
@@ -0,0 +1,140 @@
+package replay
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mkaganm/algo-trade/collector/internal/adapters/recorder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func record(t *testing.T, dir string, frames ...string) {
+	t.Helper()
+
+	rec, err := recorder.NewRecorder(dir, time.Hour)
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i, frame := range frames {
+		require.NoError(t, rec.Record([]byte(frame), start.Add(time.Duration(i)*time.Millisecond)))
+	}
+
+	require.NoError(t, rec.Close())
+}
+
+func TestReplayServesSubscribedStreamsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	record(t, dir,
+		`{"result":null,"id":1}`,
+		`{"stream":"btcusdt@trade","data":{"t":1}}`,
+		`{"stream":"btcusdt@depth","data":{"u":1}}`,
+		`{"stream":"btcusdt@trade","data":{"t":2}}`,
+	)
+
+	files, err := Files(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	replayServer, err := NewServer(files, 0, false)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(replayServer)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(request{Method: methodSubscribe, Params: []string{"btcusdt@trade"}, ID: 7}))
+
+	var received []string
+
+	for range 3 {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		_, message, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		received = append(received, string(message))
+	}
+
+	assert.Contains(t, received, `{"result":null,"id":7}`)
+	assert.Contains(t, received, `{"stream":"btcusdt@trade","data":{"t":1}}`)
+	assert.NotContains(t, received, `{"stream":"btcusdt@depth","data":{"u":1}}`)
+}
+
+func TestReplayKeepsConnectionIdleAfterRecording(t *testing.T) {
+	dir := t.TempDir()
+	record(t, dir, `{"stream":"btcusdt@trade","data":{"t":1}}`)
+
+	files, err := Files(dir)
+	require.NoError(t, err)
+
+	replayServer, err := NewServer(files, 0, false)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(replayServer)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(server.URL, "http")+"/stream?streams=btcusdt@trade", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	_, message, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"stream":"btcusdt@trade","data":{"t":1}}`, string(message))
+
+	// The server neither closes the connection nor replays the frame again
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+
+	_, _, err = conn.ReadMessage()
+
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+}
+
+func TestReplayServesRecordedDepthSnapshots(t *testing.T) {
+	dir := t.TempDir()
+
+	rec, err := recorder.NewRecorder(dir, time.Hour)
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, rec.RecordSnapshot("BTCUSDT", []byte(`{"lastUpdateId":1}`), start))
+	require.NoError(t, rec.Record([]byte(`{"stream":"btcusdt@depth","data":{"u":2}}`), start.Add(time.Second)))
+	require.NoError(t, rec.RecordSnapshot("BTCUSDT", []byte(`{"lastUpdateId":3}`), start.Add(2*time.Second)))
+	require.NoError(t, rec.Close())
+
+	files, err := Files(dir)
+	require.NoError(t, err)
+
+	replayServer, err := NewServer(files, 0, false)
+	require.NoError(t, err)
+
+	depth := func(symbol string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		replayServer.ServeDepth(response, httptest.NewRequest(http.MethodGet, "/api/v3/depth?symbol="+symbol, nil))
+
+		return response
+	}
+
+	assert.JSONEq(t, `{"lastUpdateId":1}`, depth("BTCUSDT").Body.String())
+	assert.Equal(t, http.StatusNotFound, depth("ETHUSDT").Code)
+
+	// A resync after the diff gets the snapshot the live collector got then
+	replayServer.position = start.Add(time.Second)
+	assert.JSONEq(t, `{"lastUpdateId":3}`, depth("BTCUSDT").Body.String())
+
+	replayServer.position = start.Add(time.Hour)
+	assert.JSONEq(t, `{"lastUpdateId":3}`, depth("BTCUSDT").Body.String())
+}
@@ -0,0 +1,509 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// deadLetterFile keeps the records the target rejected for good, in the
+	// segment format but outside the replay.
+	deadLetterFile   = "deadletter.wal"
+	segmentPrefix    = "segment-"
+	segmentExt       = ".wal"
+	checkpointExt    = ".ckpt"
+	corruptExt       = ".corrupt"
+	dirPerm          = 0o755
+	filePerm         = 0o644
+	lengthPrefixSize = 4
+)
+
+var ErrCorruptRecord = errors.New("corrupt wal record")
+
+// Record is a document spilled to disk together with its target collection.
+type Record struct {
+	Collection string   `bson:"c"`
+	Document   bson.Raw `bson:"d"`
+}
+
+// Log is an append-only write-ahead log of BSON records split into segment
+// files. Records are replayed segment by segment in append order and the
+// progress inside a segment is checkpointed. A crash between replaying a batch
+// and checkpointing it replays the batch again, so the replay target must
+// accept records it already has.
+type Log struct {
+	dir         string
+	segmentSize int64
+
+	mu         sync.Mutex
+	active     *os.File
+	activePath string
+	activeSize int64
+	nextSeq    int64
+	pending    int64
+	size       int64
+}
+
+// Open opens the log in dir, counting the records left over from a previous run.
+func Open(dir string, segmentSize int64) (*Log, error) {
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create wal directory: %w", err)
+	}
+
+	l := &Log{
+		dir:         dir,
+		segmentSize: segmentSize,
+	}
+
+	segments, err := l.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, segment := range segments {
+		seq, err := parseSequence(segment)
+		if err != nil {
+			return nil, err
+		}
+
+		l.nextSeq = max(l.nextSeq, seq+1)
+
+		info, err := os.Stat(segment)
+		if err != nil {
+			return nil, err
+		}
+
+		count, err := countRecords(segment, readCheckpoint(segment))
+		if err != nil {
+			return nil, err
+		}
+
+		l.size += info.Size()
+		l.pending += count
+	}
+
+	return l, nil
+}
+
+// Append writes docs for collection to the active segment and syncs it to disk.
+func (l *Log) Append(collection string, docs []interface{}) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active == nil || l.activeSize >= l.segmentSize {
+		if err := l.openSegmentLocked(); err != nil {
+			return err
+		}
+	}
+
+	writer := bufio.NewWriter(l.active)
+
+	var written int64
+
+	for _, doc := range docs {
+		raw, err := bson.Marshal(bson.M{"c": collection, "d": doc})
+		if err != nil {
+			return fmt.Errorf("failed to encode wal record: %w", err)
+		}
+
+		n, err := writer.Write(raw)
+		if err != nil {
+			return err
+		}
+
+		written += int64(n)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if err := l.active.Sync(); err != nil {
+		return err
+	}
+
+	l.activeSize += written
+	l.size += written
+	l.pending += int64(len(docs))
+
+	return nil
+}
+
+// Rotate seals the active segment so that it can be replayed.
+func (l *Log) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.closeActiveLocked()
+}
+
+// Sealed returns the segments that are no longer appended to, oldest first.
+// The lock is held while listing, so a segment Append opens meanwhile is
+// never mistaken for a sealed one.
+func (l *Log) Sealed() ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	activePath := l.activePath
+
+	segments, err := l.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := segments[:0]
+
+	for _, segment := range segments {
+		if segment != activePath {
+			sealed = append(sealed, segment)
+		}
+	}
+
+	return sealed, nil
+}
+
+// Replay calls fn with batches of consecutive records of the same collection,
+// starting at the segment checkpoint. fn returns the indexes of the records
+// the target rejected for good, they are moved to the dead-letter file instead
+// of blocking the log. After each successful call the checkpoint is advanced;
+// once the segment is fully replayed it is removed. A segment that turns out
+// corrupt is replayed up to the corruption and then set aside.
+func (l *Log) Replay(
+	segment string,
+	batchSize int,
+	fn func(records []Record) (rejected []int, err error),
+) error {
+	file, err := os.Open(segment)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	offset := readCheckpoint(segment)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+
+	var (
+		batch      []Record
+		batchBytes int64
+	)
+
+	commit := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		rejected, err := fn(batch)
+		if err != nil {
+			return err
+		}
+
+		if err := l.deadLetter(batch, rejected); err != nil {
+			return err
+		}
+
+		offset += batchBytes
+		if err := writeCheckpoint(segment, offset); err != nil {
+			return err
+		}
+
+		l.mu.Lock()
+		l.pending -= int64(len(batch))
+		l.mu.Unlock()
+
+		batch, batchBytes = nil, 0
+
+		return nil
+	}
+
+	for {
+		record, n, err := readRecord(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if errors.Is(err, ErrCorruptRecord) {
+			if err := commit(); err != nil {
+				return err
+			}
+
+			return l.quarantine(segment, offset, err)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(batch) > 0 && (batch[0].Collection != record.Collection || len(batch) >= batchSize) {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
+
+		batch = append(batch, record)
+		batchBytes += n
+	}
+
+	if err := commit(); err != nil {
+		return err
+	}
+
+	return l.remove(segment)
+}
+
+// DeadLetterPath returns the file rejected records are moved to.
+func (l *Log) DeadLetterPath() string {
+	return filepath.Join(l.dir, deadLetterFile)
+}
+
+// deadLetter appends the records at the rejected indexes of batch to the
+// dead-letter file and syncs it before the batch is checkpointed.
+func (l *Log) deadLetter(batch []Record, rejected []int) error {
+	if len(rejected) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(l.DeadLetterPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+
+	for _, index := range rejected {
+		raw, err := bson.Marshal(batch[index])
+		if err != nil {
+			return fmt.Errorf("failed to encode dead-letter record: %w", err)
+		}
+
+		if _, err := writer.Write(raw); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// Pending returns the number of records waiting to be replayed.
+func (l *Log) Pending() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.pending
+}
+
+// Size returns the number of bytes the log occupies on disk.
+func (l *Log) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.size
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.closeActiveLocked()
+}
+
+func (l *Log) openSegmentLocked() error {
+	if err := l.closeActiveLocked(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(l.dir, fmt.Sprintf("%s%020d%s", segmentPrefix, l.nextSeq, segmentExt))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed to open wal segment: %w", err)
+	}
+
+	l.nextSeq++
+	l.active = file
+	l.activePath = path
+	l.activeSize = 0
+
+	return nil
+}
+
+func (l *Log) closeActiveLocked() error {
+	if l.active == nil {
+		return nil
+	}
+
+	err := l.active.Close()
+	l.active = nil
+	l.activePath = ""
+	l.activeSize = 0
+
+	return err
+}
+
+func (l *Log) remove(segment string) error {
+	info, err := os.Stat(segment)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(segment); err != nil {
+		return err
+	}
+
+	_ = os.Remove(segment + checkpointExt)
+
+	l.mu.Lock()
+	l.size -= info.Size()
+	l.mu.Unlock()
+
+	return nil
+}
+
+// quarantine renames a corrupt segment so that it is kept for inspection but
+// no longer replayed.
+func (l *Log) quarantine(segment string, offset int64, cause error) error {
+	info, err := os.Stat(segment)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(segment, segment+corruptExt); err != nil {
+		return err
+	}
+
+	_ = os.Remove(segment + checkpointExt)
+
+	l.mu.Lock()
+	l.size -= info.Size()
+	l.mu.Unlock()
+
+	log.Printf("WAL segment %s is corrupt at offset %d, moved to %s: %v", segment, offset, segment+corruptExt, cause)
+
+	return nil
+}
+
+func (l *Log) segments() ([]string, error) {
+	segments, err := filepath.Glob(filepath.Join(l.dir, segmentPrefix+"*"+segmentExt))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(segments)
+
+	return segments, nil
+}
+
+// readRecord reads one BSON document; its first four bytes hold its total length.
+// A truncated trailing record (crash during append) is treated as the end of the segment.
+func readRecord(reader *bufio.Reader) (Record, int64, error) {
+	prefix, err := reader.Peek(lengthPrefixSize)
+	if err != nil {
+		return Record{}, 0, io.EOF
+	}
+
+	length := int64(binary.LittleEndian.Uint32(prefix))
+	if length < lengthPrefixSize {
+		return Record{}, 0, fmt.Errorf("%w: length %d", ErrCorruptRecord, length)
+	}
+
+	raw := make([]byte, length)
+	if _, err := io.ReadFull(reader, raw); err != nil {
+		return Record{}, 0, io.EOF
+	}
+
+	if err := bson.Raw(raw).Validate(); err != nil {
+		return Record{}, 0, fmt.Errorf("%w: %w", ErrCorruptRecord, err)
+	}
+
+	var record Record
+	if err := bson.Unmarshal(raw, &record); err != nil {
+		return Record{}, 0, fmt.Errorf("%w: %w", ErrCorruptRecord, err)
+	}
+
+	return record, length, nil
+}
+
+func countRecords(segment string, offset int64) (int64, error) {
+	file, err := os.Open(segment)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	reader := bufio.NewReader(file)
+
+	var count int64
+
+	for {
+		_, _, err := readRecord(reader)
+		// Records behind a corruption are never replayed
+		if errors.Is(err, io.EOF) || errors.Is(err, ErrCorruptRecord) {
+			return count, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		count++
+	}
+}
+
+func readCheckpoint(segment string) int64 {
+	data, err := os.ReadFile(segment + checkpointExt)
+	if err != nil {
+		return 0
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return offset
+}
+
+func writeCheckpoint(segment string, offset int64) error {
+	tmp := segment + checkpointExt + ".tmp"
+
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(offset, 10)), filePerm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, segment+checkpointExt)
+}
+
+func parseSequence(segment string) (int64, error) {
+	name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(segment), segmentPrefix), segmentExt)
+
+	seq, err := strconv.ParseInt(name, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wal segment name %q: %w", segment, err)
+	}
+
+	return seq, nil
+}
@@ -0,0 +1,246 @@
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var errInsertFailed = errors.New("insert failed")
+
+func collect(t *testing.T, l *Log, batchSize int) [][]Record {
+	t.Helper()
+
+	var batches [][]Record
+
+	require.NoError(t, l.Rotate())
+
+	segments, err := l.Sealed()
+	require.NoError(t, err)
+
+	for _, segment := range segments {
+		require.NoError(t, l.Replay(segment, batchSize, func(records []Record) ([]int, error) {
+			batches = append(batches, records)
+
+			return nil, nil
+		}))
+	}
+
+	return batches
+}
+
+func TestLogReplaysInOrderGroupedByCollection(t *testing.T) {
+	l, err := Open(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+
+	require.NoError(t, l.Append("depth", []interface{}{bson.M{"n": 1}, bson.M{"n": 2}, bson.M{"n": 3}}))
+	require.NoError(t, l.Append("trade", []interface{}{bson.M{"n": 4}}))
+	assert.Equal(t, int64(4), l.Pending())
+
+	batches := collect(t, l, 2)
+
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0], 2)
+	assert.Equal(t, "depth", batches[1][0].Collection)
+	assert.Equal(t, "trade", batches[2][0].Collection)
+	assert.Equal(t, int32(4), batches[2][0].Document.Lookup("n").Int32())
+	assert.Equal(t, int64(0), l.Pending())
+	assert.Equal(t, int64(0), l.Size())
+}
+
+func TestLogResumesFromCheckpointAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, 1<<20)
+	require.NoError(t, err)
+
+	require.NoError(t, l.Append("depth", []interface{}{bson.M{"n": 1}, bson.M{"n": 2}, bson.M{"n": 3}}))
+	require.NoError(t, l.Rotate())
+
+	segments, err := l.Sealed()
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	calls := 0
+	err = l.Replay(segments[0], 1, func(_ []Record) ([]int, error) {
+		calls++
+		if calls == 2 {
+			return nil, errInsertFailed
+		}
+
+		return nil, nil
+	})
+	require.ErrorIs(t, err, errInsertFailed)
+	require.NoError(t, l.Close())
+
+	reopened, err := Open(dir, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), reopened.Pending())
+
+	batches := collect(t, reopened, 10)
+
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 2)
+	assert.Equal(t, int32(2), batches[0][0].Document.Lookup("n").Int32())
+}
+
+func TestLogRotatesSegmentsBySize(t *testing.T) {
+	l, err := Open(t.TempDir(), 1)
+	require.NoError(t, err)
+
+	require.NoError(t, l.Append("depth", []interface{}{bson.M{"n": 1}}))
+	require.NoError(t, l.Append("depth", []interface{}{bson.M{"n": 2}}))
+	require.NoError(t, l.Rotate())
+
+	segments, err := l.Sealed()
+	require.NoError(t, err)
+
+	assert.Len(t, segments, 2)
+}
+
+func TestLogReplayStopsAtCorruptTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		tail     []byte
+		replayed int
+		corrupt  bool
+	}{
+		{name: "truncated record", tail: []byte{0x40, 0, 0, 0, 1, 2}, replayed: 2},
+		{name: "invalid length", tail: []byte{1, 0, 0, 0, 1, 2, 3, 4}, replayed: 2, corrupt: true},
+		{name: "invalid document", tail: []byte{5, 0, 0, 0, 1}, replayed: 2, corrupt: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			l, err := Open(dir, 1<<20)
+			require.NoError(t, err)
+			require.NoError(t, l.Append("depth", []interface{}{bson.M{"n": 1}, bson.M{"n": 2}}))
+			require.NoError(t, l.Close())
+
+			segments, err := filepath.Glob(filepath.Join(dir, segmentPrefix+"*"+segmentExt))
+			require.NoError(t, err)
+			require.Len(t, segments, 1)
+
+			file, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, filePerm)
+			require.NoError(t, err)
+			_, err = file.Write(tt.tail)
+			require.NoError(t, err)
+			require.NoError(t, file.Close())
+
+			reopened, err := Open(dir, 1<<20)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), reopened.Pending())
+
+			batches := collect(t, reopened, 10)
+
+			require.Len(t, batches, 1)
+			assert.Len(t, batches[0], tt.replayed)
+			assert.Equal(t, int64(0), reopened.Pending())
+			assert.NoFileExists(t, segments[0])
+
+			if tt.corrupt {
+				assert.FileExists(t, segments[0]+corruptExt)
+			}
+		})
+	}
+}
+
+func TestLogMovesRejectedRecordsToDeadLetter(t *testing.T) {
+	l, err := Open(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+
+	require.NoError(t, l.Append("depth", []interface{}{bson.M{"n": 1}, bson.M{"n": 2}, bson.M{"n": 3}}))
+	require.NoError(t, l.Rotate())
+
+	segments, err := l.Sealed()
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	require.NoError(t, l.Replay(segments[0], 10, func(_ []Record) ([]int, error) {
+		return []int{1}, nil
+	}))
+	assert.Equal(t, int64(0), l.Pending())
+
+	file, err := os.Open(l.DeadLetterPath())
+	require.NoError(t, err)
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	record, _, err := readRecord(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "depth", record.Collection)
+	assert.Equal(t, int32(2), record.Document.Lookup("n").Int32())
+
+	_, _, err = readRecord(reader)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestLogReplaysWhileAppending(t *testing.T) {
+	// Small segments make Append open new ones while segments are replayed
+	l, err := Open(t.TempDir(), 64)
+	require.NoError(t, err)
+
+	const appends = 500
+
+	var (
+		replayed int
+		wg       sync.WaitGroup
+	)
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := range appends {
+			assert.NoError(t, l.Append("depth", []interface{}{bson.M{"n": i}}))
+		}
+	}()
+
+	replay := func() {
+		require.NoError(t, l.Rotate())
+
+		segments, err := l.Sealed()
+		require.NoError(t, err)
+
+		for _, segment := range segments {
+			require.NoError(t, l.Replay(segment, 10, func(records []Record) ([]int, error) {
+				replayed += len(records)
+
+				return nil, nil
+			}))
+		}
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			replay()
+		}
+	}
+
+	replay()
+
+	assert.Equal(t, appends, replayed)
+	assert.Equal(t, int64(0), l.Pending())
+}
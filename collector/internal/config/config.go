@@ -5,18 +5,49 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/mkaganm/algo-trade/collector/internal/core"
 )
 
 type Config struct {
-	BinanceWSURL       string
-	MaxConnectionRetry int
-	RetryDelay         time.Duration
-	MongoURI           string
-	DatabaseName       string
-	CollectionName     string
+	BinanceWSURL          string
+	Symbols               []string
+	StreamTypes           []string
+	MaxConnectionRetry    int
+	RetryDelay            time.Duration
+	RetryMaxDelay         time.Duration
+	MaxConnectionAge      time.Duration
+	ReadTimeout           time.Duration
+	RecordDir             string
+	RecordRotate          time.Duration
+	MongoURI              string
+	DatabaseName          string
+	CollectionName        string
+	BatchSize             int
+	FlushInterval         time.Duration
+	QueueSize             int
+	OverflowPolicy        string
+	WALDir                string
+	WALSegmentSize        int64
+	WALReplayInterval     time.Duration
+	BinanceRestURL        string
+	RestTimeout           time.Duration
+	DepthSnapshotLimit    int
+	SnapshotCollection    string
+	SnapshotInterval      time.Duration
+	SnapshotDepth         int
+	GapCollection         string
+	TradeCollection       string
+	AggTradeCollection    string
+	EventCollectionPrefix string
+	DepthTTL              time.Duration
+	CandleCollection      string
+	RetentionInterval     time.Duration
+	Retention             core.RetentionPolicy
+	RetentionOverrides    map[string]core.RetentionPolicy
 }
 
 func LoadConfig() (*Config, error) {
@@ -35,12 +66,181 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("failed to parse RETRY_DELAY: %w", err)
 	}
 
+	retryMaxDelay, err := time.ParseDuration(os.Getenv("RETRY_MAX_DELAY"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RETRY_MAX_DELAY: %w", err)
+	}
+
+	maxConnectionAge, err := time.ParseDuration(os.Getenv("MAX_CONNECTION_AGE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MAX_CONNECTION_AGE: %w", err)
+	}
+
+	readTimeout, err := time.ParseDuration(os.Getenv("WS_READ_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse WS_READ_TIMEOUT: %w", err)
+	}
+
+	batchSize, err := strconv.Atoi(os.Getenv("MONGO_BATCH_SIZE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert MONGO_BATCH_SIZE to int: %w", err)
+	}
+
+	flushInterval, err := time.ParseDuration(os.Getenv("MONGO_FLUSH_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MONGO_FLUSH_INTERVAL: %w", err)
+	}
+
+	queueSize, err := strconv.Atoi(os.Getenv("MONGO_QUEUE_SIZE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert MONGO_QUEUE_SIZE to int: %w", err)
+	}
+
+	walSegmentSize, err := strconv.ParseInt(os.Getenv("WAL_SEGMENT_SIZE"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert WAL_SEGMENT_SIZE to int: %w", err)
+	}
+
+	walReplayInterval, err := time.ParseDuration(os.Getenv("WAL_REPLAY_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse WAL_REPLAY_INTERVAL: %w", err)
+	}
+
+	recordRotate, err := time.ParseDuration(getEnv("RECORD_ROTATE_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RECORD_ROTATE_INTERVAL: %w", err)
+	}
+
+	restTimeout, err := time.ParseDuration(os.Getenv("REST_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse REST_TIMEOUT: %w", err)
+	}
+
+	depthSnapshotLimit, err := strconv.Atoi(os.Getenv("DEPTH_SNAPSHOT_LIMIT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert DEPTH_SNAPSHOT_LIMIT to int: %w", err)
+	}
+
+	snapshotInterval, err := time.ParseDuration(os.Getenv("BOOK_SNAPSHOT_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse BOOK_SNAPSHOT_INTERVAL: %w", err)
+	}
+
+	snapshotDepth, err := strconv.Atoi(os.Getenv("BOOK_SNAPSHOT_DEPTH"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert BOOK_SNAPSHOT_DEPTH to int: %w", err)
+	}
+
+	depthTTL, err := time.ParseDuration(getEnv("DEPTH_TTL", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DEPTH_TTL: %w", err)
+	}
+
+	retentionInterval, err := time.ParseDuration(getEnv("RETENTION_INTERVAL", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RETENTION_INTERVAL: %w", err)
+	}
+
+	retention, err := loadRetentionPolicy("RETENTION", core.RetentionPolicy{})
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := splitList(os.Getenv("SYMBOLS"))
+	overrides := make(map[string]core.RetentionPolicy)
+
+	for _, symbol := range symbols {
+		policy, err := loadRetentionPolicy("RETENTION_"+strings.ToUpper(symbol), retention)
+		if err != nil {
+			return nil, err
+		}
+
+		if policy != retention {
+			overrides[strings.ToUpper(symbol)] = policy
+		}
+	}
+
 	return &Config{
-		BinanceWSURL:       os.Getenv("BINANCE_WS_URL"),
-		MaxConnectionRetry: maxConnectionRetry,
-		RetryDelay:         retryDelay,
-		MongoURI:           os.Getenv("MONGO_URI"),
-		DatabaseName:       os.Getenv("DATABASE_NAME"),
-		CollectionName:     os.Getenv("COLLECTION_NAME"),
+		BinanceWSURL:          os.Getenv("BINANCE_WS_URL"),
+		Symbols:               symbols,
+		StreamTypes:           splitList(os.Getenv("STREAMS")),
+		MaxConnectionRetry:    maxConnectionRetry,
+		RetryDelay:            retryDelay,
+		RetryMaxDelay:         retryMaxDelay,
+		MaxConnectionAge:      maxConnectionAge,
+		ReadTimeout:           readTimeout,
+		RecordDir:             os.Getenv("RECORD_DIR"),
+		RecordRotate:          recordRotate,
+		MongoURI:              os.Getenv("MONGO_URI"),
+		DatabaseName:          os.Getenv("DATABASE_NAME"),
+		CollectionName:        os.Getenv("COLLECTION_NAME"),
+		BatchSize:             batchSize,
+		FlushInterval:         flushInterval,
+		QueueSize:             queueSize,
+		OverflowPolicy:        os.Getenv("MONGO_OVERFLOW_POLICY"),
+		WALDir:                os.Getenv("WAL_DIR"),
+		WALSegmentSize:        walSegmentSize,
+		WALReplayInterval:     walReplayInterval,
+		BinanceRestURL:        os.Getenv("BINANCE_REST_URL"),
+		RestTimeout:           restTimeout,
+		DepthSnapshotLimit:    depthSnapshotLimit,
+		SnapshotCollection:    os.Getenv("BOOK_SNAPSHOT_COLLECTION_NAME"),
+		SnapshotInterval:      snapshotInterval,
+		SnapshotDepth:         snapshotDepth,
+		GapCollection:         os.Getenv("GAP_COLLECTION_NAME"),
+		TradeCollection:       os.Getenv("TRADE_COLLECTION_NAME"),
+		AggTradeCollection:    os.Getenv("AGG_TRADE_COLLECTION_NAME"),
+		EventCollectionPrefix: os.Getenv("EVENT_COLLECTION_PREFIX"),
+		DepthTTL:              depthTTL,
+		CandleCollection:      os.Getenv("BOOK_CANDLE_COLLECTION_NAME"),
+		RetentionInterval:     retentionInterval,
+		Retention:             retention,
+		RetentionOverrides:    overrides,
 	}, nil
 }
+
+// loadRetentionPolicy reads <prefix>_RAW_DEPTH and <prefix>_BOOK_SNAPSHOTS,
+// keeping the fallback value for unset variables.
+func loadRetentionPolicy(prefix string, fallback core.RetentionPolicy) (core.RetentionPolicy, error) {
+	policy := fallback
+
+	for key, target := range map[string]*time.Duration{
+		prefix + "_RAW_DEPTH":      &policy.RawDepth,
+		prefix + "_BOOK_SNAPSHOTS": &policy.BookSnapshots,
+	} {
+		value, ok := os.LookupEnv(key)
+		if !ok || value == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return core.RetentionPolicy{}, fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+
+		*target = duration
+	}
+
+	return policy, nil
+}
+
+// splitList parses a comma separated list, ignoring empty items.
+func splitList(value string) []string {
+	var items []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+
+	return defaultValue
+}
@@ -20,12 +20,178 @@ type OrderBookData struct {
 	AskUpdates    [][]string `json:"a"` // [["Price", "Quantity"],...]
 }
 
+// Trade is an executed trade from the trade or aggTrade stream. For aggregated
+// trades TradeID is the aggregate trade ID and First/LastTradeID its range.
+type Trade struct {
+	Symbol       string    `bson:"symbol"`
+	TradeID      int64     `bson:"tradeId"`
+	FirstTradeID int64     `bson:"firstTradeId"`
+	LastTradeID  int64     `bson:"lastTradeId"`
+	Price        float64   `bson:"price"`
+	Quantity     float64   `bson:"quantity"`
+	QuoteVolume  float64   `bson:"quoteVolume"`
+	BuyerMaker   bool      `bson:"buyerMaker"` // true when the taker was the seller
+	Aggregated   bool      `bson:"aggregated"`
+	TradeTime    time.Time `bson:"tradeTime"`
+	EventTime    time.Time `bson:"eventTime"`
+	Timestamp    time.Time `bson:"timestamp"`
+}
+
+// BookTicker is a best bid/ask update from the bookTicker stream.
+type BookTicker struct {
+	UpdateID int64  `bson:"updateId" json:"u"`
+	Symbol   string `bson:"symbol"   json:"s"`
+	BidPrice string `bson:"bidPrice" json:"b"`
+	BidQty   string `bson:"bidQty"   json:"B"`
+	AskPrice string `bson:"askPrice" json:"a"`
+	AskQty   string `bson:"askQty"   json:"A"`
+}
+
+// KlineEvent is a candlestick update from a kline_<interval> stream.
+type KlineEvent struct {
+	EventType string `bson:"eventType" json:"e"` // "kline"
+	EventTime int64  `bson:"eventTime" json:"E"`
+	Symbol    string `bson:"symbol"    json:"s"`
+	Kline     Kline  `bson:"kline"     json:"k"`
+}
+
+// Kline declares every field of the payload: encoding/json falls back to
+// case-insensitive matching, so e.g. an undeclared "V" would overwrite "v".
+type Kline struct {
+	StartTime           int64  `bson:"startTime"           json:"t"`
+	CloseTime           int64  `bson:"closeTime"           json:"T"`
+	Interval            string `bson:"interval"            json:"i"`
+	FirstTradeID        int64  `bson:"firstTradeId"        json:"f"`
+	LastTradeID         int64  `bson:"lastTradeId"         json:"L"`
+	Open                string `bson:"open"                json:"o"`
+	High                string `bson:"high"                json:"h"`
+	Low                 string `bson:"low"                 json:"l"`
+	Close               string `bson:"close"               json:"c"`
+	Volume              string `bson:"volume"              json:"v"`
+	QuoteVolume         string `bson:"quoteVolume"         json:"q"`
+	TakerBuyVolume      string `bson:"takerBuyVolume"      json:"V"`
+	TakerBuyQuoteVolume string `bson:"takerBuyQuoteVolume" json:"Q"`
+	Trades              int64  `bson:"trades"              json:"n"`
+	Closed              bool   `bson:"closed"              json:"x"`
+	Ignore              string `bson:"-"                   json:"B"`
+}
+
+// StreamEvent is a decoded non-depth stream message routed to its own collection.
+type StreamEvent struct {
+	StreamType string
+	Symbol     string
+	Data       any
+	Timestamp  time.Time
+}
+
+// PriceLevel is a single aggregated price level of an order book side.
+type PriceLevel struct {
+	Price    float64 `bson:"price"    json:"price"`
+	Quantity float64 `bson:"quantity" json:"quantity"`
+}
+
+// DepthSnapshot is a REST depth snapshot used to seed a local order book.
+type DepthSnapshot struct {
+	LastUpdateID int64      `json:"lastUpdateId"`
+	Bids         [][]string `json:"bids"` // [["Price", "Quantity"],...]
+	Asks         [][]string `json:"asks"` // [["Price", "Quantity"],...]
+}
+
+// BookSnapshot is a top-N view of a local order book.
+type BookSnapshot struct {
+	Symbol       string       `bson:"symbol"`
+	LastUpdateID int64        `bson:"lastUpdateId"`
+	Bids         []PriceLevel `bson:"bids"`
+	Asks         []PriceLevel `bson:"asks"`
+	Timestamp    time.Time    `bson:"timestamp"`
+}
+
+// BookCandle is a one-minute OHLC bar of the mid price downsampled from book
+// snapshots, kept after the snapshots themselves expire.
+type BookCandle struct {
+	Symbol    string    `bson:"symbol"`
+	OpenTime  time.Time `bson:"openTime"`
+	CloseTime time.Time `bson:"closeTime"`
+	Open      float64   `bson:"open"`
+	High      float64   `bson:"high"`
+	Low       float64   `bson:"low"`
+	Close     float64   `bson:"close"`
+	Spread    float64   `bson:"spread"` // average best ask - best bid
+	Samples   int       `bson:"samples"`
+}
+
+const GapType = "gap"
+
+// SequenceGap marks a window of the depth stream where updates were lost.
+// Consumers should exclude [From, To] for the symbol.
+type SequenceGap struct {
+	Type             string    `bson:"type"`
+	Symbol           string    `bson:"symbol"`
+	ExpectedUpdateID int64     `bson:"expectedUpdateId"`
+	ReceivedUpdateID int64     `bson:"receivedUpdateId"`
+	MissedUpdates    int64     `bson:"missedUpdates"`
+	From             time.Time `bson:"from"`
+	To               time.Time `bson:"to"`
+	DetectedAt       time.Time `bson:"detectedAt"`
+}
+
 type OrderBookRepository interface {
 	Save(ctx context.Context, update OrderBookUpdate) error
 }
 
+type TradeRepository interface {
+	SaveTrade(ctx context.Context, trade Trade) error
+}
+
+type StreamEventRepository interface {
+	SaveEvent(ctx context.Context, event StreamEvent) error
+}
+
+type BookSnapshotRepository interface {
+	SaveBookSnapshot(ctx context.Context, snapshot BookSnapshot) error
+}
+
+type GapRepository interface {
+	SaveGap(ctx context.Context, gap SequenceGap) error
+}
+
+// RetentionRepository expires raw depth diffs and book snapshots and stores
+// their downsampled candles.
+type RetentionRepository interface {
+	DeleteDepthBefore(ctx context.Context, symbol string, before time.Time) (int64, error)
+	NextBookSnapshotTime(ctx context.Context, symbol string, from time.Time) (time.Time, bool, error)
+	GetBookSnapshots(ctx context.Context, symbol string, from, to time.Time) ([]BookSnapshot, error)
+	DeleteBookSnapshotsBefore(ctx context.Context, symbol string, before time.Time) (int64, error)
+	LatestBookCandleTime(ctx context.Context, symbol string) (time.Time, bool, error)
+	SaveBookCandles(ctx context.Context, candles []BookCandle) error
+}
+
+type DepthSnapshotProvider interface {
+	GetDepthSnapshot(ctx context.Context, symbol string, limit int) (DepthSnapshot, error)
+}
+
+type ConnectionState string
+
+const (
+	ConnectionConnecting   ConnectionState = "connecting"
+	ConnectionConnected    ConnectionState = "connected"
+	ConnectionReconnecting ConnectionState = "reconnecting"
+	ConnectionClosed       ConnectionState = "closed"
+)
+
+// ConnectionStatus describes the current state of the stream connection.
+type ConnectionStatus struct {
+	State          ConnectionState `json:"state"`
+	ConnectedSince time.Time       `json:"connectedSince"`
+	Reconnects     int             `json:"reconnects"`
+	LastError      string          `json:"lastError,omitempty"`
+}
+
 type WebSocketClient interface {
 	Connect() error
+	Subscribe(streams []string) error
+	Unsubscribe(streams []string) error
 	ReadMessages() (<-chan []byte, <-chan error)
+	Status() ConnectionStatus
 	Close() error
 }
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidPriceLevel = errors.New("invalid price level")
+
+// OrderBook is an in-memory, price-sorted order book for a single symbol.
+type OrderBook struct {
+	symbol       string
+	lastUpdateID int64
+	bids         bookSide
+	asks         bookSide
+}
+
+func NewOrderBook(symbol string) *OrderBook {
+	return &OrderBook{
+		symbol: symbol,
+		bids:   bookSide{descending: true},
+		asks:   bookSide{descending: false},
+	}
+}
+
+func (b *OrderBook) Symbol() string {
+	return b.symbol
+}
+
+func (b *OrderBook) LastUpdateID() int64 {
+	return b.lastUpdateID
+}
+
+// LoadSnapshot replaces the content of the book with a REST depth snapshot.
+func (b *OrderBook) LoadSnapshot(snapshot DepthSnapshot) error {
+	b.bids.levels = b.bids.levels[:0]
+	b.asks.levels = b.asks.levels[:0]
+
+	if err := b.bids.apply(snapshot.Bids); err != nil {
+		return err
+	}
+
+	if err := b.asks.apply(snapshot.Asks); err != nil {
+		return err
+	}
+
+	b.lastUpdateID = snapshot.LastUpdateID
+
+	return nil
+}
+
+// Apply applies a depth diff event to the book. Sequencing is the caller's responsibility.
+func (b *OrderBook) Apply(data OrderBookData) error {
+	if err := b.bids.apply(data.BidUpdates); err != nil {
+		return err
+	}
+
+	if err := b.asks.apply(data.AskUpdates); err != nil {
+		return err
+	}
+
+	b.lastUpdateID = data.FinalUpdateID
+
+	return nil
+}
+
+// BestBid returns the highest bid level, if any.
+func (b *OrderBook) BestBid() (PriceLevel, bool) {
+	return b.bids.best()
+}
+
+// BestAsk returns the lowest ask level, if any.
+func (b *OrderBook) BestAsk() (PriceLevel, bool) {
+	return b.asks.best()
+}
+
+// Snapshot returns the top depth levels of each side of the book.
+func (b *OrderBook) Snapshot(depth int, timestamp time.Time) BookSnapshot {
+	return BookSnapshot{
+		Symbol:       b.symbol,
+		LastUpdateID: b.lastUpdateID,
+		Bids:         b.bids.top(depth),
+		Asks:         b.asks.top(depth),
+		Timestamp:    timestamp,
+	}
+}
+
+// bookSide keeps price levels sorted from the best price outwards.
+type bookSide struct {
+	levels     []PriceLevel
+	descending bool
+}
+
+func (s *bookSide) apply(updates [][]string) error {
+	for _, update := range updates {
+		price, quantity, err := parsePriceLevel(update)
+		if err != nil {
+			return err
+		}
+
+		s.set(price, quantity)
+	}
+
+	return nil
+}
+
+func (s *bookSide) search(price float64) int {
+	return sort.Search(len(s.levels), func(i int) bool {
+		if s.descending {
+			return s.levels[i].Price <= price
+		}
+
+		return s.levels[i].Price >= price
+	})
+}
+
+// set inserts, updates or removes (zero quantity) the level at price.
+func (s *bookSide) set(price, quantity float64) {
+	i := s.search(price)
+	found := i < len(s.levels) && s.levels[i].Price == price
+
+	switch {
+	case quantity == 0 && found:
+		s.levels = append(s.levels[:i], s.levels[i+1:]...)
+	case quantity == 0:
+		return
+	case found:
+		s.levels[i].Quantity = quantity
+	default:
+		s.levels = append(s.levels, PriceLevel{})
+		copy(s.levels[i+1:], s.levels[i:])
+		s.levels[i] = PriceLevel{Price: price, Quantity: quantity}
+	}
+}
+
+func (s *bookSide) best() (PriceLevel, bool) {
+	if len(s.levels) == 0 {
+		return PriceLevel{}, false
+	}
+
+	return s.levels[0], true
+}
+
+func (s *bookSide) top(depth int) []PriceLevel {
+	n := min(depth, len(s.levels))
+
+	levels := make([]PriceLevel, n)
+	copy(levels, s.levels[:n])
+
+	return levels
+}
+
+func parsePriceLevel(level []string) (price, quantity float64, err error) {
+	if len(level) < 2 { //nolint:mnd
+		return 0, 0, fmt.Errorf("%w: %v", ErrInvalidPriceLevel, level)
+	}
+
+	price, err = strconv.ParseFloat(level[0], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: price %q: %w", ErrInvalidPriceLevel, level[0], err)
+	}
+
+	quantity, err = strconv.ParseFloat(level[1], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: quantity %q: %w", ErrInvalidPriceLevel, level[1], err)
+	}
+
+	return price, quantity, nil
+}
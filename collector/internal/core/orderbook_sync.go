@@ -0,0 +1,197 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	maxBufferedEvents = 1000
+	minResyncInterval = time.Second
+)
+
+var (
+	ErrSequenceGap   = errors.New("order book sequence gap")
+	ErrStaleSnapshot = errors.New("depth snapshot is older than buffered events")
+)
+
+// OrderBookManager maintains one local order book per symbol from a REST depth
+// snapshot and the depth diff stream, following Binance's sequencing rules:
+// events with u <= lastUpdateId are dropped, the first applied event must
+// straddle lastUpdateId+1 and every following event must start at the previous u+1.
+// Any gap discards the book and triggers a resync from a fresh snapshot.
+// Snapshots are fetched in the background while the diffs of the symbol are
+// buffered, so a resync never holds up the streams of other symbols.
+type OrderBookManager struct {
+	provider   DepthSnapshotProvider
+	depthLimit int
+	books      map[string]*syncedBook
+	// fetches tracks the snapshot requests in flight
+	fetches sync.WaitGroup
+}
+
+type syncedBook struct {
+	book        *OrderBook
+	synced      bool
+	buffer      []OrderBookData
+	lastAttempt time.Time
+	// fetched delivers the snapshot being fetched, nil while none is
+	fetched chan snapshotResult
+}
+
+type snapshotResult struct {
+	snapshot DepthSnapshot
+	err      error
+}
+
+func NewOrderBookManager(provider DepthSnapshotProvider, depthLimit int) *OrderBookManager {
+	return &OrderBookManager{
+		provider:   provider,
+		depthLimit: depthLimit,
+		books:      make(map[string]*syncedBook),
+	}
+}
+
+// Apply feeds a depth diff event into the book of its symbol.
+func (m *OrderBookManager) Apply(ctx context.Context, data OrderBookData) error {
+	sb, ok := m.books[data.Symbol]
+	if !ok {
+		sb = &syncedBook{book: NewOrderBook(data.Symbol)}
+		m.books[data.Symbol] = sb
+	}
+
+	if sb.synced {
+		if data.FinalUpdateID <= sb.book.LastUpdateID() {
+			return nil
+		}
+
+		if data.FirstUpdateID == sb.book.LastUpdateID()+1 {
+			return sb.book.Apply(data)
+		}
+
+		log.Printf("Order book %s out of sequence (expected U=%d, got U=%d), resyncing",
+			data.Symbol, sb.book.LastUpdateID()+1, data.FirstUpdateID)
+
+		sb.synced = false
+		sb.lastAttempt = time.Time{}
+	}
+
+	sb.buffer = append(sb.buffer, data)
+	if len(sb.buffer) > maxBufferedEvents {
+		sb.buffer = sb.buffer[len(sb.buffer)-maxBufferedEvents:]
+	}
+
+	if sb.fetched != nil {
+		return m.collect(sb)
+	}
+
+	if time.Since(sb.lastAttempt) < minResyncInterval {
+		return nil
+	}
+
+	m.fetch(ctx, sb)
+
+	return nil
+}
+
+// Books returns the order books that are currently in sync, after loading the
+// snapshots fetched since the last call.
+func (m *OrderBookManager) Books() []*OrderBook {
+	books := make([]*OrderBook, 0, len(m.books))
+
+	for _, sb := range m.books {
+		if sb.fetched != nil {
+			if err := m.collect(sb); err != nil {
+				log.Printf("Failed to update local order book: %v", err)
+			}
+		}
+
+		if sb.synced {
+			books = append(books, sb.book)
+		}
+	}
+
+	return books
+}
+
+// fetch requests a snapshot of the book in the background.
+func (m *OrderBookManager) fetch(ctx context.Context, sb *syncedBook) {
+	sb.lastAttempt = time.Now()
+
+	fetched := make(chan snapshotResult, 1)
+	sb.fetched = fetched
+	symbol := sb.book.Symbol()
+
+	m.fetches.Add(1)
+
+	go func() {
+		defer m.fetches.Done()
+
+		snapshot, err := m.provider.GetDepthSnapshot(ctx, symbol, m.depthLimit)
+		fetched <- snapshotResult{snapshot: snapshot, err: err}
+	}()
+}
+
+// collect loads the fetched snapshot, if it arrived, and replays the buffered
+// diffs onto it.
+func (m *OrderBookManager) collect(sb *syncedBook) error {
+	var result snapshotResult
+
+	select {
+	case result = <-sb.fetched:
+		sb.fetched = nil
+	default:
+		return nil
+	}
+
+	if result.err != nil {
+		return fmt.Errorf("failed to fetch depth snapshot for %s: %w", sb.book.Symbol(), result.err)
+	}
+
+	return m.load(sb, result.snapshot)
+}
+
+func (m *OrderBookManager) load(sb *syncedBook, snapshot DepthSnapshot) error {
+	if err := sb.book.LoadSnapshot(snapshot); err != nil {
+		return fmt.Errorf("failed to load depth snapshot for %s: %w", sb.book.Symbol(), err)
+	}
+
+	for i, data := range sb.buffer {
+		lastUpdateID := sb.book.LastUpdateID()
+
+		if data.FinalUpdateID <= lastUpdateID {
+			continue
+		}
+
+		if data.FirstUpdateID > lastUpdateID+1 {
+			sb.synced = false
+			sb.buffer = sb.buffer[i:]
+
+			if lastUpdateID == snapshot.LastUpdateID {
+				return fmt.Errorf("%w: %s snapshot %d, next event U=%d",
+					ErrStaleSnapshot, sb.book.Symbol(), snapshot.LastUpdateID, data.FirstUpdateID)
+			}
+
+			return fmt.Errorf("%w: %s expected U=%d, got U=%d",
+				ErrSequenceGap, sb.book.Symbol(), lastUpdateID+1, data.FirstUpdateID)
+		}
+
+		if err := sb.book.Apply(data); err != nil {
+			sb.synced = false
+			sb.buffer = nil
+
+			return fmt.Errorf("failed to apply depth event for %s: %w", sb.book.Symbol(), err)
+		}
+	}
+
+	sb.synced = true
+	sb.buffer = nil
+
+	log.Printf("Order book %s synchronized at update %d", sb.book.Symbol(), sb.book.LastUpdateID())
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSnapshotProvider struct {
+	snapshots []DepthSnapshot
+	calls     int
+}
+
+func (p *stubSnapshotProvider) GetDepthSnapshot(_ context.Context, _ string, _ int) (DepthSnapshot, error) {
+	snapshot := p.snapshots[min(p.calls, len(p.snapshots)-1)]
+	p.calls++
+
+	return snapshot, nil
+}
+
+// blockingSnapshotProvider holds every snapshot request until release is closed.
+type blockingSnapshotProvider struct {
+	release chan struct{}
+}
+
+func (p *blockingSnapshotProvider) GetDepthSnapshot(_ context.Context, _ string, _ int) (DepthSnapshot, error) {
+	<-p.release
+
+	return DepthSnapshot{LastUpdateID: 100, Bids: [][]string{{"100", "1"}}, Asks: [][]string{{"101", "1"}}}, nil
+}
+
+func depthEvent(first, final int64, bids, asks [][]string) OrderBookData {
+	return OrderBookData{
+		EventType:     "depthUpdate",
+		Symbol:        "BTCUSDT",
+		FirstUpdateID: first,
+		FinalUpdateID: final,
+		BidUpdates:    bids,
+		AskUpdates:    asks,
+	}
+}
+
+func TestOrderBookKeepsSidesSorted(t *testing.T) {
+	book := NewOrderBook("BTCUSDT")
+
+	err := book.LoadSnapshot(DepthSnapshot{
+		LastUpdateID: 10,
+		Bids:         [][]string{{"99", "1"}, {"101", "2"}, {"100", "3"}},
+		Asks:         [][]string{{"104", "1"}, {"102", "2"}, {"103", "3"}},
+	})
+	require.NoError(t, err)
+
+	snapshot := book.Snapshot(2, time.Time{})
+
+	assert.Equal(t, []PriceLevel{{Price: 101, Quantity: 2}, {Price: 100, Quantity: 3}}, snapshot.Bids)
+	assert.Equal(t, []PriceLevel{{Price: 102, Quantity: 2}, {Price: 103, Quantity: 3}}, snapshot.Asks)
+	assert.Equal(t, int64(10), snapshot.LastUpdateID)
+}
+
+func TestOrderBookApplyUpdatesAndRemovesLevels(t *testing.T) {
+	book := NewOrderBook("BTCUSDT")
+
+	require.NoError(t, book.LoadSnapshot(DepthSnapshot{
+		LastUpdateID: 10,
+		Bids:         [][]string{{"100", "1"}, {"99", "1"}},
+		Asks:         [][]string{{"101", "1"}},
+	}))
+
+	err := book.Apply(depthEvent(11, 12, [][]string{{"100", "0"}, {"99", "5"}}, [][]string{{"100.5", "2"}}))
+	require.NoError(t, err)
+
+	bid, ok := book.BestBid()
+	assert.True(t, ok)
+	assert.Equal(t, PriceLevel{Price: 99, Quantity: 5}, bid)
+
+	ask, ok := book.BestAsk()
+	assert.True(t, ok)
+	assert.Equal(t, PriceLevel{Price: 100.5, Quantity: 2}, ask)
+	assert.Equal(t, int64(12), book.LastUpdateID())
+}
+
+func TestOrderBookInvalidLevelError(t *testing.T) {
+	book := NewOrderBook("BTCUSDT")
+
+	err := book.Apply(depthEvent(1, 2, [][]string{{"invalid", "1"}}, nil))
+
+	assert.ErrorIs(t, err, ErrInvalidPriceLevel)
+}
+
+func TestOrderBookManagerDropsEventsOlderThanSnapshot(t *testing.T) {
+	provider := &stubSnapshotProvider{snapshots: []DepthSnapshot{
+		{LastUpdateID: 100, Bids: [][]string{{"100", "1"}}, Asks: [][]string{{"101", "1"}}},
+	}}
+	manager := NewOrderBookManager(provider, 1000)
+
+	err := manager.Apply(context.Background(), depthEvent(95, 105, [][]string{{"100", "4"}}, nil))
+	require.NoError(t, err)
+
+	manager.fetches.Wait()
+
+	books := manager.Books()
+	require.Len(t, books, 1)
+	assert.Equal(t, int64(105), books[0].LastUpdateID())
+
+	bid, _ := books[0].BestBid()
+	assert.InDelta(t, 4.0, bid.Quantity, 0)
+
+	// Duplicate events are ignored once synchronized.
+	require.NoError(t, manager.Apply(context.Background(), depthEvent(95, 105, [][]string{{"100", "9"}}, nil)))
+
+	bid, _ = books[0].BestBid()
+	assert.InDelta(t, 4.0, bid.Quantity, 0)
+}
+
+func TestOrderBookManagerStaleSnapshotNotSynced(t *testing.T) {
+	provider := &stubSnapshotProvider{snapshots: []DepthSnapshot{{LastUpdateID: 100}}}
+	manager := NewOrderBookManager(provider, 1000)
+
+	require.NoError(t, manager.Apply(context.Background(), depthEvent(110, 120, nil, nil)))
+	manager.fetches.Wait()
+
+	err := manager.Apply(context.Background(), depthEvent(121, 125, nil, nil))
+
+	assert.ErrorIs(t, err, ErrStaleSnapshot)
+	assert.Empty(t, manager.Books())
+}
+
+func TestOrderBookManagerGapTriggersResync(t *testing.T) {
+	provider := &stubSnapshotProvider{snapshots: []DepthSnapshot{
+		{LastUpdateID: 100, Bids: [][]string{{"100", "1"}}},
+		{LastUpdateID: 130, Bids: [][]string{{"200", "1"}}},
+	}}
+	manager := NewOrderBookManager(provider, 1000)
+
+	require.NoError(t, manager.Apply(context.Background(), depthEvent(101, 110, nil, nil)))
+	manager.fetches.Wait()
+	require.Len(t, manager.Books(), 1)
+
+	// U=120 does not follow u=110, so the book is rebuilt from a new snapshot.
+	require.NoError(t, manager.Apply(context.Background(), depthEvent(120, 135, nil, nil)))
+	manager.fetches.Wait()
+
+	books := manager.Books()
+	require.Len(t, books, 1)
+	assert.Equal(t, 2, provider.calls)
+	assert.Equal(t, int64(135), books[0].LastUpdateID())
+
+	bid, _ := books[0].BestBid()
+	assert.InDelta(t, 200.0, bid.Price, 0)
+}
+
+func TestOrderBookManagerBuffersDiffsWhileFetchingSnapshots(t *testing.T) {
+	ctx := context.Background()
+	provider := &blockingSnapshotProvider{release: make(chan struct{})}
+	manager := NewOrderBookManager(provider, 1000)
+
+	other := depthEvent(101, 102, nil, nil)
+	other.Symbol = "ETHUSDT"
+
+	// Neither symbol waits for its snapshot, the diffs are buffered meanwhile
+	require.NoError(t, manager.Apply(ctx, depthEvent(95, 105, [][]string{{"100", "4"}}, nil)))
+	require.NoError(t, manager.Apply(ctx, other))
+	require.NoError(t, manager.Apply(ctx, depthEvent(106, 110, [][]string{{"99", "2"}}, nil)))
+	assert.Empty(t, manager.Books())
+
+	close(provider.release)
+	manager.fetches.Wait()
+
+	books := manager.Books()
+	require.Len(t, books, 2)
+
+	for _, book := range books {
+		if book.Symbol() == "BTCUSDT" {
+			assert.Equal(t, int64(110), book.LastUpdateID())
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package core
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	candleInterval   = time.Minute
+	downsampleWindow = time.Hour
+	midPriceDivisor  = 2
+)
+
+// RetentionPolicy defines how long a symbol's data is kept. Raw depth diffs are
+// deleted after RawDepth and book snapshots after BookSnapshots, once they have
+// been downsampled into one-minute candles which are kept forever. A zero
+// duration keeps the data forever.
+type RetentionPolicy struct {
+	RawDepth      time.Duration
+	BookSnapshots time.Duration
+}
+
+// RetentionService periodically downsamples and expires old market data.
+type RetentionService struct {
+	repo      RetentionRepository
+	symbols   []string
+	defaults  RetentionPolicy
+	overrides map[string]RetentionPolicy
+	interval  time.Duration
+}
+
+func NewRetentionService(
+	repo RetentionRepository,
+	symbols []string,
+	defaults RetentionPolicy,
+	overrides map[string]RetentionPolicy,
+	interval time.Duration,
+) *RetentionService {
+	normalized := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		normalized = append(normalized, strings.ToUpper(symbol))
+	}
+
+	return &RetentionService{
+		repo:      repo,
+		symbols:   normalized,
+		defaults:  defaults,
+		overrides: overrides,
+		interval:  interval,
+	}
+}
+
+// PolicyFor returns the policy of symbol, falling back to the defaults.
+func (s *RetentionService) PolicyFor(symbol string) RetentionPolicy {
+	if policy, ok := s.overrides[strings.ToUpper(symbol)]; ok {
+		return policy
+	}
+
+	return s.defaults
+}
+
+// Run enforces the policies every interval until ctx is done.
+func (s *RetentionService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Enforce(ctx, time.Now()); err != nil {
+			log.Printf("Retention run failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Enforce downsamples every symbol's completed minutes and then deletes the
+// data that is older than its policy. Snapshots are never deleted before they
+// have been downsampled.
+func (s *RetentionService) Enforce(ctx context.Context, now time.Time) error {
+	for _, symbol := range s.symbols {
+		if err := s.enforceSymbol(ctx, symbol, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *RetentionService) enforceSymbol(ctx context.Context, symbol string, now time.Time) error {
+	policy := s.PolicyFor(symbol)
+
+	downsampled, err := s.downsample(ctx, symbol, now)
+	if err != nil {
+		return err
+	}
+
+	if policy.BookSnapshots > 0 {
+		cutoff := now.Add(-policy.BookSnapshots)
+		if downsampled.Before(cutoff) {
+			cutoff = downsampled
+		}
+
+		deleted, err := s.repo.DeleteBookSnapshotsBefore(ctx, symbol, cutoff)
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			log.Printf("Retention: deleted %d %s book snapshots before %s", deleted, symbol, cutoff.Format(time.RFC3339))
+		}
+	}
+
+	if policy.RawDepth > 0 {
+		cutoff := now.Add(-policy.RawDepth)
+
+		deleted, err := s.repo.DeleteDepthBefore(ctx, symbol, cutoff)
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			log.Printf("Retention: deleted %d %s depth updates before %s", deleted, symbol, cutoff.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+// downsample turns the book snapshots of every completed minute since the last
+// candle into candles and returns the time up to which snapshots are covered.
+// Windows without snapshots are skipped by jumping to the next snapshot.
+func (s *RetentionService) downsample(ctx context.Context, symbol string, now time.Time) (time.Time, error) {
+	until := now.Truncate(candleInterval)
+
+	from, ok, err := s.repo.LatestBookCandleTime(ctx, symbol)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if ok {
+		from = from.Add(candleInterval)
+	}
+
+	for from.Before(until) {
+		next, ok, err := s.repo.NextBookSnapshotTime(ctx, symbol, from)
+		if err != nil {
+			return from, err
+		}
+
+		if !ok || !next.Before(until) {
+			break
+		}
+
+		from = next.Truncate(candleInterval)
+
+		to := from.Add(downsampleWindow)
+		if to.After(until) {
+			to = until
+		}
+
+		snapshots, err := s.repo.GetBookSnapshots(ctx, symbol, from, to)
+		if err != nil {
+			return from, err
+		}
+
+		if candles := DownsampleSnapshots(snapshots); len(candles) > 0 {
+			if err := s.repo.SaveBookCandles(ctx, candles); err != nil {
+				return from, err
+			}
+		}
+
+		from = to
+	}
+
+	return until, nil
+}
+
+// DownsampleSnapshots builds one-minute mid price candles from snapshots sorted
+// by time. Snapshots with an empty side are skipped.
+func DownsampleSnapshots(snapshots []BookSnapshot) []BookCandle {
+	var candles []BookCandle
+
+	for _, snapshot := range snapshots {
+		if len(snapshot.Bids) == 0 || len(snapshot.Asks) == 0 {
+			continue
+		}
+
+		bid, ask := snapshot.Bids[0].Price, snapshot.Asks[0].Price
+		mid := (bid + ask) / midPriceDivisor
+		openTime := snapshot.Timestamp.Truncate(candleInterval)
+
+		if n := len(candles); n > 0 && candles[n-1].OpenTime.Equal(openTime) {
+			candle := &candles[n-1]
+			candle.High = max(candle.High, mid)
+			candle.Low = min(candle.Low, mid)
+			candle.Close = mid
+			candle.Spread += (ask - bid - candle.Spread) / float64(candle.Samples+1)
+			candle.Samples++
+
+			continue
+		}
+
+		candles = append(candles, BookCandle{
+			Symbol:    snapshot.Symbol,
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(candleInterval),
+			Open:      mid,
+			High:      mid,
+			Low:       mid,
+			Close:     mid,
+			Spread:    ask - bid,
+			Samples:   1,
+		})
+	}
+
+	return candles
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubRetentionRepository struct {
+	snapshots      []BookSnapshot
+	candles        []BookCandle
+	depthBefore    map[string]time.Time
+	snapshotBefore map[string]time.Time
+	reads          int
+}
+
+func (r *stubRetentionRepository) DeleteDepthBefore(_ context.Context, symbol string, before time.Time) (int64, error) {
+	r.depthBefore[symbol] = before
+
+	return 0, nil
+}
+
+func (r *stubRetentionRepository) NextBookSnapshotTime(
+	_ context.Context,
+	_ string,
+	from time.Time,
+) (time.Time, bool, error) {
+	for _, snapshot := range r.snapshots {
+		if !snapshot.Timestamp.Before(from) {
+			return snapshot.Timestamp, true, nil
+		}
+	}
+
+	return time.Time{}, false, nil
+}
+
+func (r *stubRetentionRepository) GetBookSnapshots(
+	_ context.Context,
+	_ string,
+	from, to time.Time,
+) ([]BookSnapshot, error) {
+	r.reads++
+
+	var result []BookSnapshot
+
+	for _, snapshot := range r.snapshots {
+		if !snapshot.Timestamp.Before(from) && snapshot.Timestamp.Before(to) {
+			result = append(result, snapshot)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *stubRetentionRepository) DeleteBookSnapshotsBefore(
+	_ context.Context,
+	symbol string,
+	before time.Time,
+) (int64, error) {
+	r.snapshotBefore[symbol] = before
+
+	return 0, nil
+}
+
+func (r *stubRetentionRepository) LatestBookCandleTime(_ context.Context, _ string) (time.Time, bool, error) {
+	if len(r.candles) == 0 {
+		return time.Time{}, false, nil
+	}
+
+	return r.candles[len(r.candles)-1].OpenTime, true, nil
+}
+
+func (r *stubRetentionRepository) SaveBookCandles(_ context.Context, candles []BookCandle) error {
+	r.candles = append(r.candles, candles...)
+
+	return nil
+}
+
+func bookSnapshot(at time.Time, bid, ask float64) BookSnapshot {
+	return BookSnapshot{
+		Symbol:    "BTCUSDT",
+		Bids:      []PriceLevel{{Price: bid, Quantity: 1}},
+		Asks:      []PriceLevel{{Price: ask, Quantity: 1}},
+		Timestamp: at,
+	}
+}
+
+func TestDownsampleSnapshotsBuildsMinuteCandles(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	candles := DownsampleSnapshots([]BookSnapshot{
+		bookSnapshot(start, 99, 101),
+		bookSnapshot(start.Add(10*time.Second), 103, 105),
+		bookSnapshot(start.Add(20*time.Second), 97, 99),
+		{Symbol: "BTCUSDT", Timestamp: start.Add(30 * time.Second)},
+		bookSnapshot(start.Add(time.Minute), 100, 102),
+	})
+
+	require.Len(t, candles, 2)
+	assert.Equal(t, BookCandle{
+		Symbol:    "BTCUSDT",
+		OpenTime:  start,
+		CloseTime: start.Add(time.Minute),
+		Open:      100,
+		High:      104,
+		Low:       98,
+		Close:     98,
+		Spread:    2,
+		Samples:   3,
+	}, candles[0])
+	assert.Equal(t, start.Add(time.Minute), candles[1].OpenTime)
+	assert.InDelta(t, 101.0, candles[1].Open, 0)
+}
+
+func TestRetentionDeletesSnapshotsOnlyAfterDownsampling(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(3*time.Hour + 30*time.Second)
+
+	repo := &stubRetentionRepository{
+		depthBefore:    map[string]time.Time{},
+		snapshotBefore: map[string]time.Time{},
+	}
+	for at := start; at.Before(now); at = at.Add(time.Minute) {
+		repo.snapshots = append(repo.snapshots, bookSnapshot(at, 100, 101))
+	}
+
+	service := NewRetentionService(
+		repo,
+		[]string{"btcusdt", "ethusdt"},
+		RetentionPolicy{RawDepth: time.Hour, BookSnapshots: time.Nanosecond},
+		map[string]RetentionPolicy{"ETHUSDT": {}},
+		time.Minute,
+	)
+
+	require.NoError(t, service.Enforce(context.Background(), now))
+
+	// The current minute is incomplete, so its snapshots are neither downsampled nor deleted.
+	assert.Len(t, repo.candles, 180)
+	assert.Equal(t, now.Truncate(time.Minute), repo.snapshotBefore["BTCUSDT"])
+	assert.Equal(t, now.Add(-time.Hour), repo.depthBefore["BTCUSDT"])
+
+	// ETHUSDT keeps everything forever.
+	assert.NotContains(t, repo.snapshotBefore, "ETHUSDT")
+	assert.NotContains(t, repo.depthBefore, "ETHUSDT")
+
+	// A second run resumes after the last candle instead of rebuilding it.
+	require.NoError(t, service.Enforce(context.Background(), now.Add(time.Minute)))
+	assert.Len(t, repo.candles, 181)
+}
+
+func TestRetentionSkipsWindowsWithoutSnapshots(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	resumed := start.AddDate(1, 0, 0)
+
+	repo := &stubRetentionRepository{
+		candles:        []BookCandle{{Symbol: "BTCUSDT", OpenTime: start}},
+		depthBefore:    map[string]time.Time{},
+		snapshotBefore: map[string]time.Time{},
+	}
+	repo.snapshots = []BookSnapshot{
+		bookSnapshot(resumed.Add(10*time.Second), 100, 101),
+		bookSnapshot(resumed.Add(3*time.Minute), 100, 101),
+	}
+
+	service := NewRetentionService(repo, []string{"BTCUSDT"}, RetentionPolicy{}, nil, time.Minute)
+
+	// A year without snapshots is skipped rather than read hour by hour
+	require.NoError(t, service.Enforce(context.Background(), resumed.Add(time.Hour)))
+	assert.Len(t, repo.candles, 3)
+	assert.Equal(t, 1, repo.reads)
+}
@@ -0,0 +1,56 @@
+package core
+
+import "time"
+
+type SequenceStatus int
+
+const (
+	SequenceFirst SequenceStatus = iota
+	SequenceInOrder
+	SequenceDuplicate
+	SequenceGapDetected
+)
+
+// SequenceTracker tracks the last FinalUpdateID seen per symbol on the depth
+// stream and classifies every new event as in order, duplicate or gap.
+type SequenceTracker struct {
+	last map[string]OrderBookData
+}
+
+func NewSequenceTracker() *SequenceTracker {
+	return &SequenceTracker{
+		last: make(map[string]OrderBookData),
+	}
+}
+
+// Check classifies data against the previous event of its symbol. For gaps the
+// returned SequenceGap describes the window that is missing from the history.
+func (t *SequenceTracker) Check(data OrderBookData, receivedAt time.Time) (SequenceStatus, *SequenceGap) {
+	previous, ok := t.last[data.Symbol]
+	if !ok {
+		t.last[data.Symbol] = data
+
+		return SequenceFirst, nil
+	}
+
+	if data.FinalUpdateID <= previous.FinalUpdateID {
+		return SequenceDuplicate, nil
+	}
+
+	t.last[data.Symbol] = data
+
+	if data.FirstUpdateID <= previous.FinalUpdateID+1 {
+		return SequenceInOrder, nil
+	}
+
+	return SequenceGapDetected, &SequenceGap{
+		Type:             GapType,
+		Symbol:           data.Symbol,
+		ExpectedUpdateID: previous.FinalUpdateID + 1,
+		ReceivedUpdateID: data.FirstUpdateID,
+		MissedUpdates:    data.FirstUpdateID - previous.FinalUpdateID - 1,
+		From:             time.UnixMilli(previous.EventTime),
+		To:               time.UnixMilli(data.EventTime),
+		DetectedAt:       receivedAt,
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceTrackerInOrder(t *testing.T) {
+	tracker := NewSequenceTracker()
+
+	status, gap := tracker.Check(depthEvent(1, 10, nil, nil), time.Now())
+	assert.Equal(t, SequenceFirst, status)
+	assert.Nil(t, gap)
+
+	status, gap = tracker.Check(depthEvent(11, 20, nil, nil), time.Now())
+	assert.Equal(t, SequenceInOrder, status)
+	assert.Nil(t, gap)
+}
+
+func TestSequenceTrackerDuplicate(t *testing.T) {
+	tracker := NewSequenceTracker()
+
+	tracker.Check(depthEvent(1, 10, nil, nil), time.Now())
+
+	status, gap := tracker.Check(depthEvent(1, 10, nil, nil), time.Now())
+
+	assert.Equal(t, SequenceDuplicate, status)
+	assert.Nil(t, gap)
+}
+
+func TestSequenceTrackerGap(t *testing.T) {
+	tracker := NewSequenceTracker()
+
+	first := depthEvent(1, 10, nil, nil)
+	first.EventTime = 1000
+	next := depthEvent(15, 20, nil, nil)
+	next.EventTime = 3000
+
+	tracker.Check(first, time.Now())
+
+	status, gap := tracker.Check(next, time.Now())
+
+	assert.Equal(t, SequenceGapDetected, status)
+	require.NotNil(t, gap)
+	assert.Equal(t, int64(11), gap.ExpectedUpdateID)
+	assert.Equal(t, int64(4), gap.MissedUpdates)
+	assert.Equal(t, time.UnixMilli(1000), gap.From)
+	assert.Equal(t, time.UnixMilli(3000), gap.To)
+
+	// Tracking continues from the event after the gap.
+	status, _ = tracker.Check(depthEvent(21, 25, nil, nil), time.Now())
+	assert.Equal(t, SequenceInOrder, status)
+}
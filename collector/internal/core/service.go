@@ -10,15 +10,44 @@ import (
 	"time"
 )
 
+type ServiceConfig struct {
+	Streams          []string
+	SnapshotInterval time.Duration
+	SnapshotDepth    int
+}
+
 type DataCollectorService struct {
-	wsClient   WebSocketClient
-	repository OrderBookRepository
+	wsClient     WebSocketClient
+	repository   OrderBookRepository
+	tradeRepo    TradeRepository
+	eventRepo    StreamEventRepository
+	gapRepo      GapRepository
+	snapshotRepo BookSnapshotRepository
+	sequences    *SequenceTracker
+	books        *OrderBookManager
+	config       ServiceConfig
 }
 
-func NewDataCollectorService(wsClient WebSocketClient, repository OrderBookRepository) *DataCollectorService {
+func NewDataCollectorService(
+	wsClient WebSocketClient,
+	repository OrderBookRepository,
+	tradeRepo TradeRepository,
+	eventRepo StreamEventRepository,
+	gapRepo GapRepository,
+	snapshotRepo BookSnapshotRepository,
+	books *OrderBookManager,
+	config ServiceConfig,
+) *DataCollectorService {
 	return &DataCollectorService{
-		wsClient:   wsClient,
-		repository: repository,
+		wsClient:     wsClient,
+		repository:   repository,
+		tradeRepo:    tradeRepo,
+		eventRepo:    eventRepo,
+		gapRepo:      gapRepo,
+		snapshotRepo: snapshotRepo,
+		sequences:    NewSequenceTracker(),
+		books:        books,
+		config:       config,
 	}
 }
 
@@ -38,28 +67,27 @@ func (s *DataCollectorService) Run(ctx context.Context) error {
 	// Start reading messages
 	msgChan, errChan := s.wsClient.ReadMessages()
 
-	for {
-		select {
-		case message := <-msgChan:
-			log.Printf("Received data: %s", message)
+	// Subscribe to the configured streams
+	if err := s.wsClient.Subscribe(s.config.Streams); err != nil {
+		return err
+	}
 
-			var data OrderBookData
+	log.Printf("Subscribed to streams: %v", s.config.Streams)
 
-			err := json.Unmarshal(message, &data)
-			if err != nil {
-				log.Printf("Failed to unmarshal message: %v", err)
+	snapshotTicker := time.NewTicker(s.config.SnapshotInterval)
+	defer snapshotTicker.Stop()
 
-				continue
+	for {
+		select {
+		case message, ok := <-msgChan:
+			if !ok {
+				return nil
 			}
 
-			update := OrderBookUpdate{
-				Data:      data,
-				Timestamp: time.Now(),
-			}
+			s.handleMessage(ctx, message)
 
-			if err := s.repository.Save(ctx, update); err != nil {
-				log.Printf("Failed to save order book update: %v", err)
-			}
+		case <-snapshotTicker.C:
+			s.saveBookSnapshots(ctx)
 
 		case err := <-errChan:
 			return err
@@ -74,3 +102,148 @@ func (s *DataCollectorService) Run(ctx context.Context) error {
 		}
 	}
 }
+
+// handleMessage routes a combined stream message to the decoder of its stream type.
+func (s *DataCollectorService) handleMessage(ctx context.Context, message []byte) {
+	log.Printf("Received data: %s", message)
+
+	var msg StreamMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("Failed to unmarshal message: %v", err)
+
+		return
+	}
+
+	if msg.Stream == "" {
+		handleControlResponse(message)
+
+		return
+	}
+
+	symbol, streamType, err := ParseStreamName(msg.Stream)
+	if err != nil {
+		log.Printf("Failed to route message: %v", err)
+
+		return
+	}
+
+	switch streamType {
+	case StreamDepth:
+		var data OrderBookData
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			log.Printf("Failed to unmarshal depth message: %v", err)
+
+			return
+		}
+
+		s.handleDepth(ctx, data)
+
+		return
+
+	case StreamTrade, StreamAggTrade:
+		s.handleTrade(ctx, msg.Data)
+
+		return
+	}
+
+	decode, err := DecoderFor(streamType)
+	if err != nil {
+		log.Printf("Failed to route message: %v", err)
+
+		return
+	}
+
+	data, err := decode(msg.Data)
+	if err != nil {
+		log.Printf("Failed to unmarshal %s message: %v", streamType, err)
+
+		return
+	}
+
+	event := StreamEvent{
+		StreamType: streamType,
+		Symbol:     symbol,
+		Data:       data,
+		Timestamp:  time.Now(),
+	}
+
+	if err := s.eventRepo.SaveEvent(ctx, event); err != nil {
+		log.Printf("Failed to save %s event: %v", streamType, err)
+	}
+}
+
+func handleControlResponse(message []byte) {
+	var resp ControlResponse
+	if err := json.Unmarshal(message, &resp); err != nil {
+		log.Printf("Failed to unmarshal control response: %v", err)
+
+		return
+	}
+
+	if resp.Error != nil {
+		log.Printf("Subscription request %d failed: %d %s", resp.ID, resp.Error.Code, resp.Error.Msg)
+
+		return
+	}
+
+	log.Printf("Subscription request %d acknowledged", resp.ID)
+}
+
+func (s *DataCollectorService) handleDepth(ctx context.Context, data OrderBookData) {
+	now := time.Now()
+
+	status, gap := s.sequences.Check(data, now)
+
+	switch status {
+	case SequenceDuplicate:
+		log.Printf("Skipping duplicate depth event %s U=%d u=%d", data.Symbol, data.FirstUpdateID, data.FinalUpdateID)
+
+		return
+	case SequenceGapDetected:
+		log.Printf("Depth sequence gap for %s: expected U=%d, got U=%d (%d updates missed)",
+			gap.Symbol, gap.ExpectedUpdateID, gap.ReceivedUpdateID, gap.MissedUpdates)
+
+		if err := s.gapRepo.SaveGap(ctx, *gap); err != nil {
+			log.Printf("Failed to save sequence gap: %v", err)
+		}
+	case SequenceFirst, SequenceInOrder:
+	}
+
+	update := OrderBookUpdate{
+		Data:      data,
+		Timestamp: now,
+	}
+
+	if err := s.repository.Save(ctx, update); err != nil {
+		log.Printf("Failed to save order book update: %v", err)
+	}
+
+	if err := s.books.Apply(ctx, data); err != nil {
+		log.Printf("Failed to update local order book: %v", err)
+	}
+}
+
+func (s *DataCollectorService) handleTrade(ctx context.Context, payload []byte) {
+	trade, err := DecodeTrade(payload)
+	if err != nil {
+		log.Printf("Failed to decode trade message: %v", err)
+
+		return
+	}
+
+	trade.Timestamp = time.Now()
+
+	if err := s.tradeRepo.SaveTrade(ctx, trade); err != nil {
+		log.Printf("Failed to save trade: %v", err)
+	}
+}
+
+func (s *DataCollectorService) saveBookSnapshots(ctx context.Context) {
+	now := time.Now()
+
+	for _, book := range s.books.Books() {
+		if err := s.snapshotRepo.SaveBookSnapshot(ctx, book.Snapshot(s.config.SnapshotDepth, now)); err != nil {
+			log.Printf("Failed to save order book snapshot for %s: %v", book.Symbol(), err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	StreamDepth      = "depth"
+	StreamTrade      = "trade"
+	StreamAggTrade   = "aggTrade"
+	StreamBookTicker = "bookTicker"
+	StreamKline      = "kline_"
+)
+
+var (
+	ErrInvalidStreamName = errors.New("invalid stream name")
+	ErrUnsupportedStream = errors.New("unsupported stream type")
+)
+
+// StreamMessage is the envelope of a Binance combined stream message.
+type StreamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// ControlResponse is the reply to a SUBSCRIBE/UNSUBSCRIBE request.
+type ControlResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
+}
+
+// Decoder decodes the payload of one stream type.
+type Decoder func(payload []byte) (any, error)
+
+// StreamNames builds the "<symbol>@<type>" stream names for every symbol and stream type.
+func StreamNames(symbols, streamTypes []string) []string {
+	names := make([]string, 0, len(symbols)*len(streamTypes))
+
+	for _, symbol := range symbols {
+		for _, streamType := range streamTypes {
+			names = append(names, strings.ToLower(symbol)+"@"+streamType)
+		}
+	}
+
+	return names
+}
+
+// ParseStreamName splits a stream name such as "btcusdt@depth@100ms" into its
+// upper-case symbol and stream type.
+func ParseStreamName(stream string) (symbol, streamType string, err error) {
+	parts := strings.Split(stream, "@")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" { //nolint:mnd
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidStreamName, stream)
+	}
+
+	return strings.ToUpper(parts[0]), parts[1], nil
+}
+
+// DecoderFor returns the payload decoder of a stream type stored as a generic
+// StreamEvent. Depth and trade streams have dedicated handlers.
+func DecoderFor(streamType string) (Decoder, error) {
+	switch {
+	case streamType == StreamBookTicker:
+		return decodeInto[BookTicker], nil
+	case strings.HasPrefix(streamType, StreamKline):
+		return decodeInto[KlineEvent], nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedStream, streamType)
+	}
+}
+
+func decodeInto[T any](payload []byte) (any, error) {
+	var v T
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamNamesCombinesSymbolsAndTypes(t *testing.T) {
+	names := StreamNames([]string{"BTCUSDT", "ethusdt"}, []string{"depth", "kline_1m"})
+
+	expected := []string{"btcusdt@depth", "btcusdt@kline_1m", "ethusdt@depth", "ethusdt@kline_1m"}
+
+	assert.Equal(t, expected, names)
+}
+
+func TestParseStreamName(t *testing.T) {
+	tests := []struct {
+		stream     string
+		symbol     string
+		streamType string
+		wantErr    bool
+	}{
+		{stream: "btcusdt@depth", symbol: "BTCUSDT", streamType: "depth"},
+		{stream: "btcusdt@depth@100ms", symbol: "BTCUSDT", streamType: "depth"},
+		{stream: "ethusdt@kline_1m", symbol: "ETHUSDT", streamType: "kline_1m"},
+		{stream: "btcusdt", wantErr: true},
+		{stream: "@trade", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		symbol, streamType, err := ParseStreamName(tt.stream)
+
+		if tt.wantErr {
+			assert.ErrorIs(t, err, ErrInvalidStreamName, tt.stream)
+
+			continue
+		}
+
+		assert.NoError(t, err, tt.stream)
+		assert.Equal(t, tt.symbol, symbol)
+		assert.Equal(t, tt.streamType, streamType)
+	}
+}
+
+func TestDecoderForKline(t *testing.T) {
+	decode, err := DecoderFor("kline_1m")
+	assert.NoError(t, err)
+
+	data, err := decode([]byte(`{"e":"kline","s":"BTCUSDT",` +
+		`"k":{"i":"1m","o":"1.5","l":"1.1","L":42,"v":"10","V":"4","x":true}}`))
+	assert.NoError(t, err)
+
+	event, ok := data.(KlineEvent)
+	assert.True(t, ok)
+	assert.Equal(t, "1.5", event.Kline.Open)
+	assert.Equal(t, "1.1", event.Kline.Low)
+	assert.Equal(t, "10", event.Kline.Volume)
+	assert.Equal(t, "4", event.Kline.TakerBuyVolume)
+	assert.True(t, event.Kline.Closed)
+}
+
+func TestDecoderForUnsupportedStream(t *testing.T) {
+	_, err := DecoderFor("miniTicker")
+
+	assert.ErrorIs(t, err, ErrUnsupportedStream)
+}
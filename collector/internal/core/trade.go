@@ -0,0 +1,64 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// tradeEvent is the wire format shared by the trade and aggTrade streams.
+type tradeEvent struct {
+	EventType    string `json:"e"` // "trade" or "aggTrade"
+	EventTime    int64  `json:"E"`
+	Symbol       string `json:"s"`
+	TradeID      int64  `json:"t"` // trade only
+	AggTradeID   int64  `json:"a"` // aggTrade only
+	FirstTradeID int64  `json:"f"` // aggTrade only
+	LastTradeID  int64  `json:"l"` // aggTrade only
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	TradeTime    int64  `json:"T"`
+	BuyerMaker   bool   `json:"m"`
+	Ignore       bool   `json:"M"` // declared so "M" does not match "m" case-insensitively
+}
+
+// DecodeTrade decodes a trade or aggTrade stream payload into a Trade.
+func DecodeTrade(payload []byte) (Trade, error) {
+	var event tradeEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Trade{}, err
+	}
+
+	price, err := strconv.ParseFloat(event.Price, 64)
+	if err != nil {
+		return Trade{}, fmt.Errorf("invalid trade price %q: %w", event.Price, err)
+	}
+
+	quantity, err := strconv.ParseFloat(event.Quantity, 64)
+	if err != nil {
+		return Trade{}, fmt.Errorf("invalid trade quantity %q: %w", event.Quantity, err)
+	}
+
+	trade := Trade{
+		Symbol:       event.Symbol,
+		TradeID:      event.TradeID,
+		FirstTradeID: event.TradeID,
+		LastTradeID:  event.TradeID,
+		Price:        price,
+		Quantity:     quantity,
+		QuoteVolume:  price * quantity,
+		BuyerMaker:   event.BuyerMaker,
+		Aggregated:   event.EventType == StreamAggTrade,
+		TradeTime:    time.UnixMilli(event.TradeTime),
+		EventTime:    time.UnixMilli(event.EventTime),
+	}
+
+	if trade.Aggregated {
+		trade.TradeID = event.AggTradeID
+		trade.FirstTradeID = event.FirstTradeID
+		trade.LastTradeID = event.LastTradeID
+	}
+
+	return trade, nil
+}
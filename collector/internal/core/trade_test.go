@@ -0,0 +1,46 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTrade(t *testing.T) {
+	payload := []byte(`{"e":"trade","E":1700000000100,"s":"BTCUSDT","t":12345,"p":"42000.50","q":"0.5",` +
+		`"T":1700000000000,"m":true,"M":true}`)
+
+	trade, err := DecodeTrade(payload)
+	require.NoError(t, err)
+
+	assert.Equal(t, "BTCUSDT", trade.Symbol)
+	assert.Equal(t, int64(12345), trade.TradeID)
+	assert.InDelta(t, 42000.50, trade.Price, 1e-9)
+	assert.InDelta(t, 0.5, trade.Quantity, 1e-9)
+	assert.InDelta(t, 21000.25, trade.QuoteVolume, 1e-9)
+	assert.True(t, trade.BuyerMaker)
+	assert.False(t, trade.Aggregated)
+	assert.Equal(t, time.UnixMilli(1700000000000), trade.TradeTime)
+}
+
+func TestDecodeAggTrade(t *testing.T) {
+	payload := []byte(`{"e":"aggTrade","E":1700000000100,"s":"BTCUSDT","a":26129,"p":"0.01633102",` +
+		`"q":"4.70443515","f":27781,"l":27783,"T":1700000000000,"m":false,"M":true}`)
+
+	trade, err := DecodeTrade(payload)
+	require.NoError(t, err)
+
+	assert.True(t, trade.Aggregated)
+	assert.Equal(t, int64(26129), trade.TradeID)
+	assert.Equal(t, int64(27781), trade.FirstTradeID)
+	assert.Equal(t, int64(27783), trade.LastTradeID)
+	assert.False(t, trade.BuyerMaker)
+}
+
+func TestDecodeTradeInvalidPriceError(t *testing.T) {
+	_, err := DecodeTrade([]byte(`{"e":"trade","p":"invalid","q":"1"}`))
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package helpers
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+const backoffMultiplier = 2
+
+// Backoff computes jittered exponential delays between reconnect attempts.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// Delay returns the delay before the given zero-based attempt. The delay grows
+// exponentially up to Max and is randomized between half and the full value so
+// that many clients do not reconnect in lockstep.
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Initial
+
+	for range attempt {
+		delay *= backoffMultiplier
+		if delay >= b.Max {
+			delay = b.Max
+
+			break
+		}
+	}
+
+	half := delay / backoffMultiplier
+
+	return half + rand.N(half+1) //nolint:gosec
+}
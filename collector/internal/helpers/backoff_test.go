@@ -0,0 +1,20 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffDelayIsJitteredAndBounded(t *testing.T) {
+	backoff := Backoff{Initial: time.Second, Max: 8 * time.Second}
+
+	for attempt := range 10 {
+		delay := backoff.Delay(attempt)
+		expected := min(time.Second<<attempt, 8*time.Second)
+
+		assert.GreaterOrEqual(t, delay, expected/2)
+		assert.LessOrEqual(t, delay, expected)
+	}
+}
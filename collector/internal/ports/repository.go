@@ -7,3 +7,23 @@ import (
 type OrderBookRepository interface {
 	core.OrderBookRepository
 }
+
+type BookSnapshotRepository interface {
+	core.BookSnapshotRepository
+}
+
+type GapRepository interface {
+	core.GapRepository
+}
+
+type StreamEventRepository interface {
+	core.StreamEventRepository
+}
+
+type TradeRepository interface {
+	core.TradeRepository
+}
+
+type RetentionRepository interface {
+	core.RetentionRepository
+}
@@ -0,0 +1,7 @@
+package ports
+
+import "github.com/mkaganm/algo-trade/collector/internal/core"
+
+type DepthSnapshotProvider interface {
+	core.DepthSnapshotProvider
+}
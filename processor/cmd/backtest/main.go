@@ -0,0 +1,170 @@
+// Command backtest replays stored candles or book snapshots through a strategy
+// and writes the equity curve, trade list and metrics as JSON and CSV.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/application"
+	"github.com/mkaganm/algo-trade/processor/internal/config"
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+	"github.com/mkaganm/algo-trade/processor/internal/infrastructure/persistence"
+	"github.com/mkaganm/algo-trade/processor/internal/infrastructure/report"
+)
+
+const (
+	defaultPeriod   = 7 * 24 * time.Hour
+	defaultCapital  = 10000
+	defaultFee      = 0.001
+	defaultSlippage = 0.0005
+	loadTimeout     = 5 * time.Minute
+	percent         = 100
+)
+
+//nolint:funlen
+func main() {
+	cfg := config.Load()
+
+	defaultStrategy := ""
+	if len(cfg.Strategies) > 0 {
+		defaultStrategy = config.FormatStrategy(cfg.Strategies[0])
+	}
+
+	now := time.Now().UTC()
+
+	strategyFlag := flag.String("strategy", defaultStrategy, `strategy as "name:key=value,key=value"`)
+	symbol := flag.String("symbol", cfg.Symbols[0], "symbol to replay")
+	timeframeFlag := flag.String("timeframe", string(cfg.SignalTimeframes[0]), "bar timeframe")
+	fromFlag := flag.String("from", now.Add(-defaultPeriod).Format(time.RFC3339), "start time, RFC3339")
+	toFlag := flag.String("to", now.Format(time.RFC3339), "end time, RFC3339")
+	source := flag.String("source", application.HistoryCandles,
+		"history to replay: candles or depth (book snapshot mid prices)")
+	capital := flag.Float64("capital", defaultCapital, "initial capital")
+	fee := flag.Float64("fee", defaultFee, "fee per fill as a fraction of the notional")
+	slippage := flag.Float64("slippage", defaultSlippage, "slippage per fill as a fraction of the price")
+	allowShort := flag.Bool("short", false, "open short positions on SELL signals")
+	out := flag.String("out", "backtest", "output directory")
+	flag.Parse()
+
+	timeframe, err := domain.ParseTimeframe(*timeframeFlag)
+	if err != nil {
+		log.Fatalf("Invalid timeframe: %v", err)
+	}
+
+	from, err := time.Parse(time.RFC3339, *fromFlag)
+	if err != nil {
+		log.Fatalf("Invalid from time: %v", err)
+	}
+
+	to, err := time.Parse(time.RFC3339, *toFlag)
+	if err != nil {
+		log.Fatalf("Invalid to time: %v", err)
+	}
+
+	strategies := config.ParseStrategies(*strategyFlag)
+	if len(strategies) == 0 {
+		log.Fatal("No strategy to backtest")
+	}
+
+	strategy, err := application.DefaultStrategyRegistry().New(strategies[0].Name, strategies[0].Params)
+	if err != nil {
+		log.Fatalf("Failed to create strategy: %v", err)
+	}
+
+	mongoRepo, err := persistence.NewMongoOrderBookRepository(cfg.MongoURI, cfg.DatabaseName, cfg.CollectionName)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB repository: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+
+	featureRepo, err := persistence.NewMongoBookFeatureRepository(mongoRepo.Client(), cfg.DatabaseName, cfg.FeatureColName)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB book feature repository: %v", err)
+	}
+
+	snapshotRepo := persistence.NewMongoBookSnapshotRepository(mongoRepo.Client(), cfg.DatabaseName, cfg.SnapshotColName)
+
+	// Book features of the range are built and attached to the bars for strategies that use them
+	features := application.NewBookFeatureService(
+		snapshotRepo,
+		featureRepo,
+		cfg.FeatureDepth,
+		cfg.FeatureBackfill,
+	)
+
+	timeframes := []domain.Timeframe{timeframe}
+	if cfg.TrendFilter != nil {
+		timeframes = append(timeframes, cfg.TrendFilter.Timeframe)
+	}
+
+	candleRepo, err := persistence.NewMongoCandleRepository(
+		mongoRepo.Client(),
+		cfg.DatabaseName,
+		cfg.CandlesColPrefix,
+		timeframes,
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB candle repository: %v", err)
+	}
+
+	// The live trend filter gates the replayed signals as well
+	var gate ports.SignalGate
+
+	if cfg.TrendFilter != nil {
+		filter, err := application.DefaultStrategyRegistry().
+			New(cfg.TrendFilter.Strategy.Name, cfg.TrendFilter.Strategy.Params)
+		if err != nil {
+			log.Fatalf("Failed to create trend filter strategy: %v", err)
+		}
+
+		gate = application.NewTrendFilter(candleRepo, cfg.TrendFilter.Timeframe, filter)
+	}
+
+	candles, err := application.LoadHistory(
+		ctx,
+		candleRepo,
+		snapshotRepo,
+		features,
+		*source,
+		*symbol,
+		timeframe,
+		from,
+		to,
+	)
+	if err != nil {
+		log.Fatalf("Failed to load history: %v", err)
+	}
+
+	backtester := application.NewBacktester(strategy, *symbol, timeframe, application.BacktestConfig{
+		InitialCapital: *capital,
+		Fee:            *fee,
+		Slippage:       *slippage,
+		AllowShort:     *allowShort,
+		DedupWindow:    cfg.DedupWindow,
+		Gate:           gate,
+	})
+
+	result, err := backtester.Run(ctx, candles)
+	if err != nil {
+		log.Fatalf("Backtest failed: %v", err)
+	}
+
+	if err := report.WriteBacktest(*out, result); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	metrics := result.Metrics
+	log.Printf(
+		"%s on %s %s: return %.2f%%, sharpe %.2f, sortino %.2f, max drawdown %.2f%%, win rate %.2f%%, "+
+			"exposure %.2f%%, %d trades. Report written to %s",
+		result.StrategyID, result.Symbol, result.Timeframe,
+		metrics.TotalReturn*percent, metrics.Sharpe, metrics.Sortino, metrics.MaxDrawdown*percent,
+		metrics.WinRate*percent, metrics.Exposure*percent, metrics.Trades, *out,
+	)
+}
@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
 	"github.com/mkaganm/algo-trade/processor/internal/application"
 	"github.com/mkaganm/algo-trade/processor/internal/config"
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
 	"github.com/mkaganm/algo-trade/processor/internal/helpers"
 	"github.com/mkaganm/algo-trade/processor/internal/infrastructure/api"
 	"github.com/mkaganm/algo-trade/processor/internal/infrastructure/persistence"
@@ -20,6 +24,10 @@ import (
 const (
 	signalProcessingTimeout  = 30 * time.Second
 	mongoDBConnectionTimeout = 10 * time.Second
+	changeStreamRetryDelay   = 5 * time.Second
+	warmupMaxRetryDelay      = time.Minute
+	// changeStreamPrefix names the resume token of a change stream source
+	changeStreamPrefix = "signals_"
 )
 
 //nolint:funlen
@@ -38,35 +46,216 @@ func main() {
 	}
 
 	// Initialize Redis
-	redisPublisher := persistence.NewRedisSignalPublisher(cfg.RedisAddr, cfg.RedisStream)
+	redisPublisher := persistence.NewRedisSignalPublisher(
+		cfg.RedisAddr,
+		cfg.RedisStream,
+		persistence.FenceKey(cfg.LeaderKey),
+	)
+
+	candleRepo, err := persistence.NewMongoCandleRepository(
+		mongoRepo.Client(),
+		cfg.DatabaseName,
+		cfg.CandlesColPrefix,
+		cfg.Timeframes,
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB candle repository: %v", err)
+	}
+
+	tradeRepo := persistence.NewMongoTradeRepository(mongoRepo.Client(), cfg.DatabaseName, cfg.TradesColName)
+
+	featureRepo, err := persistence.NewMongoBookFeatureRepository(mongoRepo.Client(), cfg.DatabaseName, cfg.FeatureColName)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB book feature repository: %v", err)
+	}
 
 	// Initialize application services
-	signalProcessor := application.NewSignalProcessor(
-		mongoRepo,
-		mongoRepo, // Assuming MongoOrderBookRepository also implements SignalRepository
-		redisPublisher,
+	bookFeatures := application.NewBookFeatureService(
+		persistence.NewMongoBookSnapshotRepository(mongoRepo.Client(), cfg.DatabaseName, cfg.SnapshotColName),
+		featureRepo,
+		cfg.FeatureDepth,
+		cfg.FeatureBackfill,
+	)
+	candleBuilder := application.NewCandleBuilder(tradeRepo, candleRepo, cfg.Timeframes, cfg.CandleBackfill)
+
+	registry := application.DefaultStrategyRegistry()
+	groups := groupTargets(registry, cfg.SignalTargets)
+
+	// A higher-timeframe trend gates the entries of the lower timeframes
+	var gate ports.SignalGate
+
+	if cfg.TrendFilter != nil {
+		strategy, err := registry.New(cfg.TrendFilter.Strategy.Name, cfg.TrendFilter.Strategy.Params)
+		if err != nil {
+			log.Fatalf("Failed to create trend filter strategy: %v", err)
+		}
+
+		gate = application.NewTrendFilter(candleRepo, cfg.TrendFilter.Timeframe, strategy)
+	}
+
+	// Emitted signals are stored first and published to Redis from the outbox
+	outbox, err := persistence.NewMongoSignalOutbox(mongoRepo.Client(), cfg.DatabaseName, cfg.SignalsColName)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB signal outbox: %v", err)
+	}
+
+	relay := application.NewOutboxRelay(outbox, redisPublisher, cfg.OutboxInterval, cfg.OutboxBatchSize)
+
+	// Only crossovers are emitted, the steady state is kept in the status collection
+	statusRepo := persistence.NewMongoSignalStatusRepository(mongoRepo.Client(), cfg.DatabaseName, cfg.StatusColName)
+	emitter := application.NewSignalEmitter(
+		outbox,
+		statusRepo,
+		gate,
+		cfg.DedupWindow,
 	)
 
 	// Initialize scheduler
 	cronScheduler := scheduler.NewCronScheduler()
 
-	_, err = cronScheduler.Schedule("*/5 * * * *", func() {
+	_, err = cronScheduler.Schedule(cfg.CandleSchedule, func() {
 		ctx, cancel := context.WithTimeout(context.Background(), signalProcessingTimeout)
 		defer cancel()
 
-		signal, err := signalProcessor.GenerateSignal(ctx, cfg.ShortPeriod, cfg.LongPeriod)
-		if err != nil {
-			log.Printf("Failed to generate signal: %v", err)
-
-			return
+		for _, symbol := range cfg.Symbols {
+			if err := candleBuilder.Build(ctx, symbol, time.Now()); err != nil {
+				log.Printf("Failed to build %s candles: %v", symbol, err)
+			}
 		}
-
-		log.Printf("Generated signal: %s (shortSMA: %.2f, longSMA: %.2f)", signal.Signal, signal.ShortSMA, signal.LongSMA)
 	})
 	if err != nil {
-		log.Fatalf("Failed to schedule cron job: %v", err)
+		log.Fatalf("Failed to schedule candle job: %v", err)
+	}
+
+	newProcessor := func(group *targetGroup) *application.SignalProcessor {
+		return application.NewSignalProcessor(candleRepo, bookFeatures, emitter, group.strategies)
+	}
+
+	// Evaluate as new candles or depth updates land, the cron jobs stay the fallback
+	var trigger *application.ChangeTrigger
+
+	if cfg.SignalMode == config.SignalModeChangeStream {
+		var watcher ports.ChangeWatcher = persistence.NewMongoCandleWatcher(
+			mongoRepo.Client(),
+			cfg.DatabaseName,
+			cfg.CandlesColPrefix,
+			cfg.Timeframes,
+		)
+		if cfg.ChangeSource == config.ChangeSourceDepth {
+			watcher = persistence.NewMongoDepthWatcher(mongoRepo.Client(), cfg.DatabaseName, cfg.CollectionName)
+		}
+
+		trigger = application.NewChangeTrigger(
+			watcher,
+			persistence.NewMongoResumeTokenRepository(mongoRepo.Client(), cfg.DatabaseName, cfg.TokenColName),
+			changeStreamPrefix+cfg.ChangeSource,
+			application.ChangeTriggerConfig{
+				MinInterval: cfg.ChangeInterval,
+				Timeout:     signalProcessingTimeout,
+				RetryDelay:  changeStreamRetryDelay,
+			},
+		)
+
+		for _, group := range groups {
+			trigger.Add(group.symbol, group.timeframe, newProcessor(group))
+		}
+	}
+
+	// lead runs the signal jobs for one leadership term; ctx carries the
+	// fencing token and is cancelled when the term ends.
+	lead := func(ctx context.Context) {
+		// The previous leader kept the states up to date
+		loadCtx, loadCancel := context.WithTimeout(ctx, signalProcessingTimeout)
+		if err := emitter.Load(loadCtx); err != nil {
+			log.Printf("Starting without previous signal states: %v", err)
+		}
+
+		loadCancel()
+
+		signalScheduler := scheduler.NewCronScheduler()
+
+		var jobs sync.WaitGroup
+
+		jobs.Add(1)
+
+		go func() {
+			defer jobs.Done()
+
+			relay.Run(ctx)
+		}()
+
+		// Every symbol and timeframe is evaluated independently
+		for _, group := range groups {
+			switch cfg.SignalMode {
+			case config.SignalModeCron:
+				scheduleSignals(ctx, signalScheduler, group, newProcessor(group))
+			case config.SignalModeChangeStream:
+				// Evaluated by the change trigger below
+			default:
+				// Follow new trades and emit signals as soon as a bar closes
+				engine := application.NewSignalEngine(
+					tradeRepo,
+					candleRepo,
+					bookFeatures,
+					emitter,
+					group.strategies,
+					group.symbol,
+					group.timeframe,
+					application.EngineConfig{
+						PollInterval:  cfg.PollInterval,
+						CloseDelay:    cfg.CloseDelay,
+						RetryDelay:    changeStreamRetryDelay,
+						MaxRetryDelay: warmupMaxRetryDelay,
+					},
+				)
+
+				jobs.Add(1)
+
+				go func() {
+					defer jobs.Done()
+
+					engine.Run(ctx)
+				}()
+			}
+		}
+
+		if trigger != nil {
+			jobs.Add(1)
+
+			go func() {
+				defer jobs.Done()
+
+				err := trigger.Run(ctx)
+				if !errors.Is(err, domain.ErrChangeStreamUnsupported) {
+					return
+				}
+
+				log.Printf("Falling back to cron signal jobs: %v", err)
+
+				for _, group := range groups {
+					scheduleSignals(ctx, signalScheduler, group, newProcessor(group))
+				}
+			}()
+		}
+
+		signalScheduler.Start()
+
+		<-ctx.Done()
+
+		// Wait for running jobs so the next term does not overlap with this one
+		jobs.Wait()
+		<-signalScheduler.Stop().Done()
 	}
 
+	// Only the leader evaluates and publishes signals, standbys take over when its lease expires
+	elector := application.NewLeaderElector(
+		persistence.NewRedisLeaderLock(cfg.RedisAddr, cfg.LeaderKey),
+		cfg.LeaderID,
+		application.LeaderConfig{TTL: cfg.LeaderTTL, RenewInterval: cfg.LeaderRenew},
+	)
+
+	go elector.Run(context.Background(), lead)
+
 	cronScheduler.Start()
 
 	defer cronScheduler.Stop()
@@ -97,6 +286,7 @@ func main() {
 	// Setup health check handler
 	healthHandler := api.NewHealthHandler(mongoClient, redisClient)
 	app.Get("/healthcheck", healthHandler.Check)
+	app.Get("/signals/status", api.NewSignalStatusHandler(emitter).List)
 
 	// Start server
 	go startServer(app, cfg.ServerPort)
@@ -105,6 +295,67 @@ func main() {
 	select {}
 }
 
+// scheduleSignals evaluates the strategies of group every 5 minutes within ctx.
+func scheduleSignals(
+	ctx context.Context,
+	cronScheduler *scheduler.CronScheduler,
+	group *targetGroup,
+	service ports.SignalService,
+) {
+	_, err := cronScheduler.Schedule("*/5 * * * *", func() {
+		ctx, cancel := context.WithTimeout(ctx, signalProcessingTimeout)
+		defer cancel()
+
+		signals, err := service.GenerateSignals(ctx, group.symbol, group.timeframe)
+		if err != nil {
+			log.Printf("Failed to generate %s %s signals: %v", group.symbol, group.timeframe, err)
+		}
+
+		for _, signal := range signals {
+			log.Printf("Generated signal: %s on %s %s by %s %v",
+				signal.Signal, signal.Symbol, signal.Timeframe, signal.Strategy, signal.Indicators)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to schedule cron job: %v", err)
+	}
+}
+
+// targetGroup is the strategies evaluated on the bars of one symbol and timeframe.
+type targetGroup struct {
+	symbol     string
+	timeframe  domain.Timeframe
+	strategies []ports.Strategy
+}
+
+// groupTargets creates the strategies of targets, a separate instance for
+// every target, grouped by symbol and timeframe in configuration order.
+func groupTargets(registry *application.StrategyRegistry, targets []config.SignalTarget) []*targetGroup {
+	var groups []*targetGroup
+
+	index := make(map[string]*targetGroup)
+
+	for _, target := range targets {
+		strategy, err := registry.New(target.Strategy.Name, target.Strategy.Params)
+		if err != nil {
+			log.Fatalf("Failed to create strategy: %v", err)
+		}
+
+		key := target.Symbol + "/" + string(target.Timeframe)
+
+		group, ok := index[key]
+		if !ok {
+			group = &targetGroup{symbol: target.Symbol, timeframe: target.Timeframe}
+			index[key] = group
+			groups = append(groups, group)
+		}
+
+		group.strategies = append(group.strategies, strategy)
+	}
+
+	return groups
+}
+
 func startServer(app *fiber.App, serverPort string) {
 	defer helpers.RecoverRoutine(make(chan error))
 
@@ -0,0 +1,213 @@
+// Command optimize searches the parameters of a strategy on stored history,
+// validates the search walk-forward and exports the best set as processor config.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/application"
+	"github.com/mkaganm/algo-trade/processor/internal/config"
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+	"github.com/mkaganm/algo-trade/processor/internal/infrastructure/persistence"
+	"github.com/mkaganm/algo-trade/processor/internal/infrastructure/report"
+)
+
+const (
+	defaultPeriod        = 30 * 24 * time.Hour
+	defaultCapital       = 10000
+	defaultFee           = 0.001
+	defaultSlippage      = 0.0005
+	defaultInSampleRatio = 0.7
+	loadTimeout          = 5 * time.Minute
+	envFilePermissions   = 0o644
+	strategiesKey        = "STRATEGIES"
+)
+
+//nolint:funlen
+func main() {
+	cfg := config.Load()
+
+	now := time.Now().UTC()
+
+	strategyName := flag.String("strategy", application.SMACrossoverName, "strategy to optimize")
+	paramsFlag := flag.String("params", "short=10:100:10,long=50:300:25",
+		`parameter space as "name=start:end:step" ranges or "name=v1|v2" lists`)
+	objective := flag.String("objective", application.ObjectiveSharpe,
+		"objective to rank by: sharpe, sortino, return, winrate or drawdown")
+	samples := flag.Int("random", 0, "random search over this many parameter sets instead of the full grid")
+	seed := flag.Uint64("seed", 1, "random search seed")
+	folds := flag.Int("folds", 0, "walk-forward folds, 0 disables walk-forward validation")
+	inSampleRatio := flag.Float64("in-sample", defaultInSampleRatio, "in-sample share of every walk-forward fold")
+	minOutOfSample := flag.Float64("min-oos", 0, "average out-of-sample score the best set needs to be exported")
+	workers := flag.Int("workers", 0, "parallel backtests, all CPU cores when 0")
+	symbol := flag.String("symbol", cfg.Symbols[0], "symbol to replay")
+	timeframeFlag := flag.String("timeframe", string(cfg.SignalTimeframes[0]), "bar timeframe")
+	fromFlag := flag.String("from", now.Add(-defaultPeriod).Format(time.RFC3339), "start time, RFC3339")
+	toFlag := flag.String("to", now.Format(time.RFC3339), "end time, RFC3339")
+	source := flag.String("source", application.HistoryCandles,
+		"history to replay: candles or depth (book snapshot mid prices)")
+	capital := flag.Float64("capital", defaultCapital, "initial capital")
+	fee := flag.Float64("fee", defaultFee, "fee per fill as a fraction of the notional")
+	slippage := flag.Float64("slippage", defaultSlippage, "slippage per fill as a fraction of the price")
+	allowShort := flag.Bool("short", false, "open short positions on SELL signals")
+	out := flag.String("out", "optimization", "output directory")
+	envFile := flag.String("env", "", "processor .env file to write the best STRATEGIES to")
+	flag.Parse()
+
+	timeframe, err := domain.ParseTimeframe(*timeframeFlag)
+	if err != nil {
+		log.Fatalf("Invalid timeframe: %v", err)
+	}
+
+	from, err := time.Parse(time.RFC3339, *fromFlag)
+	if err != nil {
+		log.Fatalf("Invalid from time: %v", err)
+	}
+
+	to, err := time.Parse(time.RFC3339, *toFlag)
+	if err != nil {
+		log.Fatalf("Invalid to time: %v", err)
+	}
+
+	space, err := config.ParseParamSpace(*paramsFlag)
+	if err != nil {
+		log.Fatalf("Invalid parameter space: %v", err)
+	}
+
+	mongoRepo, err := persistence.NewMongoOrderBookRepository(cfg.MongoURI, cfg.DatabaseName, cfg.CollectionName)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB repository: %v", err)
+	}
+
+	featureRepo, err := persistence.NewMongoBookFeatureRepository(mongoRepo.Client(), cfg.DatabaseName, cfg.FeatureColName)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB book feature repository: %v", err)
+	}
+
+	snapshotRepo := persistence.NewMongoBookSnapshotRepository(mongoRepo.Client(), cfg.DatabaseName, cfg.SnapshotColName)
+
+	// Book features of the range are built and attached to the bars for strategies that use them
+	features := application.NewBookFeatureService(
+		snapshotRepo,
+		featureRepo,
+		cfg.FeatureDepth,
+		cfg.FeatureBackfill,
+	)
+
+	timeframes := []domain.Timeframe{timeframe}
+	if cfg.TrendFilter != nil {
+		timeframes = append(timeframes, cfg.TrendFilter.Timeframe)
+	}
+
+	candleRepo, err := persistence.NewMongoCandleRepository(
+		mongoRepo.Client(),
+		cfg.DatabaseName,
+		cfg.CandlesColPrefix,
+		timeframes,
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize MongoDB candle repository: %v", err)
+	}
+
+	// The live trend filter gates the replayed signals as well
+	var gate ports.SignalGate
+
+	if cfg.TrendFilter != nil {
+		filter, err := application.DefaultStrategyRegistry().
+			New(cfg.TrendFilter.Strategy.Name, cfg.TrendFilter.Strategy.Params)
+		if err != nil {
+			log.Fatalf("Failed to create trend filter strategy: %v", err)
+		}
+
+		gate = application.NewTrendFilter(candleRepo, cfg.TrendFilter.Timeframe, filter)
+	}
+
+	loadCtx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+
+	candles, err := application.LoadHistory(
+		loadCtx,
+		candleRepo,
+		snapshotRepo,
+		features,
+		*source,
+		*symbol,
+		timeframe,
+		from,
+		to,
+	)
+	if err != nil {
+		log.Fatalf("Failed to load history: %v", err)
+	}
+
+	optimizer := application.NewOptimizer(
+		application.DefaultStrategyRegistry(),
+		*strategyName,
+		*symbol,
+		timeframe,
+		application.OptimizerConfig{
+			Backtest: application.BacktestConfig{
+				InitialCapital: *capital,
+				Fee:            *fee,
+				Slippage:       *slippage,
+				AllowShort:     *allowShort,
+				DedupWindow:    cfg.DedupWindow,
+				Gate:           gate,
+			},
+			Objective:     *objective,
+			Workers:       *workers,
+			Samples:       *samples,
+			Seed:          *seed,
+			Folds:         *folds,
+			InSampleRatio: *inSampleRatio,
+		},
+	)
+
+	result, err := optimizer.Optimize(context.Background(), candles, space)
+	if err != nil {
+		log.Fatalf("Optimization failed: %v", err)
+	}
+
+	if err := report.WriteOptimization(*out, result); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	log.Printf("Best of %d parameter sets by %s in sample: %s (score %.4f)",
+		len(result.Runs), result.Objective, result.Runs[0].StrategyID, result.Runs[0].Score)
+
+	for i, fold := range result.Folds {
+		log.Printf("Fold %d: %s in sample %.4f, out of sample %.4f",
+			i+1, fold.InSample.StrategyID, fold.InSample.Score, fold.OutOfSampleScore)
+	}
+
+	if len(result.Folds) > 0 {
+		log.Printf("Average out-of-sample %s: %.4f", result.Objective, result.OutOfSampleScore)
+	}
+
+	// Only a walk-forward selection that held up out of sample is exported
+	if err := application.CheckExport(result, *minOutOfSample); err != nil {
+		log.Fatalf("Report written to %s, not exporting the parameters: %v", *out, err)
+	}
+
+	// The best set in the format of the processor configuration
+	strategies := config.FormatStrategy(config.StrategyConfig{Name: result.Strategy, Params: result.Best})
+	best := strategiesKey + "=" + strategies
+
+	if err := os.WriteFile(filepath.Join(*out, "best.env"), []byte(best+"\n"), envFilePermissions); err != nil {
+		log.Fatalf("Failed to write best parameters: %v", err)
+	}
+
+	if *envFile != "" {
+		if err := config.WriteEnvValue(*envFile, strategiesKey, strategies); err != nil {
+			log.Fatalf("Failed to update %s: %v", *envFile, err)
+		}
+	}
+
+	log.Printf("Report written to %s, best parameters: %s", *out, best)
+}
@@ -0,0 +1,374 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+const year = 365 * 24 * time.Hour
+
+// History sources a backtest can replay.
+const (
+	HistoryCandles = "candles"
+	HistoryDepth   = "depth"
+)
+
+var (
+	ErrNoCandles            = errors.New("no closed candles to backtest")
+	ErrUnknownHistorySource = errors.New("unknown history source")
+)
+
+// LoadHistory returns the bars of symbol in [from, to), either the stored
+// candles or mid-price bars built from the order book snapshots, which are
+// streamed rather than loaded at once. With features set the order book
+// features of the range are built from the snapshots and attached to the bars.
+func LoadHistory(
+	ctx context.Context,
+	candleRepo ports.CandleRepository,
+	snapshotRepo ports.BookSnapshotRepository,
+	features *BookFeatureService,
+	source string,
+	symbol string,
+	timeframe domain.Timeframe,
+	from, to time.Time,
+) ([]domain.Candle, error) {
+	var candles []domain.Candle
+
+	switch source {
+	case HistoryCandles:
+		stored, err := candleRepo.GetCandles(ctx, symbol, timeframe, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get candles: %w", err)
+		}
+
+		candles = stored
+	case HistoryDepth:
+		aggregator := &midPriceAggregator{symbol: symbol, timeframe: timeframe, now: to}
+
+		err := snapshotRepo.EachSnapshot(ctx, symbol, from, to, func(snapshot domain.BookSnapshot) error {
+			aggregator.add(snapshot)
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate book snapshots: %w", err)
+		}
+
+		candles = aggregator.candles
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownHistorySource, source)
+	}
+
+	if features != nil {
+		if err := features.BuildRange(ctx, symbol, from, to); err != nil {
+			return nil, err
+		}
+
+		if err := features.Enrich(ctx, symbol, candles); err != nil {
+			return nil, err
+		}
+	}
+
+	return candles, nil
+}
+
+// BacktestConfig controls the simulated fills. Fee is charged on the notional
+// of every fill and Slippage moves every fill price against the order, both
+// as fractions.
+type BacktestConfig struct {
+	InitialCapital float64
+	Fee            float64
+	Slippage       float64
+	// AllowShort makes SELL signals open short positions instead of only
+	// closing long ones.
+	AllowShort bool
+	// DedupWindow is the live dedup window of repeated signals.
+	DedupWindow time.Duration
+	// WarmupBars are the leading bars that only warm the strategy up, like
+	// the stored candles the signal engine starts from.
+	WarmupBars int
+	// Gate is the live signal gate, such as the trend filter, nil passes every
+	// signal.
+	Gate ports.SignalGate
+}
+
+// Backtester replays closed candles through a strategy the way the signal
+// engine does live: every bar updates the strategy and the signal emitter
+// decides which signals are actionable. Actionable signals are filled at the
+// open of the next bar with the whole equity.
+type Backtester struct {
+	strategy  ports.StreamingStrategy
+	symbol    string
+	timeframe domain.Timeframe
+	config    BacktestConfig
+
+	cash     float64
+	quantity float64
+	open     *domain.BacktestTrade
+	trades   []domain.BacktestTrade
+}
+
+// NewBacktester creates a backtester for a fresh strategy, as the strategy
+// keeps its state between bars.
+func NewBacktester(
+	strategy ports.Strategy,
+	symbol string,
+	timeframe domain.Timeframe,
+	config BacktestConfig,
+) *Backtester {
+	return &Backtester{
+		strategy:  AsStreaming(strategy),
+		symbol:    symbol,
+		timeframe: timeframe,
+		config:    config,
+	}
+}
+
+// Run replays candles, oldest first. Open bars are skipped and a position
+// still open at the end is closed at the last close.
+func (b *Backtester) Run(ctx context.Context, candles []domain.Candle) (domain.BacktestResult, error) {
+	series := closedSeries(candles)
+	if len(series) <= b.config.WarmupBars {
+		return domain.BacktestResult{}, ErrNoCandles
+	}
+
+	discard := discardSignals{}
+	emitter := NewSignalEmitter(discard, discard, b.config.Gate, b.config.DedupWindow)
+
+	for _, candle := range series[:b.config.WarmupBars] {
+		if signal, ok := b.strategy.Update(candle); ok {
+			tradeSignal := newTradeSignal(b.strategy, signal, b.symbol, b.timeframe, candle, candle.CloseTime)
+			emitter.Prime(b.symbol, tradeSignal)
+		}
+	}
+
+	series = series[b.config.WarmupBars:]
+
+	b.cash, b.quantity, b.open, b.trades = b.config.InitialCapital, 0, nil, nil
+
+	equity := make([]domain.EquityPoint, 0, len(series))
+	target, pending := 0.0, false
+
+	for _, candle := range series {
+		if pending {
+			b.fill(target, candle.Open, candle.OpenTime)
+
+			pending = false
+		}
+
+		if signal, ok := b.strategy.Update(candle); ok {
+			tradeSignal := newTradeSignal(b.strategy, signal, b.symbol, b.timeframe, candle, candle.CloseTime)
+			if emitter.Emit(ctx, b.symbol, tradeSignal) {
+				target, pending = b.targetPosition(tradeSignal.Signal), true
+			}
+		}
+
+		equity = append(equity, domain.EquityPoint{
+			Time:     candle.CloseTime,
+			Equity:   b.cash + b.quantity*candle.Close,
+			Position: b.quantity,
+		})
+	}
+
+	last := series[len(series)-1]
+	b.fill(0, last.Close, last.CloseTime)
+	equity[len(equity)-1].Equity = b.cash
+
+	return domain.BacktestResult{
+		Symbol:     b.symbol,
+		Timeframe:  b.timeframe,
+		StrategyID: domain.StrategyID(b.strategy.Name(), b.strategy.Params()),
+		From:       series[0].OpenTime,
+		To:         last.CloseTime,
+		Metrics:    backtestMetrics(b.config.InitialCapital, equity, b.trades, b.timeframe),
+		Trades:     b.trades,
+		Equity:     equity,
+	}, nil
+}
+
+// targetPosition returns the direction of the position a signal asks for.
+func (b *Backtester) targetPosition(action string) float64 {
+	switch {
+	case action == domain.Buy:
+		return 1
+	case action == domain.Sell && b.config.AllowShort:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// fill moves the position to the target direction at price.
+func (b *Backtester) fill(target, price float64, at time.Time) {
+	direction := math.Copysign(1, b.quantity)
+	if b.quantity == 0 {
+		direction = 0
+	}
+
+	if direction == target {
+		return
+	}
+
+	if b.open != nil {
+		// Closing trades in the opposite direction of the position
+		exitPrice := price * (1 - direction*b.config.Slippage)
+		fee := math.Abs(b.quantity*exitPrice) * b.config.Fee
+
+		b.cash += b.quantity*exitPrice - fee
+
+		trade := *b.open
+		trade.ExitTime = at
+		trade.ExitPrice = exitPrice
+		trade.Fees += fee
+		trade.PnL = (exitPrice-trade.EntryPrice)*b.quantity - trade.Fees
+		trade.Return = trade.PnL / (trade.EntryPrice * trade.Quantity)
+
+		b.trades = append(b.trades, trade)
+		b.quantity, b.open = 0, nil
+	}
+
+	if target == 0 || b.cash <= 0 {
+		return
+	}
+
+	entryPrice := price * (1 + target*b.config.Slippage)
+	quantity := b.cash / (entryPrice * (1 + b.config.Fee))
+	fee := quantity * entryPrice * b.config.Fee
+
+	b.quantity = target * quantity
+	b.cash -= b.quantity*entryPrice + fee
+
+	side := domain.Long
+	if target < 0 {
+		side = domain.Short
+	}
+
+	b.open = &domain.BacktestTrade{
+		Side:       side,
+		EntryTime:  at,
+		EntryPrice: entryPrice,
+		Quantity:   quantity,
+		Fees:       fee,
+	}
+}
+
+// backtestMetrics computes the summary of an equity curve and its trades.
+func backtestMetrics(
+	initialCapital float64,
+	equity []domain.EquityPoint,
+	trades []domain.BacktestTrade,
+	timeframe domain.Timeframe,
+) domain.BacktestMetrics {
+	metrics := domain.BacktestMetrics{
+		InitialCapital: initialCapital,
+		FinalEquity:    initialCapital,
+		Trades:         len(trades),
+	}
+
+	if len(equity) == 0 {
+		return metrics
+	}
+
+	metrics.FinalEquity = equity[len(equity)-1].Equity
+	if initialCapital != 0 {
+		metrics.TotalReturn = metrics.FinalEquity/initialCapital - 1
+	}
+
+	returns := make([]float64, 0, len(equity))
+	previous, peak := initialCapital, initialCapital
+	exposed := 0
+
+	for _, point := range equity {
+		if previous != 0 {
+			returns = append(returns, point.Equity/previous-1)
+		}
+
+		previous = point.Equity
+		peak = max(peak, point.Equity)
+
+		if peak > 0 {
+			metrics.MaxDrawdown = max(metrics.MaxDrawdown, (peak-point.Equity)/peak)
+		}
+
+		if point.Position != 0 {
+			exposed++
+		}
+	}
+
+	metrics.Exposure = float64(exposed) / float64(len(equity))
+
+	periodsPerYear := float64(year) / float64(timeframe.Duration())
+	metrics.Sharpe, metrics.Sortino = riskAdjustedReturns(returns, periodsPerYear)
+
+	wins := 0
+
+	for _, trade := range trades {
+		if trade.PnL > 0 {
+			wins++
+		}
+	}
+
+	if len(trades) > 0 {
+		metrics.WinRate = float64(wins) / float64(len(trades))
+	}
+
+	return metrics
+}
+
+// riskAdjustedReturns returns the annualized Sharpe and Sortino ratios of bar
+// returns with a zero risk-free rate. A ratio without deviation is 0.
+func riskAdjustedReturns(returns []float64, periodsPerYear float64) (float64, float64) {
+	if len(returns) == 0 {
+		return 0, 0
+	}
+
+	var mean float64
+	for _, value := range returns {
+		mean += value
+	}
+
+	mean /= float64(len(returns))
+
+	var variance, downside float64
+
+	for _, value := range returns {
+		variance += (value - mean) * (value - mean)
+		downside += min(value, 0) * min(value, 0)
+	}
+
+	deviation := math.Sqrt(variance / float64(len(returns)))
+	downsideDeviation := math.Sqrt(downside / float64(len(returns)))
+	annualization := math.Sqrt(periodsPerYear)
+
+	var sharpe, sortino float64
+	if deviation > 0 {
+		sharpe = mean / deviation * annualization
+	}
+
+	if downsideDeviation > 0 {
+		sortino = mean / downsideDeviation * annualization
+	}
+
+	return sharpe, sortino
+}
+
+// discardSignals stands in for the signal and status stores while backtesting.
+type discardSignals struct{}
+
+func (discardSignals) SaveSignal(_ context.Context, _ domain.TradeSignal) error {
+	return nil
+}
+
+func (discardSignals) SaveStatus(_ context.Context, _ domain.SignalStatus) error {
+	return nil
+}
+
+func (discardSignals) GetStatuses(_ context.Context) ([]domain.SignalStatus, error) {
+	return nil, nil
+}
@@ -0,0 +1,121 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backtestCandles builds closed 1m bars from [open, close] pairs.
+func backtestCandles(prices ...[2]float64) []domain.Candle {
+	candles := make([]domain.Candle, len(prices))
+	for i, price := range prices {
+		openTime := baseTime.Add(time.Duration(i) * time.Minute)
+		candles[i] = domain.Candle{
+			Timeframe: "1m",
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(time.Minute),
+			Open:      price[0],
+			Close:     price[1],
+			Closed:    true,
+		}
+	}
+
+	return candles
+}
+
+func TestBacktesterFillsCrossoversAtNextOpen(t *testing.T) {
+	// SELL is primed, BUY at bar 3 fills at 12, SELL at bar 5 exits at 11.
+	candles := backtestCandles([2]float64{10, 10}, [2]float64{10, 9}, [2]float64{9, 8},
+		[2]float64{8, 12}, [2]float64{12, 14}, [2]float64{14, 10}, [2]float64{11, 11})
+
+	strategy, err := NewSMACrossover(1, 2)
+	require.NoError(t, err)
+
+	result, err := NewBacktester(strategy, "BTCUSDT", "1m", BacktestConfig{InitialCapital: 1200}).
+		Run(context.Background(), candles)
+	require.NoError(t, err)
+
+	require.Len(t, result.Trades, 1)
+
+	trade := result.Trades[0]
+	assert.Equal(t, domain.Long, trade.Side)
+	assert.Equal(t, baseTime.Add(4*time.Minute), trade.EntryTime)
+	assert.InDelta(t, 12.0, trade.EntryPrice, 0)
+	assert.InDelta(t, 11.0, trade.ExitPrice, 0)
+	assert.InDelta(t, 100.0, trade.Quantity, 1e-9)
+	assert.InDelta(t, -100.0, trade.PnL, 1e-9)
+	assert.InDelta(t, -1.0/12, trade.Return, 1e-9)
+
+	equity := make([]float64, len(result.Equity))
+	for i, point := range result.Equity {
+		equity[i] = point.Equity
+	}
+
+	assert.InDeltaSlice(t, []float64{1200, 1200, 1200, 1200, 1400, 1000, 1100}, equity, 1e-9)
+
+	metrics := result.Metrics
+	assert.InDelta(t, 1100.0, metrics.FinalEquity, 1e-9)
+	assert.InDelta(t, -100.0/1200, metrics.TotalReturn, 1e-9)
+	assert.InDelta(t, 400.0/1400, metrics.MaxDrawdown, 1e-9)
+	assert.InDelta(t, 2.0/7, metrics.Exposure, 1e-9)
+	assert.InDelta(t, 0.0, metrics.WinRate, 0)
+	assert.Equal(t, 1, metrics.Trades)
+	assert.Less(t, metrics.Sharpe, 0.0)
+	assert.Less(t, metrics.Sortino, metrics.Sharpe)
+}
+
+func TestBacktesterAppliesTheSignalGate(t *testing.T) {
+	candles := backtestCandles([2]float64{10, 10}, [2]float64{10, 9}, [2]float64{9, 8},
+		[2]float64{8, 12}, [2]float64{12, 14}, [2]float64{14, 10}, [2]float64{11, 11})
+
+	strategy, err := NewSMACrossover(1, 2)
+	require.NoError(t, err)
+
+	// The higher timeframe trends down, so the BUY crossover is never filled
+	config := BacktestConfig{InitialCapital: 1200, Gate: newTestTrendFilter(t, 4, 3, 2, 1)}
+
+	result, err := NewBacktester(strategy, "BTCUSDT", "1m", config).Run(context.Background(), candles)
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Trades)
+	assert.InDelta(t, 1200.0, result.Metrics.FinalEquity, 1e-9)
+}
+
+func TestBacktesterChargesFeesAndSlippage(t *testing.T) {
+	candles := backtestCandles([2]float64{10, 10}, [2]float64{10, 9}, [2]float64{9, 8},
+		[2]float64{8, 12}, [2]float64{12, 14}, [2]float64{14, 10}, [2]float64{11, 11})
+
+	strategy, err := NewSMACrossover(1, 2)
+	require.NoError(t, err)
+
+	config := BacktestConfig{InitialCapital: 1200, Fee: 0.001, Slippage: 0.002, AllowShort: true}
+
+	result, err := NewBacktester(strategy, "BTCUSDT", "1m", config).Run(context.Background(), candles)
+	require.NoError(t, err)
+
+	// The SELL reverses the long into a short that is closed at the end.
+	require.Len(t, result.Trades, 2)
+	assert.Equal(t, domain.Long, result.Trades[0].Side)
+	assert.Equal(t, domain.Short, result.Trades[1].Side)
+	assert.InDelta(t, 12*1.002, result.Trades[0].EntryPrice, 1e-9)
+	assert.InDelta(t, 11*0.998, result.Trades[0].ExitPrice, 1e-9)
+	assert.InDelta(t, 11*0.998, result.Trades[1].EntryPrice, 1e-9)
+	assert.InDelta(t, 11*1.002, result.Trades[1].ExitPrice, 1e-9)
+
+	pnl := 0.0
+	for _, trade := range result.Trades {
+		assert.Positive(t, trade.Fees)
+
+		pnl += trade.PnL
+	}
+
+	assert.InDelta(t, 1200+pnl, result.Metrics.FinalEquity, 1e-9)
+
+	_, err = NewBacktester(strategy, "BTCUSDT", "1m", config).Run(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrNoCandles)
+}
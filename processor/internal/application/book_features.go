@@ -0,0 +1,282 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+// featureSaveBatch is the number of features saved per write while building.
+const featureSaveBatch = 1000
+
+// BookFeatureService turns the order book snapshots of the collector into a
+// stored time series of microstructure features and attaches them to candles
+// so strategies can use them.
+type BookFeatureService struct {
+	snapshotRepo ports.BookSnapshotRepository
+	featureRepo  ports.BookFeatureRepository
+	depth        int
+	backfill     time.Duration
+}
+
+func NewBookFeatureService(
+	snapshotRepo ports.BookSnapshotRepository,
+	featureRepo ports.BookFeatureRepository,
+	depth int,
+	backfill time.Duration,
+) *BookFeatureService {
+	return &BookFeatureService{
+		snapshotRepo: snapshotRepo,
+		featureRepo:  featureRepo,
+		depth:        depth,
+		backfill:     backfill,
+	}
+}
+
+// Build computes and stores the features of the snapshots taken since the
+// last stored feature, or within the backfill window, up to now.
+func (s *BookFeatureService) Build(ctx context.Context, symbol string, now time.Time) error {
+	from := now.Add(-s.backfill)
+
+	latest, found, err := s.featureRepo.LatestFeatureTime(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get latest book feature: %w", err)
+	}
+
+	if !found {
+		return s.build(ctx, symbol, from, now, time.Time{})
+	}
+
+	// The snapshot of the latest feature only seeds the order flow imbalance
+	return s.build(ctx, symbol, latest, now, latest)
+}
+
+// BuildRange computes and stores the features of the snapshots taken in
+// [from, to), replacing the stored ones, so that a backtest of a range the
+// live engine never covered has its features too.
+func (s *BookFeatureService) BuildRange(ctx context.Context, symbol string, from, to time.Time) error {
+	return s.build(ctx, symbol, from, to, time.Time{})
+}
+
+// build streams the snapshots in [from, to) and saves their features in
+// batches, skipping the ones taken at or before seed when it is set.
+func (s *BookFeatureService) build(ctx context.Context, symbol string, from, to, seed time.Time) error {
+	features := make([]domain.BookFeatures, 0, featureSaveBatch)
+
+	save := func() error {
+		if len(features) == 0 {
+			return nil
+		}
+
+		if err := s.featureRepo.SaveFeatures(ctx, features); err != nil {
+			return fmt.Errorf("failed to save book features: %w", err)
+		}
+
+		features = features[:0]
+
+		return nil
+	}
+
+	var previous *domain.BookSnapshot
+
+	err := s.snapshotRepo.EachSnapshot(ctx, symbol, from, to, func(snapshot domain.BookSnapshot) error {
+		feature, ok := ComputeBookFeatures(snapshot, previous, s.depth)
+		previous = &snapshot
+
+		if !ok || (!seed.IsZero() && !snapshot.Timestamp.After(seed)) {
+			return nil
+		}
+
+		features = append(features, feature)
+		if len(features) < featureSaveBatch {
+			return nil
+		}
+
+		return save()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get book snapshots: %w", err)
+	}
+
+	return save()
+}
+
+// Refresh builds the features up to the close of the last candle and
+// attaches them to candles.
+func (s *BookFeatureService) Refresh(ctx context.Context, symbol string, candles []domain.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	if err := s.Build(ctx, symbol, candles[len(candles)-1].CloseTime); err != nil {
+		return err
+	}
+
+	return s.Enrich(ctx, symbol, candles)
+}
+
+// Enrich attaches the aggregated features stored within every candle to its
+// Features. Candles must be ordered, candles without features are left as is.
+func (s *BookFeatureService) Enrich(ctx context.Context, symbol string, candles []domain.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	features, err := s.featureRepo.GetFeatures(ctx, symbol, candles[0].OpenTime, candles[len(candles)-1].CloseTime)
+	if err != nil {
+		return fmt.Errorf("failed to get book features: %w", err)
+	}
+
+	next := 0
+
+	for i := range candles {
+		for next < len(features) && features[next].Timestamp.Before(candles[i].OpenTime) {
+			next++
+		}
+
+		first := next
+		for next < len(features) && features[next].Timestamp.Before(candles[i].CloseTime) {
+			next++
+		}
+
+		if next > first {
+			candles[i].Features = AggregateBookFeatures(features[first:next])
+		}
+	}
+
+	return nil
+}
+
+// ComputeBookFeatures computes the features of snapshot over its top depth
+// levels. previous, if any, is the snapshot before it for the order flow
+// imbalance. ok is false without a valid touch on both sides.
+func ComputeBookFeatures(
+	snapshot domain.BookSnapshot,
+	previous *domain.BookSnapshot,
+	depth int,
+) (domain.BookFeatures, bool) {
+	if !validTouch(snapshot) {
+		return domain.BookFeatures{}, false
+	}
+
+	bid, ask := snapshot.Bids[0], snapshot.Asks[0]
+
+	features := domain.BookFeatures{
+		Symbol:    snapshot.Symbol,
+		Timestamp: snapshot.Timestamp,
+		MidPrice:  (bid.Price + ask.Price) / midpointDivisor,
+		Spread:    ask.Price - bid.Price,
+	}
+
+	// The microprice leans towards the side with less liquidity, where the
+	// price is more likely to move.
+	features.Microprice = features.MidPrice
+	if touch := bid.Quantity + ask.Quantity; touch > 0 {
+		features.Microprice = (bid.Price*ask.Quantity + ask.Price*bid.Quantity) / touch
+	}
+
+	var bidDepth, askDepth, bidWeighted, askWeighted float64
+
+	for i, level := range topLevels(snapshot.Bids, depth) {
+		bidDepth += level.Quantity
+		bidWeighted += level.Quantity / float64(i+1)
+	}
+
+	for i, level := range topLevels(snapshot.Asks, depth) {
+		askDepth += level.Quantity
+		askWeighted += level.Quantity / float64(i+1)
+	}
+
+	features.Imbalance = normalizedDifference(bidDepth, askDepth)
+	// Book pressure weights level i by 1/i, so liquidity near the touch counts most
+	features.Pressure = normalizedDifference(bidWeighted, askWeighted)
+
+	if previous != nil && validTouch(*previous) {
+		features.OFI = orderFlowImbalance(*previous, snapshot)
+	}
+
+	return features, true
+}
+
+// orderFlowImbalance is the change of the best bid and ask queues between two
+// snapshots (Cont, Kukanov and Stoikov): bid queue growth and ask queue
+// depletion push it up, the opposite pushes it down.
+func orderFlowImbalance(previous, current domain.BookSnapshot) float64 {
+	prevBid, bid := previous.Bids[0], current.Bids[0]
+	prevAsk, ask := previous.Asks[0], current.Asks[0]
+
+	var ofi float64
+
+	if bid.Price >= prevBid.Price {
+		ofi += bid.Quantity
+	}
+
+	if bid.Price <= prevBid.Price {
+		ofi -= prevBid.Quantity
+	}
+
+	if ask.Price <= prevAsk.Price {
+		ofi -= ask.Quantity
+	}
+
+	if ask.Price >= prevAsk.Price {
+		ofi += prevAsk.Quantity
+	}
+
+	return ofi
+}
+
+// AggregateBookFeatures summarizes the features within a bar: the last mid
+// and microprice, the mean spread, imbalance and pressure and the summed
+// order flow imbalance.
+func AggregateBookFeatures(features []domain.BookFeatures) map[string]float64 {
+	if len(features) == 0 {
+		return nil
+	}
+
+	last := features[len(features)-1]
+
+	var spread, imbalance, pressure, ofi float64
+
+	for _, feature := range features {
+		spread += feature.Spread
+		imbalance += feature.Imbalance
+		pressure += feature.Pressure
+		ofi += feature.OFI
+	}
+
+	count := float64(len(features))
+
+	return map[string]float64{
+		domain.FeatureMidPrice:   last.MidPrice,
+		domain.FeatureMicroprice: last.Microprice,
+		domain.FeatureSpread:     spread / count,
+		domain.FeatureImbalance:  imbalance / count,
+		domain.FeaturePressure:   pressure / count,
+		domain.FeatureOFI:        ofi,
+	}
+}
+
+func validTouch(snapshot domain.BookSnapshot) bool {
+	return len(snapshot.Bids) > 0 && len(snapshot.Asks) > 0 && snapshot.Bids[0].Price < snapshot.Asks[0].Price
+}
+
+func topLevels(levels []domain.PriceLevel, depth int) []domain.PriceLevel {
+	if depth > 0 && len(levels) > depth {
+		return levels[:depth]
+	}
+
+	return levels
+}
+
+// normalizedDifference maps (a - b) / (a + b) to [-1, 1], 0 without liquidity.
+func normalizedDifference(a, b float64) float64 {
+	if a+b == 0 {
+		return 0
+	}
+
+	return (a - b) / (a + b)
+}
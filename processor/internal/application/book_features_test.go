@@ -0,0 +1,200 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSnapshotRepository struct {
+	snapshots []domain.BookSnapshot
+}
+
+func (r *stubSnapshotRepository) GetSnapshots(
+	_ context.Context,
+	_ string,
+	from, to time.Time,
+) ([]domain.BookSnapshot, error) {
+	var snapshots []domain.BookSnapshot
+
+	for _, snapshot := range r.snapshots {
+		if !snapshot.Timestamp.Before(from) && snapshot.Timestamp.Before(to) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return snapshots, nil
+}
+
+func (r *stubSnapshotRepository) EachSnapshot(
+	ctx context.Context,
+	symbol string,
+	from, to time.Time,
+	fn func(snapshot domain.BookSnapshot) error,
+) error {
+	snapshots, _ := r.GetSnapshots(ctx, symbol, from, to)
+
+	for _, snapshot := range snapshots {
+		if err := fn(snapshot); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type stubFeatureRepository struct {
+	features []domain.BookFeatures
+}
+
+func (r *stubFeatureRepository) SaveFeatures(_ context.Context, features []domain.BookFeatures) error {
+	for _, feature := range features {
+		index := slices.IndexFunc(r.features, func(stored domain.BookFeatures) bool {
+			return stored.Timestamp.Equal(feature.Timestamp)
+		})
+		if index < 0 {
+			r.features = append(r.features, feature)
+
+			continue
+		}
+
+		r.features[index] = feature
+	}
+
+	return nil
+}
+
+func (r *stubFeatureRepository) GetFeatures(
+	_ context.Context,
+	_ string,
+	from, to time.Time,
+) ([]domain.BookFeatures, error) {
+	var features []domain.BookFeatures
+
+	for _, feature := range r.features {
+		if !feature.Timestamp.Before(from) && feature.Timestamp.Before(to) {
+			features = append(features, feature)
+		}
+	}
+
+	return features, nil
+}
+
+func (r *stubFeatureRepository) LatestFeatureTime(_ context.Context, _ string) (time.Time, bool, error) {
+	if len(r.features) == 0 {
+		return time.Time{}, false, nil
+	}
+
+	return r.features[len(r.features)-1].Timestamp, true, nil
+}
+
+func bookSnapshot(at time.Time, bids, asks []domain.PriceLevel) domain.BookSnapshot {
+	return domain.BookSnapshot{Symbol: "BTCUSDT", Bids: bids, Asks: asks, Timestamp: at}
+}
+
+func TestComputeBookFeatures(t *testing.T) {
+	previous := bookSnapshot(baseTime,
+		[]domain.PriceLevel{{Price: 99, Quantity: 2}},
+		[]domain.PriceLevel{{Price: 101, Quantity: 3}},
+	)
+	snapshot := bookSnapshot(baseTime.Add(time.Second),
+		[]domain.PriceLevel{{Price: 100, Quantity: 3}, {Price: 99, Quantity: 4}, {Price: 98, Quantity: 100}},
+		[]domain.PriceLevel{{Price: 101, Quantity: 1}, {Price: 102, Quantity: 2}, {Price: 103, Quantity: 100}},
+	)
+
+	features, ok := ComputeBookFeatures(snapshot, &previous, 2)
+	require.True(t, ok)
+
+	assert.InDelta(t, 100.5, features.MidPrice, 1e-12)
+	assert.InDelta(t, 1.0, features.Spread, 1e-12)
+	// 3 bid against 1 ask at the touch moves the microprice towards the ask.
+	assert.InDelta(t, (100*1+101*3)/4.0, features.Microprice, 1e-12)
+	// Only the top 2 levels count: 7 bid against 3 ask.
+	assert.InDelta(t, 0.4, features.Imbalance, 1e-12)
+	assert.InDelta(t, (5.0-2)/(5+2), features.Pressure, 1e-12)
+	// The bid improved (+3) and the ask queue at an unchanged price shrank (-1 + 3).
+	assert.InDelta(t, 5.0, features.OFI, 1e-12)
+
+	_, ok = ComputeBookFeatures(bookSnapshot(baseTime, nil, snapshot.Asks), nil, 2)
+	assert.False(t, ok)
+}
+
+func TestBookFeatureServiceBuildsAndEnriches(t *testing.T) {
+	level := func(price, quantity float64) []domain.PriceLevel {
+		return []domain.PriceLevel{{Price: price, Quantity: quantity}}
+	}
+
+	snapshots := &stubSnapshotRepository{snapshots: []domain.BookSnapshot{
+		bookSnapshot(baseTime.Add(10*time.Second), level(100, 3), level(101, 1)),
+		bookSnapshot(baseTime.Add(30*time.Second), level(100, 1), level(101, 1)),
+		bookSnapshot(baseTime.Add(70*time.Second), level(102, 1), level(103, 3)),
+	}}
+	features := &stubFeatureRepository{}
+	service := NewBookFeatureService(snapshots, features, 10, time.Hour)
+
+	require.NoError(t, service.Build(context.Background(), "BTCUSDT", baseTime.Add(time.Minute)))
+	require.Len(t, features.features, 2)
+
+	// Resuming reuses the latest snapshot for the order flow imbalance only.
+	require.NoError(t, service.Build(context.Background(), "BTCUSDT", baseTime.Add(2*time.Minute)))
+	require.Len(t, features.features, 3)
+	// Both sides moved up: the new bid queue adds, the old ask queue was taken.
+	assert.InDelta(t, 2.0, features.features[2].OFI, 1e-12)
+
+	candles := backtestCandles([2]float64{100, 100}, [2]float64{100, 102}, [2]float64{102, 102})
+	require.NoError(t, service.Enrich(context.Background(), "BTCUSDT", candles))
+
+	assert.InDelta(t, 100.5, candles[0].Features[domain.FeatureMidPrice], 1e-12)
+	assert.InDelta(t, (0.5+0)/2, candles[0].Features[domain.FeatureImbalance], 1e-12)
+	assert.InDelta(t, -0.5, candles[1].Features[domain.FeatureImbalance], 1e-12)
+	assert.Nil(t, candles[2].Features)
+
+	strategy, err := NewBookImbalance(0.3)
+	require.NoError(t, err)
+
+	signal, err := strategy.Evaluate(candles[:1])
+	require.NoError(t, err)
+	assert.Equal(t, domain.Neutral, signal.Action)
+
+	signal, err = strategy.Evaluate(candles[:2])
+	require.NoError(t, err)
+	assert.Equal(t, domain.Sell, signal.Action)
+	assert.InDelta(t, 0.5, signal.Strength, 1e-12)
+
+	_, err = strategy.Evaluate(candles)
+	assert.ErrorIs(t, err, ErrMissingFeatures)
+}
+
+func TestLoadHistoryBuildsBookFeaturesOfTheRange(t *testing.T) {
+	level := func(price, quantity float64) []domain.PriceLevel {
+		return []domain.PriceLevel{{Price: price, Quantity: quantity}}
+	}
+
+	snapshots := &stubSnapshotRepository{snapshots: []domain.BookSnapshot{
+		bookSnapshot(baseTime.Add(10*time.Second), level(100, 3), level(101, 1)),
+		bookSnapshot(baseTime.Add(70*time.Second), level(102, 1), level(103, 3)),
+	}}
+	features := &stubFeatureRepository{}
+	service := NewBookFeatureService(snapshots, features, 10, time.Hour)
+
+	// No feature was stored for the range before, the backtest builds them
+	candles, err := LoadHistory(context.Background(), nil, snapshots, service,
+		HistoryDepth, "BTCUSDT", "1m", baseTime, baseTime.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, candles, 2)
+	require.Len(t, features.features, 2)
+
+	assert.InDelta(t, 0.5, candles[0].Features[domain.FeatureImbalance], 1e-12)
+	assert.InDelta(t, -0.5, candles[1].Features[domain.FeatureImbalance], 1e-12)
+
+	// Loading the range again replaces the features instead of adding to them
+	_, err = LoadHistory(context.Background(), nil, snapshots, service,
+		HistoryDepth, "BTCUSDT", "1m", baseTime, baseTime.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, features.features, 2)
+}
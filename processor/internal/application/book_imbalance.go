@@ -0,0 +1,80 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+const BookImbalanceName = "book_imbalance"
+
+var ErrMissingFeatures = errors.New("candle has no order book features")
+
+// BookImbalance buys while the top of book depth leans to the bids by more
+// than the threshold and sells while it leans to the asks.
+type BookImbalance struct {
+	threshold float64
+}
+
+func NewBookImbalance(threshold float64) (*BookImbalance, error) {
+	if threshold <= 0 || threshold >= 1 {
+		return nil, fmt.Errorf("%w: threshold %g", ErrInvalidStrategyParams, threshold)
+	}
+
+	return &BookImbalance{threshold: threshold}, nil
+}
+
+// NewBookImbalanceFromParams reads the "threshold" parameter.
+func NewBookImbalanceFromParams(params map[string]float64) (ports.Strategy, error) {
+	return NewBookImbalance(params["threshold"])
+}
+
+func (s *BookImbalance) Name() string {
+	return BookImbalanceName
+}
+
+func (s *BookImbalance) Params() map[string]float64 {
+	return map[string]float64{"threshold": s.threshold}
+}
+
+func (s *BookImbalance) RequiredHistory() int {
+	return 1
+}
+
+// Evaluate decides on the book features of the latest bar.
+func (s *BookImbalance) Evaluate(series domain.Series) (domain.Signal, error) {
+	if len(series) == 0 {
+		return domain.Signal{}, ErrNotEnoughDataPoints
+	}
+
+	features := series[len(series)-1].Features
+	if features == nil {
+		return domain.Signal{}, ErrMissingFeatures
+	}
+
+	imbalance := features[domain.FeatureImbalance]
+
+	signal := domain.Signal{
+		Action:   domain.Neutral,
+		Strength: min(math.Abs(imbalance), 1),
+		Indicators: map[string]float64{
+			domain.FeatureImbalance:  imbalance,
+			domain.FeatureOFI:        features[domain.FeatureOFI],
+			domain.FeatureMicroprice: features[domain.FeatureMicroprice],
+		},
+	}
+
+	switch {
+	case imbalance > s.threshold:
+		signal.Action = domain.Buy
+	case imbalance < -s.threshold:
+		signal.Action = domain.Sell
+	default:
+		signal.Strength = 0
+	}
+
+	return signal, nil
+}
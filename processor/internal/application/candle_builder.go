@@ -0,0 +1,262 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+const (
+	// candleSaveBatch is the number of completed base bars saved at once while
+	// the trades are streamed.
+	candleSaveBatch = 500
+	midpointDivisor = 2
+)
+
+// CandleBuilder aggregates stored trades into OHLCV bars. The smallest
+// configured timeframe is built from trades streamed from the repository,
+// larger ones are rolled up from it.
+type CandleBuilder struct {
+	tradeRepo  ports.TradeRepository
+	candleRepo ports.CandleRepository
+	timeframes []domain.Timeframe
+	backfill   time.Duration
+}
+
+func NewCandleBuilder(
+	tradeRepo ports.TradeRepository,
+	candleRepo ports.CandleRepository,
+	timeframes []domain.Timeframe,
+	backfill time.Duration,
+) *CandleBuilder {
+	sorted := slices.Clone(timeframes)
+	slices.SortFunc(sorted, func(a, b domain.Timeframe) int {
+		return int(a.Duration() - b.Duration())
+	})
+
+	return &CandleBuilder{
+		tradeRepo:  tradeRepo,
+		candleRepo: candleRepo,
+		timeframes: sorted,
+		backfill:   backfill,
+	}
+}
+
+// Build updates the candles of every timeframe for symbol up to now.
+func (b *CandleBuilder) Build(ctx context.Context, symbol string, now time.Time) error {
+	if len(b.timeframes) == 0 {
+		return nil
+	}
+
+	base := b.timeframes[0]
+
+	from, err := b.resumeFrom(ctx, symbol, base, now)
+	if err != nil {
+		return err
+	}
+
+	aggregator := &tradeAggregator{symbol: symbol, timeframe: base, now: now}
+
+	err = b.tradeRepo.EachTrade(ctx, symbol, from, now, func(trade domain.Trade) error {
+		aggregator.add(trade)
+
+		if len(aggregator.candles) <= candleSaveBatch {
+			return nil
+		}
+
+		return b.save(ctx, aggregator.completed())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to aggregate trades: %w", err)
+	}
+
+	if err := b.save(ctx, aggregator.candles); err != nil {
+		return err
+	}
+
+	for _, timeframe := range b.timeframes[1:] {
+		from, err := b.resumeFrom(ctx, symbol, timeframe, now)
+		if err != nil {
+			return err
+		}
+
+		bars, err := b.candleRepo.GetCandles(ctx, symbol, base, from, now)
+		if err != nil {
+			return fmt.Errorf("failed to get %s candles: %w", base, err)
+		}
+
+		if err := b.save(ctx, AggregateCandles(bars, timeframe, now)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resumeFrom returns the open time of the last stored bar, which may still be
+// open, or the start of the backfill window when there is none.
+func (b *CandleBuilder) resumeFrom(
+	ctx context.Context,
+	symbol string,
+	timeframe domain.Timeframe,
+	now time.Time,
+) (time.Time, error) {
+	last, err := b.candleRepo.GetLatestCandles(ctx, symbol, timeframe, 1)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get latest %s candle: %w", timeframe, err)
+	}
+
+	if len(last) == 0 {
+		return now.Add(-b.backfill).Truncate(timeframe.Duration()), nil
+	}
+
+	return last[0].OpenTime, nil
+}
+
+func (b *CandleBuilder) save(ctx context.Context, candles []domain.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	if err := b.candleRepo.SaveCandles(ctx, candles); err != nil {
+		return fmt.Errorf("failed to save candles: %w", err)
+	}
+
+	return nil
+}
+
+// AggregateTrades groups time-ordered trades into bars of timeframe. Bars whose
+// close time is after now are returned with Closed set to false.
+func AggregateTrades(trades []domain.Trade, symbol string, timeframe domain.Timeframe, now time.Time) []domain.Candle {
+	aggregator := &tradeAggregator{symbol: symbol, timeframe: timeframe, now: now}
+
+	for _, trade := range trades {
+		aggregator.add(trade)
+	}
+
+	return aggregator.candles
+}
+
+// tradeAggregator groups time-ordered trades into bars one trade at a time.
+type tradeAggregator struct {
+	symbol    string
+	timeframe domain.Timeframe
+	now       time.Time
+	candles   []domain.Candle
+}
+
+func (a *tradeAggregator) add(trade domain.Trade) {
+	openTime := trade.TradeTime.Truncate(a.timeframe.Duration())
+
+	if len(a.candles) == 0 || !a.candles[len(a.candles)-1].OpenTime.Equal(openTime) {
+		a.candles = append(a.candles, newCandle(a.symbol, a.timeframe, openTime, a.now, trade.Price))
+	}
+
+	addTrade(&a.candles[len(a.candles)-1], trade)
+}
+
+// completed takes the bars that later trades can no longer change, all but
+// the last one.
+func (a *tradeAggregator) completed() []domain.Candle {
+	last := len(a.candles) - 1
+	completed := a.candles[:last]
+	a.candles = []domain.Candle{a.candles[last]}
+
+	return completed
+}
+
+func addTrade(candle *domain.Candle, trade domain.Trade) {
+	candle.High = max(candle.High, trade.Price)
+	candle.Low = min(candle.Low, trade.Price)
+	candle.Close = trade.Price
+	candle.Volume += trade.Quantity
+	candle.QuoteVolume += trade.Price * trade.Quantity
+	candle.Trades++
+}
+
+// AggregateCandles rolls time-ordered bars up into a larger timeframe.
+func AggregateCandles(bars []domain.Candle, timeframe domain.Timeframe, now time.Time) []domain.Candle {
+	var candles []domain.Candle
+
+	for _, bar := range bars {
+		openTime := bar.OpenTime.Truncate(timeframe.Duration())
+
+		if len(candles) == 0 || !candles[len(candles)-1].OpenTime.Equal(openTime) {
+			candles = append(candles, newCandle(bar.Symbol, timeframe, openTime, now, bar.Open))
+		}
+
+		candle := &candles[len(candles)-1]
+		candle.High = max(candle.High, bar.High)
+		candle.Low = min(candle.Low, bar.Low)
+		candle.Close = bar.Close
+		candle.Volume += bar.Volume
+		candle.QuoteVolume += bar.QuoteVolume
+		candle.Trades += bar.Trades
+	}
+
+	return candles
+}
+
+// AggregateSnapshots builds mid-price bars from time-ordered order book
+// snapshots. Snapshots without a valid touch are skipped.
+func AggregateSnapshots(
+	snapshots []domain.BookSnapshot,
+	symbol string,
+	timeframe domain.Timeframe,
+	now time.Time,
+) []domain.Candle {
+	aggregator := &midPriceAggregator{symbol: symbol, timeframe: timeframe, now: now}
+
+	for _, snapshot := range snapshots {
+		aggregator.add(snapshot)
+	}
+
+	return aggregator.candles
+}
+
+// midPriceAggregator groups time-ordered snapshots into mid-price bars one
+// snapshot at a time.
+type midPriceAggregator struct {
+	symbol    string
+	timeframe domain.Timeframe
+	now       time.Time
+	candles   []domain.Candle
+}
+
+func (a *midPriceAggregator) add(snapshot domain.BookSnapshot) {
+	if !validTouch(snapshot) {
+		return
+	}
+
+	mid := (snapshot.Bids[0].Price + snapshot.Asks[0].Price) / midpointDivisor
+	openTime := snapshot.Timestamp.Truncate(a.timeframe.Duration())
+
+	if len(a.candles) == 0 || !a.candles[len(a.candles)-1].OpenTime.Equal(openTime) {
+		a.candles = append(a.candles, newCandle(a.symbol, a.timeframe, openTime, a.now, mid))
+	}
+
+	candle := &a.candles[len(a.candles)-1]
+	candle.High = max(candle.High, mid)
+	candle.Low = min(candle.Low, mid)
+	candle.Close = mid
+}
+
+func newCandle(symbol string, timeframe domain.Timeframe, openTime, now time.Time, open float64) domain.Candle {
+	closeTime := openTime.Add(timeframe.Duration())
+
+	return domain.Candle{
+		Symbol:    symbol,
+		Timeframe: timeframe,
+		OpenTime:  openTime,
+		CloseTime: closeTime,
+		Open:      open,
+		High:      open,
+		Low:       open,
+		Close:     open,
+		Closed:    !closeTime.After(now),
+	}
+}
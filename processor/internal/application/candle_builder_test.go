@@ -0,0 +1,183 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var baseTime = time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func TestAggregateTradesBuildsOHLCV(t *testing.T) {
+	trades := []domain.Trade{
+		{Price: 100, Quantity: 1, TradeTime: baseTime.Add(5 * time.Second)},
+		{Price: 105, Quantity: 2, TradeTime: baseTime.Add(20 * time.Second)},
+		{Price: 95, Quantity: 1, TradeTime: baseTime.Add(40 * time.Second)},
+		{Price: 101, Quantity: 1, TradeTime: baseTime.Add(70 * time.Second)},
+	}
+
+	now := baseTime.Add(90 * time.Second)
+	candles := AggregateTrades(trades, "BTCUSDT", "1m", now)
+
+	assert.Len(t, candles, 2)
+
+	first := candles[0]
+	assert.Equal(t, baseTime, first.OpenTime)
+	assert.Equal(t, baseTime.Add(time.Minute), first.CloseTime)
+	assert.InDelta(t, 100.0, first.Open, 0)
+	assert.InDelta(t, 105.0, first.High, 0)
+	assert.InDelta(t, 95.0, first.Low, 0)
+	assert.InDelta(t, 95.0, first.Close, 0)
+	assert.InDelta(t, 4.0, first.Volume, 0)
+	assert.InDelta(t, 405.0, first.QuoteVolume, 0)
+	assert.Equal(t, int64(3), first.Trades)
+	assert.True(t, first.Closed)
+
+	assert.False(t, candles[1].Closed)
+	assert.InDelta(t, 101.0, candles[1].Open, 0)
+}
+
+func TestAggregateTradesEmpty(t *testing.T) {
+	assert.Empty(t, AggregateTrades(nil, "BTCUSDT", "1m", baseTime))
+}
+
+type streamTradeRepository struct {
+	trades []domain.Trade
+}
+
+func (r *streamTradeRepository) GetTrades(_ context.Context, _ string, _, _ time.Time) ([]domain.Trade, error) {
+	return r.trades, nil
+}
+
+func (r *streamTradeRepository) EachTrade(
+	_ context.Context,
+	_ string,
+	_, _ time.Time,
+	fn func(trade domain.Trade) error,
+) error {
+	for _, trade := range r.trades {
+		if err := fn(trade); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type savingCandleRepository struct {
+	stubCandleRepository
+	saves [][]domain.Candle
+}
+
+func (r *savingCandleRepository) SaveCandles(_ context.Context, candles []domain.Candle) error {
+	r.saves = append(r.saves, candles)
+
+	return nil
+}
+
+func TestCandleBuilderSavesStreamedTradesInBatches(t *testing.T) {
+	var trades []domain.Trade
+
+	// Two trades per minute, so that each bar is saved once it is complete
+	for i := range 2 * (candleSaveBatch + 10) {
+		trades = append(trades, domain.Trade{
+			Price:     float64(i),
+			Quantity:  1,
+			TradeTime: baseTime.Add(time.Duration(i) * 30 * time.Second),
+		})
+	}
+
+	candles := &savingCandleRepository{}
+	builder := NewCandleBuilder(&streamTradeRepository{trades: trades}, candles, []domain.Timeframe{"1m"}, time.Hour)
+
+	require.NoError(t, builder.Build(context.Background(), "BTCUSDT", baseTime.Add(24*time.Hour)))
+
+	require.Len(t, candles.saves, 2)
+	assert.Len(t, candles.saves[0], candleSaveBatch)
+	assert.Len(t, candles.saves[1], 10)
+
+	for _, saved := range candles.saves {
+		for _, candle := range saved {
+			assert.Equal(t, int64(2), candle.Trades)
+		}
+	}
+}
+
+func TestAggregateCandlesRollsUpTimeframe(t *testing.T) {
+	var bars []domain.Candle
+
+	for i := range 10 {
+		price := float64(100 + i)
+		bars = append(bars, domain.Candle{
+			Symbol:   "BTCUSDT",
+			OpenTime: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:     price, High: price + 1, Low: price - 1, Close: price + 0.5,
+			Volume: 1, Trades: 2,
+		})
+	}
+
+	candles := AggregateCandles(bars, "5m", baseTime.Add(10*time.Minute))
+
+	assert.Len(t, candles, 2)
+	assert.Equal(t, domain.Timeframe("5m"), candles[0].Timeframe)
+	assert.InDelta(t, 100.0, candles[0].Open, 0)
+	assert.InDelta(t, 105.0, candles[0].High, 0)
+	assert.InDelta(t, 99.0, candles[0].Low, 0)
+	assert.InDelta(t, 104.5, candles[0].Close, 0)
+	assert.InDelta(t, 5.0, candles[0].Volume, 0)
+	assert.Equal(t, int64(10), candles[0].Trades)
+	assert.True(t, candles[1].Closed)
+}
+
+func TestClosePricesSkipsOpenBar(t *testing.T) {
+	candles := []domain.Candle{
+		{Close: 1, Closed: true},
+		{Close: 2, Closed: true},
+		{Close: 3, Closed: false},
+	}
+
+	assert.Equal(t, []float64{1, 2}, closedSeries(candles).Closes())
+}
+
+func TestParseTimeframe(t *testing.T) {
+	timeframe, err := domain.ParseTimeframe("15m")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, timeframe.Duration())
+
+	_, err = domain.ParseTimeframe("7m")
+	assert.ErrorIs(t, err, domain.ErrUnsupportedTimeframe)
+}
+
+func TestAggregateSnapshotsBuildsMidPriceBars(t *testing.T) {
+	book := func(bid, ask float64, at time.Duration) domain.BookSnapshot {
+		return domain.BookSnapshot{
+			Bids:      []domain.PriceLevel{{Price: bid, Quantity: 1}, {Price: bid - 1, Quantity: 2}},
+			Asks:      []domain.PriceLevel{{Price: ask, Quantity: 1}, {Price: ask + 1, Quantity: 2}},
+			Timestamp: baseTime.Add(at),
+		}
+	}
+
+	snapshots := []domain.BookSnapshot{
+		book(99, 101, time.Second),
+		// A crossed book carries no touch
+		book(150, 101, 2*time.Second),
+		book(104, 106, 30*time.Second),
+		book(101, 103, 61*time.Second),
+	}
+
+	candles := AggregateSnapshots(snapshots, "BTCUSDT", "1m", baseTime.Add(90*time.Second))
+
+	assert.Len(t, candles, 2)
+	assert.InDelta(t, 100.0, candles[0].Open, 0)
+	assert.InDelta(t, 105.0, candles[0].High, 0)
+	assert.InDelta(t, 100.0, candles[0].Low, 0)
+	assert.InDelta(t, 105.0, candles[0].Close, 0)
+	assert.True(t, candles[0].Closed)
+	assert.InDelta(t, 102.0, candles[1].Open, 0)
+	assert.False(t, candles[1].Closed)
+}
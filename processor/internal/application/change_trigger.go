@@ -0,0 +1,165 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+// ChangeTriggerConfig controls how often the change trigger evaluates.
+type ChangeTriggerConfig struct {
+	// MinInterval is the least time between two evaluations of a target and
+	// between two stored resume tokens, as depth updates land many times a second.
+	MinInterval time.Duration
+	// Timeout bounds a single evaluation.
+	Timeout time.Duration
+	// RetryDelay is the pause before the change stream is reopened after an error.
+	RetryDelay time.Duration
+}
+
+// ChangeTrigger evaluates the strategies of a symbol and timeframe as soon as
+// new data for it lands instead of on a fixed schedule. Its position in the
+// change stream is stored, so after a restart it resumes where it stopped.
+type ChangeTrigger struct {
+	watcher   ports.ChangeWatcher
+	tokenRepo ports.ResumeTokenRepository
+	stream    string
+	config    ChangeTriggerConfig
+
+	targets   []changeTarget
+	tokenSave time.Time
+}
+
+// changeTarget is a signal service evaluated on the changes of one symbol and timeframe.
+type changeTarget struct {
+	symbol    string
+	timeframe domain.Timeframe
+	service   ports.SignalService
+	lastRun   time.Time
+}
+
+func NewChangeTrigger(
+	watcher ports.ChangeWatcher,
+	tokenRepo ports.ResumeTokenRepository,
+	stream string,
+	config ChangeTriggerConfig,
+) *ChangeTrigger {
+	return &ChangeTrigger{
+		watcher:   watcher,
+		tokenRepo: tokenRepo,
+		stream:    stream,
+		config:    config,
+	}
+}
+
+// Add evaluates service on the changes of symbol at timeframe. Must be called before Run.
+func (t *ChangeTrigger) Add(symbol string, timeframe domain.Timeframe, service ports.SignalService) {
+	t.targets = append(t.targets, changeTarget{symbol: symbol, timeframe: timeframe, service: service})
+}
+
+// Run follows the change stream until ctx is done, reopening it from the
+// stored resume token after errors. It returns domain.ErrChangeStreamUnsupported
+// when the database cannot provide change streams.
+func (t *ChangeTrigger) Run(ctx context.Context) error {
+	for {
+		token, err := t.tokenRepo.GetResumeToken(ctx, t.stream)
+		if err != nil {
+			log.Printf("Failed to load resume token of %s: %v", t.stream, err)
+		} else {
+			// Without a position the changes while stopped are unknown, so every target is brought up to date
+			if token == nil {
+				t.evaluateAll(ctx)
+			}
+
+			err = t.watcher.Watch(ctx, token, t.handle)
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, domain.ErrChangeStreamUnsupported):
+			return err
+		case errors.Is(err, domain.ErrResumeTokenLost):
+			log.Printf("Resume token of %s expired, following %s from now", t.stream, t.stream)
+
+			if err := t.tokenRepo.SaveResumeToken(ctx, t.stream, nil); err != nil {
+				log.Printf("Failed to reset resume token: %v", err)
+			}
+
+			continue
+		case err != nil:
+			log.Printf("Change stream %s failed: %v", t.stream, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(t.config.RetryDelay):
+		}
+	}
+}
+
+// handle evaluates the targets the event belongs to and stores the position
+// in the stream once they ran. Skipped changes are covered by the next
+// evaluation, which always sees all stored data.
+func (t *ChangeTrigger) handle(ctx context.Context, event domain.ChangeEvent) error {
+	now := time.Now()
+	evaluated := false
+
+	for i := range t.targets {
+		target := &t.targets[i]
+
+		if target.symbol != event.Symbol || (event.Timeframe != "" && target.timeframe != event.Timeframe) {
+			continue
+		}
+
+		if now.Sub(target.lastRun) < t.config.MinInterval {
+			continue
+		}
+
+		t.evaluate(ctx, target, now)
+
+		evaluated = true
+	}
+
+	if !evaluated && now.Sub(t.tokenSave) < t.config.MinInterval {
+		return nil
+	}
+
+	if err := t.tokenRepo.SaveResumeToken(ctx, t.stream, event.ResumeToken); err != nil {
+		return err
+	}
+
+	t.tokenSave = now
+
+	return nil
+}
+
+func (t *ChangeTrigger) evaluateAll(ctx context.Context) {
+	now := time.Now()
+
+	for i := range t.targets {
+		t.evaluate(ctx, &t.targets[i], now)
+	}
+}
+
+func (t *ChangeTrigger) evaluate(ctx context.Context, target *changeTarget, now time.Time) {
+	target.lastRun = now
+
+	ctx, cancel := context.WithTimeout(ctx, t.config.Timeout)
+	defer cancel()
+
+	signals, err := target.service.GenerateSignals(ctx, target.symbol, target.timeframe)
+	if err != nil {
+		log.Printf("Failed to generate %s %s signals: %v", target.symbol, target.timeframe, err)
+	}
+
+	for _, signal := range signals {
+		log.Printf("Generated signal: %s on %s %s by %s %v",
+			signal.Signal, signal.Symbol, signal.Timeframe, signal.Strategy, signal.Indicators)
+	}
+}
@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubWatcher replays events on the first Watch and then reports err.
+type stubWatcher struct {
+	events []domain.ChangeEvent
+	err    error
+	tokens [][]byte
+}
+
+func (w *stubWatcher) Watch(
+	ctx context.Context,
+	resumeToken []byte,
+	handle func(context.Context, domain.ChangeEvent) error,
+) error {
+	w.tokens = append(w.tokens, resumeToken)
+
+	for _, event := range w.events {
+		if err := handle(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	w.events = nil
+
+	return w.err
+}
+
+type stubTokenRepository struct {
+	tokens map[string][]byte
+}
+
+func (r *stubTokenRepository) GetResumeToken(_ context.Context, stream string) ([]byte, error) {
+	return r.tokens[stream], nil
+}
+
+func (r *stubTokenRepository) SaveResumeToken(_ context.Context, stream string, token []byte) error {
+	r.tokens[stream] = token
+
+	return nil
+}
+
+type stubSignalService struct {
+	calls []string
+}
+
+func (s *stubSignalService) GenerateSignals(
+	_ context.Context,
+	symbol string,
+	timeframe domain.Timeframe,
+) ([]domain.TradeSignal, error) {
+	s.calls = append(s.calls, symbol+"/"+string(timeframe))
+
+	return nil, nil
+}
+
+func TestChangeTriggerEvaluatesMatchingTargets(t *testing.T) {
+	watcher := &stubWatcher{
+		events: []domain.ChangeEvent{
+			{Symbol: "BTCUSDT", Timeframe: "1m", ResumeToken: []byte("1")},
+			{Symbol: "ETHUSDT", Timeframe: "1m", ResumeToken: []byte("2")},
+			{Symbol: "BTCUSDT", Timeframe: "1m", ResumeToken: []byte("3")}, // within the interval
+			{Symbol: "BTCUSDT", ResumeToken: []byte("4")},                  // depth update, every timeframe
+		},
+		err: domain.ErrChangeStreamUnsupported,
+	}
+	tokenRepo := &stubTokenRepository{tokens: map[string][]byte{"signals_candles": []byte("0")}}
+	service := &stubSignalService{}
+
+	trigger := NewChangeTrigger(watcher, tokenRepo, "signals_candles", ChangeTriggerConfig{
+		MinInterval: time.Hour,
+		Timeout:     time.Second,
+	})
+	trigger.Add("BTCUSDT", "1m", service)
+	trigger.Add("BTCUSDT", "1h", service)
+
+	err := trigger.Run(context.Background())
+	require.ErrorIs(t, err, domain.ErrChangeStreamUnsupported)
+
+	// Resumed from the stored token without a catch-up evaluation
+	assert.Equal(t, [][]byte{[]byte("0")}, watcher.tokens)
+	assert.Equal(t, []string{"BTCUSDT/1m", "BTCUSDT/1h"}, service.calls)
+	assert.Equal(t, []byte("4"), tokenRepo.tokens["signals_candles"])
+}
+
+func TestChangeTriggerCatchesUpWithoutToken(t *testing.T) {
+	watcher := &stubWatcher{err: domain.ErrChangeStreamUnsupported}
+	service := &stubSignalService{}
+
+	trigger := NewChangeTrigger(watcher, &stubTokenRepository{tokens: map[string][]byte{}}, "signals_candles",
+		ChangeTriggerConfig{MinInterval: time.Second, Timeout: time.Second})
+	trigger.Add("BTCUSDT", "1m", service)
+
+	require.ErrorIs(t, trigger.Run(context.Background()), domain.ErrChangeStreamUnsupported)
+	assert.Equal(t, []string{"BTCUSDT/1m"}, service.calls)
+}
@@ -0,0 +1,112 @@
+package application
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+// LeaderConfig controls the leader lease.
+type LeaderConfig struct {
+	// TTL is how long the lease lasts without renewal, so how long a failed
+	// leader blocks the others at most.
+	TTL time.Duration
+	// RenewInterval is how often the leader renews the lease and standbys try
+	// to take it. It must be well below TTL.
+	RenewInterval time.Duration
+}
+
+// LeaderElector makes sure only one replica runs the signal jobs. The
+// replica holding the lease leads; the others wait and take the lease over
+// once it expires.
+type LeaderElector struct {
+	lock   ports.LeaderLock
+	owner  string
+	config LeaderConfig
+}
+
+func NewLeaderElector(lock ports.LeaderLock, owner string, config LeaderConfig) *LeaderElector {
+	return &LeaderElector{
+		lock:   lock,
+		owner:  owner,
+		config: config,
+	}
+}
+
+// Run competes for the lease until ctx is done. Every time it is elected it
+// calls lead with a context that carries the fencing token of the term and is
+// cancelled when the lease is lost, and waits for lead to return before
+// competing again.
+func (e *LeaderElector) Run(ctx context.Context, lead func(context.Context)) {
+	ticker := time.NewTicker(e.config.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		token, err := e.lock.Acquire(ctx, e.owner, e.config.TTL)
+		if err != nil {
+			log.Printf("Failed to compete for leadership: %v", err)
+		}
+
+		if token > 0 {
+			e.lead(ctx, token, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead runs one term, renewing the lease until it is lost, ctx is done or lead returns.
+func (e *LeaderElector) lead(ctx context.Context, token int64, lead func(context.Context)) {
+	log.Printf("%s elected leader with fencing token %d", e.owner, token)
+
+	termCtx, cancel := context.WithCancel(domain.WithFencingToken(ctx, token))
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		lead(termCtx)
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+
+		// The lease expires anyway, releasing it lets a standby take over right away
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), e.config.RenewInterval)
+		defer releaseCancel()
+
+		if err := e.lock.Release(releaseCtx, e.owner); err != nil {
+			log.Printf("Failed to release leadership: %v", err)
+		}
+
+		log.Printf("%s stepped down as leader", e.owner)
+	}()
+
+	ticker := time.NewTicker(e.config.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			// Without a confirmed renewal another replica may lead soon, so stop at once
+			renewed, err := e.lock.Renew(ctx, e.owner, e.config.TTL)
+			if err != nil || !renewed {
+				log.Printf("Lost leadership: renewed %t, %v", renewed, err)
+
+				return
+			}
+		}
+	}
+}
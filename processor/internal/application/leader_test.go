@@ -0,0 +1,99 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLock is a lease without expiry; expire hands it to the next caller.
+type memoryLock struct {
+	mu    sync.Mutex
+	owner string
+	fence int64
+}
+
+func (l *memoryLock) Acquire(_ context.Context, owner string, _ time.Duration) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.owner != "" {
+		return 0, nil
+	}
+
+	l.owner = owner
+	l.fence++
+
+	return l.fence, nil
+}
+
+func (l *memoryLock) Renew(_ context.Context, owner string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.owner == owner, nil
+}
+
+func (l *memoryLock) Release(_ context.Context, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.owner == owner {
+		l.owner = ""
+	}
+
+	return nil
+}
+
+func (l *memoryLock) expire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.owner = ""
+}
+
+func TestLeaderElectorHandsOverWithHigherToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lock := &memoryLock{}
+	config := LeaderConfig{TTL: 50 * time.Millisecond, RenewInterval: 5 * time.Millisecond}
+
+	terms := make(chan int64, 4)
+	lead := func(ctx context.Context) {
+		token, ok := domain.FencingToken(ctx)
+		assert.True(t, ok)
+
+		terms <- token
+
+		<-ctx.Done()
+	}
+
+	go NewLeaderElector(lock, "first", config).Run(ctx, lead)
+
+	require.Equal(t, int64(1), <-terms)
+
+	go NewLeaderElector(lock, "second", config).Run(ctx, lead)
+
+	// The standby waits while the lease is held
+	select {
+	case token := <-terms:
+		t.Fatalf("standby elected with token %d while the lease is held", token)
+	case <-time.After(10 * config.RenewInterval):
+	}
+
+	// Once the lease expires one of them leads again in a newer term
+	lock.expire()
+
+	select {
+	case token := <-terms:
+		assert.Equal(t, int64(2), token)
+	case <-time.After(time.Second):
+		t.Fatal("no leader after the lease expired")
+	}
+}
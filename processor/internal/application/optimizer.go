@@ -0,0 +1,394 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+)
+
+// Objectives the optimizer ranks parameter sets by, higher is better for all.
+const (
+	ObjectiveSharpe   = "sharpe"
+	ObjectiveSortino  = "sortino"
+	ObjectiveReturn   = "return"
+	ObjectiveWinRate  = "winrate"
+	ObjectiveDrawdown = "drawdown"
+)
+
+var (
+	ErrUnknownObjective   = errors.New("unknown objective")
+	ErrNoValidParamSets   = errors.New("no valid parameter sets")
+	ErrInvalidWalkForward = errors.New("invalid walk-forward split")
+	ErrNotValidated       = errors.New("parameters not validated out of sample")
+	ErrGridTooLarge       = errors.New("parameter grid too large")
+)
+
+// maxGridSize is the largest grid searched exhaustively, larger ones need a
+// random search.
+const maxGridSize = 1_000_000
+
+// OptimizerConfig controls the parameter search.
+type OptimizerConfig struct {
+	Backtest  BacktestConfig
+	Objective string
+	// Workers is the number of parallel backtests, all CPU cores when 0.
+	Workers int
+	// Samples switches from the full grid to a random search over that many
+	// parameter sets drawn with Seed.
+	Samples int
+	Seed    uint64
+	// Folds is the number of walk-forward splits, 0 disables the validation.
+	Folds int
+	// InSampleRatio is the share of every fold the parameters are optimized on,
+	// the rest of the fold tests them out of sample.
+	InSampleRatio float64
+}
+
+// Optimizer searches the parameters of a strategy by backtesting every
+// candidate set in parallel and ranking them by the objective.
+type Optimizer struct {
+	registry  *StrategyRegistry
+	strategy  string
+	symbol    string
+	timeframe domain.Timeframe
+	config    OptimizerConfig
+}
+
+func NewOptimizer(
+	registry *StrategyRegistry,
+	strategy string,
+	symbol string,
+	timeframe domain.Timeframe,
+	config OptimizerConfig,
+) *Optimizer {
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+
+	return &Optimizer{
+		registry:  registry,
+		strategy:  strategy,
+		symbol:    symbol,
+		timeframe: timeframe,
+		config:    config,
+	}
+}
+
+// Optimize ranks the parameter sets of space on the whole history and, with
+// folds configured, validates the search walk-forward and selects the winner
+// of the latest fold as the best set. Parameter sets the strategy rejects are
+// skipped.
+func (o *Optimizer) Optimize(
+	ctx context.Context,
+	candles []domain.Candle,
+	space map[string][]float64,
+) (domain.OptimizationResult, error) {
+	if _, err := objectiveScore(domain.BacktestMetrics{}, o.config.Objective); err != nil {
+		return domain.OptimizationResult{}, err
+	}
+
+	series := closedSeries(candles)
+
+	paramSets, err := o.paramSets(space)
+	if err != nil {
+		return domain.OptimizationResult{}, err
+	}
+
+	runs, err := o.rank(ctx, series, paramSets)
+	if err != nil {
+		return domain.OptimizationResult{}, err
+	}
+
+	result := domain.OptimizationResult{
+		Strategy:  o.strategy,
+		Symbol:    o.symbol,
+		Timeframe: o.timeframe,
+		Objective: o.config.Objective,
+		Best:      runs[0].Params,
+		Runs:      runs,
+	}
+
+	if o.config.Folds == 0 {
+		return result, nil
+	}
+
+	result.Folds, err = o.walkForward(ctx, series, paramSets)
+	if err != nil {
+		return domain.OptimizationResult{}, err
+	}
+
+	for _, fold := range result.Folds {
+		result.OutOfSampleScore += fold.OutOfSampleScore
+	}
+
+	result.OutOfSampleScore /= float64(len(result.Folds))
+	// The full history ranking is in sample only, the latest fold picked its
+	// winner on the most recent data and was scored after it
+	result.Best = result.Folds[len(result.Folds)-1].InSample.Params
+
+	return result, nil
+}
+
+// CheckExport returns an error unless the best set of result was validated
+// walk-forward with an average out-of-sample score of at least minScore.
+func CheckExport(result domain.OptimizationResult, minScore float64) error {
+	if len(result.Folds) == 0 {
+		return fmt.Errorf("%w: no walk-forward folds", ErrNotValidated)
+	}
+
+	if result.OutOfSampleScore < minScore {
+		return fmt.Errorf("%w: out-of-sample %s %.4f below %.4f",
+			ErrNotValidated, result.Objective, result.OutOfSampleScore, minScore)
+	}
+
+	return nil
+}
+
+// walkForward splits the history into consecutive folds, optimizes on the
+// in-sample part of each and backtests the winner on the rest. The in-sample
+// bars warm the strategy up for the out-of-sample backtest.
+func (o *Optimizer) walkForward(
+	ctx context.Context,
+	series domain.Series,
+	paramSets []map[string]float64,
+) ([]domain.WalkForwardFold, error) {
+	size := len(series) / o.config.Folds
+	folds := make([]domain.WalkForwardFold, 0, o.config.Folds)
+
+	for k := range o.config.Folds {
+		fold := series[k*size : (k+1)*size]
+		if k == o.config.Folds-1 {
+			fold = series[k*size:]
+		}
+
+		inSampleLen := int(float64(len(fold)) * o.config.InSampleRatio)
+		if inSampleLen <= 0 || inSampleLen >= len(fold) {
+			return nil, fmt.Errorf("%w: fold %d has %d bars", ErrInvalidWalkForward, k, len(fold))
+		}
+
+		runs, err := o.rank(ctx, fold[:inSampleLen], paramSets)
+		if err != nil {
+			return nil, fmt.Errorf("fold %d: %w", k, err)
+		}
+
+		config := o.config.Backtest
+		config.WarmupBars = inSampleLen
+
+		outOfSample, err := o.backtest(ctx, fold, runs[0].Params, config)
+		if err != nil {
+			return nil, fmt.Errorf("fold %d: %w", k, err)
+		}
+
+		score, _ := objectiveScore(outOfSample.Metrics, o.config.Objective)
+
+		folds = append(folds, domain.WalkForwardFold{
+			InSampleFrom:     fold[0].OpenTime,
+			InSampleTo:       fold[inSampleLen-1].CloseTime,
+			OutOfSampleFrom:  fold[inSampleLen].OpenTime,
+			OutOfSampleTo:    fold[len(fold)-1].CloseTime,
+			InSample:         runs[0],
+			OutOfSample:      outOfSample.Metrics,
+			OutOfSampleScore: score,
+		})
+	}
+
+	return folds, nil
+}
+
+// rank backtests every parameter set on series in parallel, best first.
+func (o *Optimizer) rank(
+	ctx context.Context,
+	series domain.Series,
+	paramSets []map[string]float64,
+) ([]domain.OptimizationRun, error) {
+	runs := make([]*domain.OptimizationRun, len(paramSets))
+	errs := make([]error, len(paramSets))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range o.config.Workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				runs[i], errs[i] = o.run(ctx, series, paramSets[i])
+			}
+		}()
+	}
+
+	for i := range paramSets {
+		if ctx.Err() != nil {
+			break
+		}
+
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("optimization canceled: %w", err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	ranked := make([]domain.OptimizationRun, 0, len(runs))
+
+	for _, run := range runs {
+		if run != nil {
+			ranked = append(ranked, *run)
+		}
+	}
+
+	if len(ranked) == 0 {
+		return nil, ErrNoValidParamSets
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+
+		return ranked[i].StrategyID < ranked[j].StrategyID
+	})
+
+	return ranked, nil
+}
+
+// run scores one parameter set, returning nil if the strategy rejects it.
+func (o *Optimizer) run(
+	ctx context.Context,
+	series domain.Series,
+	params map[string]float64,
+) (*domain.OptimizationRun, error) {
+	result, err := o.backtest(ctx, series, params, o.config.Backtest)
+	if errors.Is(err, ErrInvalidStrategyParams) {
+		return nil, nil //nolint:nilnil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	score, _ := objectiveScore(result.Metrics, o.config.Objective)
+
+	return &domain.OptimizationRun{
+		Params:     params,
+		StrategyID: result.StrategyID,
+		Score:      score,
+		Metrics:    result.Metrics,
+	}, nil
+}
+
+func (o *Optimizer) backtest(
+	ctx context.Context,
+	series domain.Series,
+	params map[string]float64,
+	config BacktestConfig,
+) (domain.BacktestResult, error) {
+	strategy, err := o.registry.New(o.strategy, params)
+	if err != nil {
+		return domain.BacktestResult{}, err
+	}
+
+	return NewBacktester(strategy, o.symbol, o.timeframe, config).Run(ctx, series)
+}
+
+// paramSets returns the full grid of space, or a random sample of it drawn
+// without building the grid. Grids above maxGridSize require a sample.
+func (o *Optimizer) paramSets(space map[string][]float64) ([]map[string]float64, error) {
+	names := make([]string, 0, len(space))
+	for name := range space {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	size := 1
+
+	for _, name := range names {
+		if len(space[name]) == 0 {
+			return nil, nil
+		}
+
+		if size > math.MaxInt/len(space[name]) {
+			size = math.MaxInt
+		} else {
+			size *= len(space[name])
+		}
+	}
+
+	// at decodes index i of the grid, the last name varying fastest
+	at := func(i int) map[string]float64 {
+		params := make(map[string]float64, len(names))
+
+		for j := len(names) - 1; j >= 0; j-- {
+			values := space[names[j]]
+			params[names[j]] = values[i%len(values)]
+			i /= len(values)
+		}
+
+		return params
+	}
+
+	if o.config.Samples <= 0 && size > maxGridSize {
+		return nil, fmt.Errorf("%w: %d sets exceed %d, use a random search", ErrGridTooLarge, size, maxGridSize)
+	}
+
+	if o.config.Samples <= 0 || o.config.Samples >= size {
+		grid := make([]map[string]float64, size)
+		for i := range grid {
+			grid[i] = at(i)
+		}
+
+		return grid, nil
+	}
+
+	rng := rand.New(rand.NewPCG(o.config.Seed, o.config.Seed)) //nolint:gosec
+	drawn := make(map[int]bool, o.config.Samples)
+	sample := make([]map[string]float64, 0, o.config.Samples)
+
+	for len(sample) < o.config.Samples {
+		i := rng.IntN(size)
+		if drawn[i] {
+			continue
+		}
+
+		drawn[i] = true
+
+		sample = append(sample, at(i))
+	}
+
+	return sample, nil
+}
+
+// objectiveScore returns the value of the objective, higher being better.
+func objectiveScore(metrics domain.BacktestMetrics, objective string) (float64, error) {
+	switch objective {
+	case ObjectiveSharpe:
+		return metrics.Sharpe, nil
+	case ObjectiveSortino:
+		return metrics.Sortino, nil
+	case ObjectiveReturn:
+		return metrics.TotalReturn, nil
+	case ObjectiveWinRate:
+		return metrics.WinRate, nil
+	case ObjectiveDrawdown:
+		return -metrics.MaxDrawdown, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnknownObjective, objective)
+	}
+}
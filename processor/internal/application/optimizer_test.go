@@ -0,0 +1,153 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waveCandles builds closed 1m bars following a sine wave, opening at the
+// previous close.
+func waveCandles(count int) []domain.Candle {
+	candles := make([]domain.Candle, count)
+	previous := 100.0
+
+	for i := range candles {
+		openTime := baseTime.Add(time.Duration(i) * time.Minute)
+		price := 100 + 10*math.Sin(float64(i)/6) + float64(i)/20
+		candles[i] = domain.Candle{
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(time.Minute),
+			Open:      previous,
+			Close:     price,
+			Closed:    true,
+		}
+		previous = price
+	}
+
+	return candles
+}
+
+func TestOptimizerRanksValidParamSets(t *testing.T) {
+	space := map[string][]float64{"short": {1, 2, 4}, "long": {2, 4, 8}}
+	config := OptimizerConfig{
+		Backtest:  BacktestConfig{InitialCapital: 1000, Fee: 0.001},
+		Objective: ObjectiveReturn,
+		Workers:   4,
+	}
+
+	result, err := NewOptimizer(DefaultStrategyRegistry(), SMACrossoverName, "BTCUSDT", "1m", config).
+		Optimize(context.Background(), waveCandles(200), space)
+	require.NoError(t, err)
+
+	// Sets with short >= long are rejected by the strategy.
+	require.Len(t, result.Runs, 6)
+
+	for i := 1; i < len(result.Runs); i++ {
+		assert.GreaterOrEqual(t, result.Runs[i-1].Score, result.Runs[i].Score)
+	}
+
+	assert.Equal(t, result.Runs[0].Params, result.Best)
+	assert.InDelta(t, result.Runs[0].Metrics.TotalReturn, result.Runs[0].Score, 0)
+
+	// The ranking does not depend on the number of workers.
+	config.Workers = 1
+
+	sequential, err := NewOptimizer(DefaultStrategyRegistry(), SMACrossoverName, "BTCUSDT", "1m", config).
+		Optimize(context.Background(), waveCandles(200), space)
+	require.NoError(t, err)
+	assert.Equal(t, result, sequential)
+}
+
+func TestOptimizerRandomSearchAndWalkForward(t *testing.T) {
+	space := map[string][]float64{"short": {1, 2, 3}, "long": {10, 20, 30}}
+	config := OptimizerConfig{
+		Backtest:      BacktestConfig{InitialCapital: 1000},
+		Objective:     ObjectiveSharpe,
+		Samples:       4,
+		Seed:          7,
+		Folds:         3,
+		InSampleRatio: 0.75,
+	}
+
+	optimizer := NewOptimizer(DefaultStrategyRegistry(), SMACrossoverName, "BTCUSDT", "1m", config)
+
+	result, err := optimizer.Optimize(context.Background(), waveCandles(300), space)
+	require.NoError(t, err)
+	assert.Len(t, result.Runs, 4)
+	require.Len(t, result.Folds, 3)
+
+	var outOfSample float64
+
+	for i, fold := range result.Folds {
+		assert.Equal(t, baseTime.Add(time.Duration(i*100)*time.Minute), fold.InSampleFrom)
+		assert.Equal(t, fold.InSampleTo, fold.OutOfSampleFrom)
+		assert.Equal(t, baseTime.Add(time.Duration((i+1)*100)*time.Minute), fold.OutOfSampleTo)
+
+		outOfSample += fold.OutOfSampleScore
+	}
+
+	assert.InDelta(t, outOfSample/3, result.OutOfSampleScore, 1e-12)
+
+	// The export is the selection of the latest fold, validated out of sample
+	assert.Equal(t, result.Folds[2].InSample.Params, result.Best)
+	require.NoError(t, CheckExport(result, result.OutOfSampleScore))
+	require.ErrorIs(t, CheckExport(result, result.OutOfSampleScore+1), ErrNotValidated)
+
+	result.Folds = nil
+	require.ErrorIs(t, CheckExport(result, math.Inf(-1)), ErrNotValidated)
+
+	// The random sample is drawn from the grid without repeating a set
+	seen := make(map[string]bool)
+
+	sample, err := optimizer.paramSets(map[string][]float64{"a": {1, 2, 3, 4}, "b": {1, 2, 3, 4, 5}})
+	require.NoError(t, err)
+
+	for _, params := range sample {
+		key := fmt.Sprint(params)
+		assert.False(t, seen[key], key)
+		seen[key] = true
+	}
+
+	assert.Len(t, seen, 4)
+
+	config.Objective = "profit"
+
+	_, err = NewOptimizer(DefaultStrategyRegistry(), SMACrossoverName, "BTCUSDT", "1m", config).
+		Optimize(context.Background(), waveCandles(300), space)
+	require.ErrorIs(t, err, ErrUnknownObjective)
+
+	config.Objective, config.InSampleRatio = ObjectiveSharpe, 1
+
+	_, err = NewOptimizer(DefaultStrategyRegistry(), SMACrossoverName, "BTCUSDT", "1m", config).
+		Optimize(context.Background(), waveCandles(300), space)
+	require.ErrorIs(t, err, ErrInvalidWalkForward)
+}
+
+func TestOptimizerRejectsOversizedGrid(t *testing.T) {
+	values := make([]float64, 100)
+	space := make(map[string][]float64)
+
+	// 100^20 sets overflow int, only a random search can cover them
+	for i := range 20 {
+		space[fmt.Sprintf("p%d", i)] = values
+	}
+
+	config := OptimizerConfig{Objective: ObjectiveSharpe}
+
+	_, err := NewOptimizer(DefaultStrategyRegistry(), SMACrossoverName, "BTCUSDT", "1m", config).
+		Optimize(context.Background(), waveCandles(300), space)
+	require.ErrorIs(t, err, ErrGridTooLarge)
+
+	config.Samples = 3
+
+	sample, err := NewOptimizer(DefaultStrategyRegistry(), SMACrossoverName, "BTCUSDT", "1m", config).paramSets(space)
+	require.NoError(t, err)
+	assert.Len(t, sample, 3)
+}
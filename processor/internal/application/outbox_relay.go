@@ -0,0 +1,138 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+// OutboxRelay publishes the signals waiting in the outbox in the order they
+// were generated and marks them published. A signal published but not yet
+// marked is published again after a failure, so delivery is at least once and
+// consumers drop duplicates by the signal ID.
+//
+// Signals are published with the fencing token they were stored with. A new
+// leader first adopts the signals its predecessor left, so the only ones the
+// fenced publish rejects are stored by a deposed leader after the handover,
+// and those are discarded.
+type OutboxRelay struct {
+	outbox    ports.SignalOutbox
+	publisher ports.SignalPublisher
+	interval  time.Duration
+	batchSize int
+}
+
+func NewOutboxRelay(
+	outbox ports.SignalOutbox,
+	publisher ports.SignalPublisher,
+	interval time.Duration,
+	batchSize int,
+) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:    outbox,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run relays the outbox every interval until ctx is done. With a fencing
+// token in ctx it adopts the unpublished signals of older terms first.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	adopted := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !adopted {
+				if err := r.adopt(ctx); err != nil {
+					log.Printf("Failed to adopt unpublished signals: %v", err)
+
+					continue
+				}
+
+				adopted = true
+			}
+
+			if _, err := r.Relay(ctx); err != nil {
+				log.Printf("Failed to relay signals: %v", err)
+			}
+		}
+	}
+}
+
+// Relay publishes every unpublished signal and returns how many it published.
+// It stops at the first failure so later signals are not delivered first.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	published := 0
+
+	for {
+		signals, err := r.outbox.GetUnpublished(ctx, r.batchSize)
+		if err != nil {
+			return published, err
+		}
+
+		for _, pending := range signals {
+			ok, err := r.publish(ctx, pending)
+			if err != nil {
+				return published, fmt.Errorf("signal %s: %w", pending.Signal.ID, err)
+			}
+
+			if ok {
+				published++
+			}
+		}
+
+		if len(signals) < r.batchSize {
+			return published, nil
+		}
+	}
+}
+
+// adopt moves the unpublished signals of older terms to the term of ctx.
+func (r *OutboxRelay) adopt(ctx context.Context) error {
+	token, ok := domain.FencingToken(ctx)
+	if !ok {
+		return nil
+	}
+
+	return r.outbox.AdoptUnpublished(ctx, token)
+}
+
+// publish publishes pending with its fencing token and marks it published.
+// A signal stored by a deposed leader is discarded instead, ok is false then.
+func (r *OutboxRelay) publish(ctx context.Context, pending domain.OutboxSignal) (bool, error) {
+	if pending.FencingToken == 0 {
+		if err := r.publisher.PublishSignal(ctx, pending.Signal); err != nil {
+			return false, err
+		}
+
+		return true, r.outbox.MarkPublished(ctx, pending.Signal.ID, time.Now())
+	}
+
+	err := r.publisher.PublishSignal(domain.WithFencingToken(ctx, pending.FencingToken), pending.Signal)
+
+	// A stale token of the current term means this replica was deposed itself
+	token, leading := domain.FencingToken(ctx)
+	if errors.Is(err, domain.ErrStaleFencingToken) && leading && pending.FencingToken < token {
+		log.Printf("Discarding signal %s stored by a deposed leader", pending.Signal.ID)
+
+		return false, r.outbox.MarkDiscarded(ctx, pending.Signal.ID, time.Now())
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, r.outbox.MarkPublished(ctx, pending.Signal.ID, time.Now())
+}
@@ -0,0 +1,144 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errPublishFailed = errors.New("publish failed")
+
+type stubOutbox struct {
+	signals   []domain.OutboxSignal
+	published map[string]bool
+	discarded []string
+}
+
+func (o *stubOutbox) GetUnpublished(_ context.Context, limit int) ([]domain.OutboxSignal, error) {
+	var pending []domain.OutboxSignal
+
+	for _, signal := range o.signals {
+		if !o.published[signal.Signal.ID] && len(pending) < limit {
+			pending = append(pending, signal)
+		}
+	}
+
+	return pending, nil
+}
+
+func (o *stubOutbox) MarkPublished(_ context.Context, id string, _ time.Time) error {
+	o.published[id] = true
+
+	return nil
+}
+
+func (o *stubOutbox) MarkDiscarded(_ context.Context, id string, _ time.Time) error {
+	o.published[id] = true
+	o.discarded = append(o.discarded, id)
+
+	return nil
+}
+
+func (o *stubOutbox) AdoptUnpublished(_ context.Context, token int64) error {
+	for i, signal := range o.signals {
+		if !o.published[signal.Signal.ID] && signal.FencingToken < token {
+			o.signals[i].FencingToken = token
+		}
+	}
+
+	return nil
+}
+
+// flakyPublisher fails the publish of one signal once.
+type flakyPublisher struct {
+	failID    string
+	published []string
+}
+
+func (p *flakyPublisher) PublishSignal(_ context.Context, signal domain.TradeSignal) error {
+	if signal.ID == p.failID {
+		p.failID = ""
+
+		return errPublishFailed
+	}
+
+	p.published = append(p.published, signal.ID)
+
+	return nil
+}
+
+func TestOutboxRelayPublishesInOrderAndRetries(t *testing.T) {
+	ctx := context.Background()
+	outbox := &stubOutbox{published: map[string]bool{}}
+
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		outbox.signals = append(outbox.signals, domain.OutboxSignal{Signal: domain.TradeSignal{ID: id}})
+	}
+
+	publisher := &flakyPublisher{failID: "d"}
+	relay := NewOutboxRelay(outbox, publisher, time.Second, 2)
+
+	published, err := relay.Relay(ctx)
+	require.ErrorIs(t, err, errPublishFailed)
+	assert.Equal(t, 3, published)
+
+	// The failed signal is retried before the ones after it
+	published, err = relay.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, publisher.published)
+
+	published, err = relay.Relay(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, published)
+}
+
+// fencedPublisher rejects signals published with a fencing token older than fence.
+type fencedPublisher struct {
+	fence     int64
+	published []string
+}
+
+func (p *fencedPublisher) PublishSignal(ctx context.Context, signal domain.TradeSignal) error {
+	if token, ok := domain.FencingToken(ctx); ok && token < p.fence {
+		return domain.ErrStaleFencingToken
+	}
+
+	p.published = append(p.published, signal.ID)
+
+	return nil
+}
+
+func TestOutboxRelayDiscardsSignalsOfDeposedLeaders(t *testing.T) {
+	ctx := domain.WithFencingToken(context.Background(), 2)
+	outbox := &stubOutbox{published: map[string]bool{}, signals: []domain.OutboxSignal{
+		{Signal: domain.TradeSignal{ID: "handed-over"}, FencingToken: 1},
+	}}
+	publisher := &fencedPublisher{fence: 2}
+	relay := NewOutboxRelay(outbox, publisher, time.Second, 10)
+
+	// The signals the previous leader left are adopted by the new term
+	require.NoError(t, relay.adopt(ctx))
+
+	// A deposed leader stores a signal after the handover
+	outbox.signals = append(outbox.signals, domain.OutboxSignal{Signal: domain.TradeSignal{ID: "zombie"}, FencingToken: 1})
+
+	published, err := relay.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"handed-over"}, publisher.published)
+	assert.Equal(t, []string{"zombie"}, outbox.discarded)
+
+	// Once deposed itself the relay stops instead of discarding its own signals
+	outbox.signals = append(outbox.signals, domain.OutboxSignal{Signal: domain.TradeSignal{ID: "own"}, FencingToken: 2})
+	publisher.fence = 3
+
+	_, err = relay.Relay(ctx)
+	require.ErrorIs(t, err, domain.ErrStaleFencingToken)
+	assert.Equal(t, []string{"zombie"}, outbox.discarded)
+}
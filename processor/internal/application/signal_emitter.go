@@ -0,0 +1,224 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+// SignalEmitter tracks the state of every strategy per symbol and timeframe and
+// only stores actionable signals: a transition into BUY or SELL, such as a
+// crossover. Stored signals are published from the outbox by the OutboxRelay.
+// Repeating the same actionable signal within the dedup window is suppressed.
+// A transition the optional gate rejects, or that fails to store, leaves the
+// state as it was, so it is emitted on a later bar. The steady state is kept
+// separately in the status repository.
+type SignalEmitter struct {
+	signalRepo  ports.SignalRepository
+	statusRepo  ports.SignalStatusRepository
+	gate        ports.SignalGate
+	dedupWindow time.Duration
+
+	mu       sync.Mutex
+	statuses map[string]domain.SignalStatus
+}
+
+func NewSignalEmitter(
+	signalRepo ports.SignalRepository,
+	statusRepo ports.SignalStatusRepository,
+	gate ports.SignalGate,
+	dedupWindow time.Duration,
+) *SignalEmitter {
+	return &SignalEmitter{
+		signalRepo:  signalRepo,
+		statusRepo:  statusRepo,
+		gate:        gate,
+		dedupWindow: dedupWindow,
+		statuses:    make(map[string]domain.SignalStatus),
+	}
+}
+
+// Load restores the states stored by a previous run so that the first signal
+// after a restart is not mistaken for a transition.
+func (e *SignalEmitter) Load(ctx context.Context) error {
+	statuses, err := e.statusRepo.GetStatuses(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load signal statuses: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, status := range statuses {
+		e.statuses[statusKey(status.Symbol, status.Timeframe, status.StrategyID)] = status
+	}
+
+	return nil
+}
+
+// Prime records the state of a warm-up signal without emitting or storing it.
+func (e *SignalEmitter) Prime(symbol string, signal domain.TradeSignal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key, status, _, _ := e.next(symbol, signal)
+	e.statuses[key] = status
+}
+
+// CatchUp records the state of a warm-up signal like Prime. A signal of a bar
+// closed after the loaded state was last updated was evaluated by no one, such
+// as during a leader failover, so its transition is emitted instead. Signals
+// the loaded state already covers are skipped.
+func (e *SignalEmitter) CatchUp(ctx context.Context, symbol string, signal domain.TradeSignal) bool {
+	e.mu.Lock()
+	key, _, _, _ := e.next(symbol, signal)
+	status, known := e.statuses[key]
+	e.mu.Unlock()
+
+	switch {
+	case !known:
+		e.Prime(symbol, signal)
+
+		return false
+	case !signal.Timestamp.After(status.UpdatedAt):
+		return false
+	default:
+		return e.Emit(ctx, symbol, signal)
+	}
+}
+
+// Emit records the state of signal and stores it in the outbox if it is
+// actionable. It reports whether the signal was emitted.
+func (e *SignalEmitter) Emit(ctx context.Context, symbol string, signal domain.TradeSignal) bool {
+	// The gate may query storage, so it is asked before taking the lock
+	allowed := signal.Signal == domain.Neutral || e.allow(ctx, signal)
+
+	e.mu.Lock()
+	key, status, known, changed := e.next(symbol, signal)
+
+	actionable := known && changed && signal.Signal != domain.Neutral
+
+	// The state is kept so that the transition is emitted once the gate opens
+	if actionable && !allowed {
+		e.mu.Unlock()
+		log.Printf("Gated %s signal of %s on %s %s", signal.Signal, status.StrategyID, symbol, signal.Timeframe)
+
+		return false
+	}
+
+	if actionable && status.LastSignal == signal.Signal && signal.Timestamp.Sub(status.LastSignalAt) < e.dedupWindow {
+		log.Printf("Suppressed duplicate %s signal of %s on %s", signal.Signal, status.StrategyID, symbol)
+
+		actionable = false
+	}
+
+	if !actionable {
+		e.statuses[key] = status
+		e.mu.Unlock()
+		e.saveStatus(ctx, status)
+
+		return false
+	}
+
+	e.mu.Unlock()
+
+	if signal.ID == "" {
+		signal.ID = domain.SignalID(signal)
+	}
+
+	// Published by the outbox relay once stored. The state is only committed
+	// then, so a signal that failed to store is emitted again on the next bar.
+	if err := e.signalRepo.SaveSignal(ctx, signal); err != nil {
+		log.Printf("Failed to save signal to database: %v", err)
+
+		return false
+	}
+
+	status.LastSignal, status.LastSignalAt = signal.Signal, signal.Timestamp
+
+	e.mu.Lock()
+	e.statuses[key] = status
+	e.mu.Unlock()
+	e.saveStatus(ctx, status)
+
+	return true
+}
+
+func (e *SignalEmitter) saveStatus(ctx context.Context, status domain.SignalStatus) {
+	if err := e.statusRepo.SaveStatus(ctx, status); err != nil {
+		log.Printf("Failed to save signal status: %v", err)
+	}
+}
+
+// Statuses returns the current state of every strategy, symbol and timeframe.
+func (e *SignalEmitter) Statuses() []domain.SignalStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	statuses := make([]domain.SignalStatus, 0, len(e.statuses))
+	for _, status := range e.statuses {
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statusKey(statuses[i].Symbol, statuses[i].Timeframe, statuses[i].StrategyID) <
+			statusKey(statuses[j].Symbol, statuses[j].Timeframe, statuses[j].StrategyID)
+	})
+
+	return statuses
+}
+
+// next returns the key and the state after signal without storing it, and
+// reports whether a previous state was known and whether it changed. Callers
+// must hold the lock.
+func (e *SignalEmitter) next(symbol string, signal domain.TradeSignal) (string, domain.SignalStatus, bool, bool) {
+	strategyID := signal.StrategyID
+	if strategyID == "" {
+		strategyID = domain.StrategyID(signal.Strategy, signal.Params)
+	}
+
+	key := statusKey(symbol, signal.Timeframe, strategyID)
+
+	status, known := e.statuses[key]
+	changed := !known || status.State != signal.Signal
+
+	if changed {
+		status.Since = signal.Timestamp
+	}
+
+	status.Symbol = symbol
+	status.Timeframe = signal.Timeframe
+	status.StrategyID = strategyID
+	status.State = signal.Signal
+	status.UpdatedAt = signal.Timestamp
+	status.Indicators = signal.Indicators
+
+	return key, status, known, changed
+}
+
+// allow reports whether the gate lets signal through. Without a gate every
+// signal passes, a failing gate blocks it.
+func (e *SignalEmitter) allow(ctx context.Context, signal domain.TradeSignal) bool {
+	if e.gate == nil {
+		return true
+	}
+
+	allowed, err := e.gate.Allow(ctx, signal)
+	if err != nil {
+		log.Printf("Failed to check %s signal of %s on %s: %v", signal.Signal, signal.StrategyID, signal.Symbol, err)
+
+		return false
+	}
+
+	return allowed
+}
+
+func statusKey(symbol string, timeframe domain.Timeframe, strategyID string) string {
+	return symbol + "/" + string(timeframe) + "/" + strategyID
+}
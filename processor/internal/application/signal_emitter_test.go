@@ -0,0 +1,103 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func emitterSignal(action string, at time.Time) domain.TradeSignal {
+	return domain.TradeSignal{
+		Signal:    action,
+		Symbol:    "BTCUSDT",
+		Timeframe: "1m",
+		Strategy:  SMACrossoverName,
+		Params:    map[string]float64{"short": 50, "long": 200},
+		Timestamp: at,
+	}
+}
+
+func TestSignalEmitterEmitsTransitionsOnly(t *testing.T) {
+	ctx := context.Background()
+	sink := &stubSignalSink{}
+	emitter, statusRepo := newTestEmitter(sink, 10*time.Minute)
+
+	steps := []struct {
+		action  string
+		offset  time.Duration
+		emitted bool
+	}{
+		{action: domain.Sell, offset: 0, emitted: false},              // first state is not a crossover
+		{action: domain.Sell, offset: time.Minute, emitted: false},    // steady state
+		{action: domain.Buy, offset: 2 * time.Minute, emitted: true},  // crossover
+		{action: domain.Buy, offset: 3 * time.Minute, emitted: false}, // steady state
+		{action: domain.Neutral, offset: 4 * time.Minute, emitted: false},
+		{action: domain.Buy, offset: 5 * time.Minute, emitted: false}, // same content within the window
+		{action: domain.Sell, offset: 6 * time.Minute, emitted: true}, // crossover
+		{action: domain.Buy, offset: 20 * time.Minute, emitted: true}, // outside the window
+	}
+
+	for i, step := range steps {
+		emitted := emitter.Emit(ctx, "BTCUSDT", emitterSignal(step.action, baseTime.Add(step.offset)))
+		assert.Equalf(t, step.emitted, emitted, "step %d", i)
+	}
+
+	require.Len(t, sink.saved, 3)
+
+	statuses := emitter.Statuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "sma_crossover(long=200,short=50)", statuses[0].StrategyID)
+	assert.Equal(t, domain.Buy, statuses[0].State)
+	assert.Equal(t, baseTime.Add(20*time.Minute), statuses[0].Since)
+	assert.Equal(t, statuses[0], statusRepo.statuses["BTCUSDT/1m/sma_crossover(long=200,short=50)"])
+}
+
+func TestSignalEmitterRestoresStates(t *testing.T) {
+	ctx := context.Background()
+	sink := &stubSignalSink{}
+	first, statusRepo := newTestEmitter(sink, time.Minute)
+
+	first.Emit(ctx, "BTCUSDT", emitterSignal(domain.Sell, baseTime))
+
+	restarted := NewSignalEmitter(sink, statusRepo, nil, time.Minute)
+	require.NoError(t, restarted.Load(ctx))
+
+	// The stored SELL state turns the first BUY after the restart into a crossover.
+	assert.True(t, restarted.Emit(ctx, "BTCUSDT", emitterSignal(domain.Buy, baseTime.Add(time.Minute))))
+}
+
+func TestSignalEmitterRetriesUnsavedSignals(t *testing.T) {
+	ctx := context.Background()
+	sink := &stubSignalSink{saveErr: errors.New("outbox unavailable")}
+	emitter, statusRepo := newTestEmitter(sink, time.Minute)
+
+	emitter.Emit(ctx, "BTCUSDT", emitterSignal(domain.Sell, baseTime))
+	assert.False(t, emitter.Emit(ctx, "BTCUSDT", emitterSignal(domain.Buy, baseTime.Add(time.Minute))))
+
+	// The state is not committed without the stored signal
+	assert.Equal(t, domain.Sell, emitter.Statuses()[0].State)
+	assert.Equal(t, domain.Sell, statusRepo.statuses["BTCUSDT/1m/sma_crossover(long=200,short=50)"].State)
+
+	sink.saveErr = nil
+	assert.True(t, emitter.Emit(ctx, "BTCUSDT", emitterSignal(domain.Buy, baseTime.Add(2*time.Minute))))
+	require.Len(t, sink.saved, 1)
+	assert.Equal(t, domain.Buy, emitter.Statuses()[0].State)
+}
+
+func TestSignalIDIdentifiesTheBar(t *testing.T) {
+	signal := emitterSignal(domain.Buy, baseTime.Add(time.Second))
+	signal.BarTime = baseTime
+
+	again := signal
+	again.Timestamp = baseTime.Add(time.Minute)
+
+	assert.Equal(t, domain.SignalID(signal), domain.SignalID(again))
+
+	again.BarTime = baseTime.Add(time.Minute)
+	assert.NotEqual(t, domain.SignalID(signal), domain.SignalID(again))
+}
@@ -0,0 +1,241 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+// EngineConfig controls how the signal engine follows new trades.
+type EngineConfig struct {
+	// PollInterval is how often new trades are fetched.
+	PollInterval time.Duration
+	// CloseDelay is how long after its close time a bar without a newer trade
+	// is closed, leaving time for late trades to be stored.
+	CloseDelay time.Duration
+	// RetryDelay is the pause before a failed warm-up is retried, doubled on
+	// every failure up to MaxRetryDelay.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+}
+
+// SignalEngine generates signals as trades arrive instead of recomputing them
+// from history on every tick. It warms its strategies up once from stored
+// candles, then builds the current bar from new trades and feeds every closed
+// bar to the strategies, which update their indicators incrementally.
+type SignalEngine struct {
+	tradeRepo  ports.TradeRepository
+	candleRepo ports.CandleRepository
+	features   *BookFeatureService
+	emitter    *SignalEmitter
+	strategies []ports.StreamingStrategy
+	symbol     string
+	timeframe  domain.Timeframe
+	config     EngineConfig
+
+	bar         *domain.Candle
+	cursor      time.Time
+	lastTradeID int64
+}
+
+func NewSignalEngine(
+	tradeRepo ports.TradeRepository,
+	candleRepo ports.CandleRepository,
+	features *BookFeatureService,
+	emitter *SignalEmitter,
+	strategies []ports.Strategy,
+	symbol string,
+	timeframe domain.Timeframe,
+	config EngineConfig,
+) *SignalEngine {
+	streaming := make([]ports.StreamingStrategy, 0, len(strategies))
+	for _, strategy := range strategies {
+		streaming = append(streaming, AsStreaming(strategy))
+	}
+
+	return &SignalEngine{
+		tradeRepo:  tradeRepo,
+		candleRepo: candleRepo,
+		features:   features,
+		emitter:    emitter,
+		strategies: streaming,
+		symbol:     symbol,
+		timeframe:  timeframe,
+		config:     config,
+	}
+}
+
+// Run warms the engine up, retrying until the candles can be loaded, and
+// follows new trades until ctx is done.
+func (e *SignalEngine) Run(ctx context.Context) {
+	delay := e.config.RetryDelay
+
+	for {
+		err := e.Warmup(ctx, time.Now())
+		if err == nil {
+			break
+		}
+
+		log.Printf("Failed to warm up the %s %s signal engine, retrying in %s: %v", e.symbol, e.timeframe, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay = min(2*delay, e.config.MaxRetryDelay)
+	}
+
+	ticker := time.NewTicker(e.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Poll(ctx, time.Now()); err != nil {
+				log.Printf("Failed to poll trades: %v", err)
+			}
+		}
+	}
+}
+
+// Warmup feeds the latest closed candles to the strategies and positions the
+// trade cursor after them. Crossovers of bars closed after the loaded states
+// were last updated are emitted.
+func (e *SignalEngine) Warmup(ctx context.Context, now time.Time) error {
+	history := 0
+	for _, strategy := range e.strategies {
+		history = max(history, strategy.RequiredHistory())
+	}
+
+	// One extra bar as the latest one may still be open
+	candles, err := e.candleRepo.GetLatestCandles(ctx, e.symbol, e.timeframe, history+1)
+	if err != nil {
+		return fmt.Errorf("failed to get candles: %w", err)
+	}
+
+	e.refreshFeatures(ctx, candles)
+
+	e.cursor = now.Truncate(e.timeframe.Duration())
+
+	for _, candle := range candles {
+		if !candle.Closed {
+			// The open bar is rebuilt from its trades.
+			e.cursor = candle.OpenTime
+
+			break
+		}
+
+		// Warm-up signals establish the state crossovers are detected against
+		for _, strategy := range e.strategies {
+			if signal, ok := strategy.Update(candle); ok {
+				tradeSignal := newTradeSignal(strategy, signal, e.symbol, e.timeframe, candle, candle.CloseTime)
+				e.emitter.CatchUp(ctx, e.symbol, tradeSignal)
+			}
+		}
+
+		e.cursor = candle.CloseTime
+	}
+
+	log.Printf("Signal engine warmed up with %d %s candles of %s", len(candles), e.timeframe, e.symbol)
+
+	return nil
+}
+
+// Poll processes the trades stored since the last poll and closes the current
+// bar once its close delay has passed.
+func (e *SignalEngine) Poll(ctx context.Context, now time.Time) error {
+	trades, err := e.tradeRepo.GetTrades(ctx, e.symbol, e.cursor, now)
+	if err != nil {
+		return fmt.Errorf("failed to get trades: %w", err)
+	}
+
+	for _, trade := range trades {
+		e.OnTrade(ctx, trade)
+	}
+
+	e.OnTick(ctx, now)
+
+	return nil
+}
+
+// OnTrade adds trade to the current bar, closing the bar first if the trade
+// belongs to a later one, and returns the actionable signals of a closed bar.
+// Trades that were already seen are ignored.
+func (e *SignalEngine) OnTrade(ctx context.Context, trade domain.Trade) []domain.TradeSignal {
+	if trade.TradeTime.Before(e.cursor) || (trade.TradeTime.Equal(e.cursor) && trade.TradeID <= e.lastTradeID) {
+		return nil
+	}
+
+	e.cursor, e.lastTradeID = trade.TradeTime, trade.TradeID
+
+	var signals []domain.TradeSignal
+
+	openTime := trade.TradeTime.Truncate(e.timeframe.Duration())
+	if e.bar != nil && openTime.After(e.bar.OpenTime) {
+		signals = e.closeBar(ctx)
+	}
+
+	if e.bar == nil {
+		candle := newCandle(e.symbol, e.timeframe, openTime, openTime, trade.Price)
+		e.bar = &candle
+	}
+
+	addTrade(e.bar, trade)
+
+	return signals
+}
+
+// OnTick closes the current bar if no newer trade arrived within the close delay.
+func (e *SignalEngine) OnTick(ctx context.Context, now time.Time) []domain.TradeSignal {
+	if e.bar == nil || now.Before(e.bar.CloseTime.Add(e.config.CloseDelay)) {
+		return nil
+	}
+
+	return e.closeBar(ctx)
+}
+
+func (e *SignalEngine) closeBar(ctx context.Context) []domain.TradeSignal {
+	bar := *e.bar
+	bar.Closed = true
+	e.bar = nil
+
+	bars := []domain.Candle{bar}
+	e.refreshFeatures(ctx, bars)
+	bar = bars[0]
+
+	var signals []domain.TradeSignal
+
+	for _, strategy := range e.strategies {
+		signal, ok := strategy.Update(bar)
+		if !ok {
+			continue
+		}
+
+		tradeSignal := newTradeSignal(strategy, signal, e.symbol, e.timeframe, bar, time.Now())
+		if e.emitter.Emit(ctx, e.symbol, tradeSignal) {
+			signals = append(signals, tradeSignal)
+		}
+	}
+
+	return signals
+}
+
+// refreshFeatures attaches the order book features to candles, if enabled.
+// Strategies that do not need them still run when they are unavailable.
+func (e *SignalEngine) refreshFeatures(ctx context.Context, candles []domain.Candle) {
+	if e.features == nil {
+		return
+	}
+
+	if err := e.features.Refresh(ctx, e.symbol, candles); err != nil {
+		log.Printf("Failed to refresh book features: %v", err)
+	}
+}
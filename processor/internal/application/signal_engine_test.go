@@ -0,0 +1,218 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evaluateOnly hides the streaming implementation of a strategy.
+type evaluateOnly struct {
+	ports.Strategy
+}
+
+func TestStreamingUpdateMatchesEvaluate(t *testing.T) {
+	closes := []float64{5, 3, 8, 1, 9, 4, 7, 2, 6, 10}
+
+	streaming, err := NewSMACrossover(2, 4)
+	require.NoError(t, err)
+
+	batch, err := NewSMACrossover(2, 4)
+	require.NoError(t, err)
+
+	windowed := AsStreaming(evaluateOnly{batch})
+	candles := closedCandles(closes...)
+
+	for i, candle := range candles {
+		got, ok := streaming.Update(candle)
+		fallback, fallbackOK := windowed.Update(candle)
+
+		assert.Equal(t, i >= 3, ok)
+		assert.Equal(t, ok, fallbackOK)
+
+		if !ok {
+			continue
+		}
+
+		want, err := batch.Evaluate(candles[:i+1])
+		require.NoError(t, err)
+		assert.Equal(t, want.Action, got.Action)
+		assert.InDelta(t, want.Indicators["shortSMA"], got.Indicators["shortSMA"], 1e-9)
+		assert.InDelta(t, want.Indicators["longSMA"], got.Indicators["longSMA"], 1e-9)
+		assert.Equal(t, want, fallback)
+	}
+}
+
+func TestSignalEngineClosesBarsFromTrades(t *testing.T) {
+	start := baseTime
+
+	history := []domain.Candle{
+		{Close: 10, Closed: true, OpenTime: start, CloseTime: start.Add(time.Minute)},
+		{Close: 9, Closed: true, OpenTime: start.Add(time.Minute), CloseTime: start.Add(2 * time.Minute)},
+		{Close: 7, Closed: false, OpenTime: start.Add(2 * time.Minute), CloseTime: start.Add(3 * time.Minute)},
+	}
+
+	strategy, err := NewSMACrossover(1, 2)
+	require.NoError(t, err)
+
+	sink := &stubSignalSink{}
+	emitter, _ := newTestEmitter(sink, time.Hour)
+	engine := NewSignalEngine(
+		nil,
+		&stubCandleRepository{candles: history},
+		nil,
+		emitter,
+		[]ports.Strategy{strategy},
+		"BTCUSDT",
+		"1m",
+		EngineConfig{CloseDelay: time.Second},
+	)
+
+	require.NoError(t, engine.Warmup(context.Background(), start.Add(2*time.Minute+30*time.Second)))
+
+	// The open bar is rebuilt from its trades.
+	assert.Equal(t, start.Add(2*time.Minute), engine.cursor)
+
+	ctx := context.Background()
+	barStart := start.Add(2 * time.Minute)
+
+	assert.Empty(t, engine.OnTrade(ctx, domain.Trade{TradeID: 1, Price: 11, TradeTime: barStart.Add(time.Second)}))
+	assert.Empty(t, engine.OnTrade(ctx, domain.Trade{TradeID: 2, Price: 12, TradeTime: barStart.Add(50 * time.Second)}))
+
+	// A duplicate of the last trade is ignored.
+	assert.Empty(t, engine.OnTrade(ctx, domain.Trade{TradeID: 2, Price: 99, TradeTime: barStart.Add(50 * time.Second)}))
+
+	// The first trade of the next bar closes the current one, crossing above the primed SELL state.
+	signals := engine.OnTrade(ctx, domain.Trade{TradeID: 3, Price: 5, TradeTime: barStart.Add(61 * time.Second)})
+	require.Len(t, signals, 1)
+	assert.Equal(t, domain.Buy, signals[0].Signal)
+	assert.InDelta(t, 12.0, signals[0].Indicators["shortSMA"], 0)
+	assert.InDelta(t, 10.5, signals[0].Indicators["longSMA"], 0)
+	assert.Equal(t, signals, sink.saved)
+
+	// Without newer trades the bar closes once the close delay has passed.
+	assert.Empty(t, engine.OnTick(ctx, barStart.Add(2*time.Minute)))
+
+	signals = engine.OnTick(ctx, barStart.Add(2*time.Minute+time.Second))
+	require.Len(t, signals, 1)
+	assert.Equal(t, domain.Sell, signals[0].Signal)
+	assert.Nil(t, engine.bar)
+}
+
+func TestSignalEngineEmitsCrossoversMissedDuringFailover(t *testing.T) {
+	ctx := context.Background()
+	start := baseTime
+
+	history := []domain.Candle{
+		{Close: 10, Closed: true, OpenTime: start, CloseTime: start.Add(time.Minute)},
+		{Close: 9, Closed: true, OpenTime: start.Add(time.Minute), CloseTime: start.Add(2 * time.Minute)},
+		{Close: 12, Closed: true, OpenTime: start.Add(2 * time.Minute), CloseTime: start.Add(3 * time.Minute)},
+		{Close: 12, Closed: false, OpenTime: start.Add(3 * time.Minute), CloseTime: start.Add(4 * time.Minute)},
+	}
+
+	newEngine := func(candles []domain.Candle, emitter *SignalEmitter) *SignalEngine {
+		strategy, err := NewSMACrossover(1, 2)
+		require.NoError(t, err)
+
+		return NewSignalEngine(nil, &stubCandleRepository{candles: candles}, nil, emitter,
+			[]ports.Strategy{strategy}, "BTCUSDT", "1m", EngineConfig{})
+	}
+
+	// The previous leader ended its term in the SELL state of the second bar
+	previous, statusRepo := newTestEmitter(&stubSignalSink{}, time.Hour)
+	require.NoError(t, newEngine(history[:2], previous).Warmup(ctx, start.Add(2*time.Minute)))
+
+	for _, status := range previous.Statuses() {
+		require.NoError(t, statusRepo.SaveStatus(ctx, status))
+	}
+
+	// The third bar crossed over before the new leader took over
+	sink := &stubSignalSink{}
+	emitter := NewSignalEmitter(sink, statusRepo, nil, time.Hour)
+	require.NoError(t, emitter.Load(ctx))
+	require.NoError(t, newEngine(history, emitter).Warmup(ctx, start.Add(3*time.Minute+30*time.Second)))
+
+	require.Len(t, sink.saved, 1)
+	assert.Equal(t, domain.Buy, sink.saved[0].Signal)
+	assert.Equal(t, start.Add(3*time.Minute), sink.saved[0].Timestamp)
+
+	// A restart without a newer bar emits nothing
+	sink.saved = nil
+	require.NoError(t, newEngine(history, emitter).Warmup(ctx, start.Add(3*time.Minute+30*time.Second)))
+	assert.Empty(t, sink.saved)
+}
+
+// flakyCandleRepository fails the first failures candle lookups.
+type flakyCandleRepository struct {
+	stubCandleRepository
+
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (r *flakyCandleRepository) GetLatestCandles(
+	ctx context.Context,
+	symbol string,
+	timeframe domain.Timeframe,
+	limit int,
+) ([]domain.Candle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls++
+	if r.calls <= r.failures {
+		return nil, errStrategyFailed
+	}
+
+	return r.stubCandleRepository.GetLatestCandles(ctx, symbol, timeframe, limit)
+}
+
+func (r *flakyCandleRepository) attempts() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.calls
+}
+
+func TestSignalEngineRetriesWarmup(t *testing.T) {
+	strategy, err := NewSMACrossover(1, 2)
+	require.NoError(t, err)
+
+	emitter, _ := newTestEmitter(&stubSignalSink{}, time.Hour)
+	candles := &flakyCandleRepository{failures: 2}
+	engine := NewSignalEngine(
+		nil,
+		candles,
+		nil,
+		emitter,
+		[]ports.Strategy{strategy},
+		"BTCUSDT",
+		"1m",
+		EngineConfig{PollInterval: time.Hour, RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		engine.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return candles.attempts() == 3
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+
+	assert.Equal(t, 3, candles.attempts())
+}
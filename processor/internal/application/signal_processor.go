@@ -9,6 +9,7 @@ import (
 
 	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
 	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+	"github.com/mkaganm/algo-trade/processor/internal/indicators"
 )
 
 // Define static errors.
@@ -18,91 +19,119 @@ var (
 )
 
 type SignalProcessor struct {
-	orderBookRepo ports.OrderBookRepository
-	signalRepo    ports.SignalRepository
-	publisher     ports.SignalPublisher
+	candleRepo ports.CandleRepository
+	features   *BookFeatureService
+	emitter    *SignalEmitter
+	strategies []ports.Strategy
 }
 
 func NewSignalProcessor(
-	orderBookRepo ports.OrderBookRepository,
-	signalRepo ports.SignalRepository,
-	publisher ports.SignalPublisher,
+	candleRepo ports.CandleRepository,
+	features *BookFeatureService,
+	emitter *SignalEmitter,
+	strategies []ports.Strategy,
 ) *SignalProcessor {
 	return &SignalProcessor{
-		orderBookRepo: orderBookRepo,
-		signalRepo:    signalRepo,
-		publisher:     publisher,
+		candleRepo: candleRepo,
+		features:   features,
+		emitter:    emitter,
+		strategies: strategies,
 	}
 }
 
-func (s *SignalProcessor) CalculateSMAs(
-	prices []float64, shortPeriod,
-	longPeriod int,
-) (shortSMA, longSMA []float64, err error) {
-	if len(prices) < longPeriod {
-		return nil, nil, ErrNotEnoughDataPoints
+// GenerateSignals evaluates every configured strategy on the latest closed bars
+// of symbol at the given timeframe and returns the actionable signals. A
+// strategy that fails does not prevent the others from running.
+func (s *SignalProcessor) GenerateSignals(
+	ctx context.Context,
+	symbol string,
+	timeframe domain.Timeframe,
+) ([]domain.TradeSignal, error) {
+	history := 0
+	for _, strategy := range s.strategies {
+		history = max(history, strategy.RequiredHistory())
 	}
 
-	shortSMA = calculateSMA(prices, shortPeriod)
-	longSMA = calculateSMA(prices, longPeriod)
-
-	return shortSMA, longSMA, nil
-}
-
-func (s *SignalProcessor) GenerateSignal(
-	ctx context.Context,
-	shortPeriod int,
-	longPeriod int,
-) (*domain.TradeSignal, error) {
-	records, err := s.orderBookRepo.GetLatestRecords(ctx, longPeriod)
+	// One extra bar as the latest one may still be open
+	candles, err := s.candleRepo.GetLatestCandles(ctx, symbol, timeframe, history+1)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get order book records: %w", err)
+		return nil, fmt.Errorf("failed to get candles: %w", err)
 	}
 
-	if len(records) < longPeriod {
-		return nil, ErrNotEnoughData
+	series := closedSeries(candles)
+
+	if s.features != nil {
+		if err := s.features.Refresh(ctx, symbol, series); err != nil {
+			log.Printf("Failed to refresh book features: %v", err)
+		}
 	}
 
-	// Extract prices
-	var prices []float64
+	signals := make([]domain.TradeSignal, 0, len(s.strategies))
 
-	prices, err = extractPrices(records)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract prices: %w", err)
-	}
+	var errs []error
 
-	shortSMA, longSMA, err := s.CalculateSMAs(prices, shortPeriod, longPeriod)
-	if err != nil {
-		return nil, fmt.Errorf("failed to calculate SMAs: %w", err)
-	}
+	for _, strategy := range s.strategies {
+		signal, err := s.evaluate(strategy, series, symbol, timeframe)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("strategy %s: %w", strategy.Name(), err))
 
-	if len(shortSMA) == 0 || len(longSMA) == 0 {
-		return nil, ErrNotEnoughDataPoints
-	}
+			continue
+		}
 
-	lastShortSMA := shortSMA[len(shortSMA)-1]
-	lastLongSMA := longSMA[len(longSMA)-1]
+		if s.emitter.Emit(ctx, symbol, signal) {
+			signals = append(signals, signal)
+		}
+	}
 
-	signal := selectSignal(lastShortSMA, lastLongSMA)
+	return signals, errors.Join(errs...)
+}
 
-	tradeSignal := &domain.TradeSignal{
-		Signal:    signal,
-		ShortSMA:  lastShortSMA,
-		LongSMA:   lastLongSMA,
-		Timestamp: time.Now(),
+func (s *SignalProcessor) evaluate(
+	strategy ports.Strategy,
+	series domain.Series,
+	symbol string,
+	timeframe domain.Timeframe,
+) (domain.TradeSignal, error) {
+	if len(series) < strategy.RequiredHistory() || len(series) == 0 {
+		return domain.TradeSignal{}, ErrNotEnoughData
 	}
 
-	// Save to database
-	if err := s.signalRepo.SaveSignal(ctx, *tradeSignal); err != nil {
-		log.Printf("Failed to save signal to database: %v", err)
+	signal, err := strategy.Evaluate(series[len(series)-strategy.RequiredHistory():])
+	if err != nil {
+		return domain.TradeSignal{}, err
 	}
 
-	// Publish to Redis
-	if err := s.publisher.PublishSignal(ctx, *tradeSignal); err != nil {
-		log.Printf("Failed to publish signal: %v", err)
+	return newTradeSignal(strategy, signal, symbol, timeframe, series[len(series)-1], time.Now()), nil
+}
+
+// newTradeSignal tags signal with its strategy, market and the bar it was
+// generated on.
+func newTradeSignal(
+	strategy ports.Strategy,
+	signal domain.Signal,
+	symbol string,
+	timeframe domain.Timeframe,
+	bar domain.Candle,
+	now time.Time,
+) domain.TradeSignal {
+	params := strategy.Params()
+
+	tradeSignal := domain.TradeSignal{
+		Signal:     signal.Action,
+		Strength:   signal.Strength,
+		Symbol:     symbol,
+		Timeframe:  timeframe,
+		Price:      bar.Close,
+		Strategy:   strategy.Name(),
+		StrategyID: domain.StrategyID(strategy.Name(), params),
+		Params:     params,
+		Indicators: signal.Indicators,
+		BarTime:    bar.CloseTime,
+		Timestamp:  now,
 	}
+	tradeSignal.ID = domain.SignalID(tradeSignal)
 
-	return tradeSignal, nil
+	return tradeSignal
 }
 
 func selectSignal(lastShortSMA, lastLongSMA float64) string {
@@ -120,6 +149,19 @@ func selectSignal(lastShortSMA, lastLongSMA float64) string {
 	return signal
 }
 
+// closedSeries returns the closed bars, oldest first.
+func closedSeries(candles []domain.Candle) domain.Series {
+	series := make(domain.Series, 0, len(candles))
+
+	for _, candle := range candles {
+		if candle.Closed {
+			series = append(series, candle)
+		}
+	}
+
+	return series
+}
+
 func extractPrices(records []domain.OrderBookRecord) ([]float64, error) {
 	var prices []float64
 
@@ -141,21 +183,11 @@ func extractPrices(records []domain.OrderBookRecord) ([]float64, error) {
 	return prices, nil
 }
 
+// calculateSMA returns the SMA of every full window of prices.
 func calculateSMA(prices []float64, period int) []float64 {
 	if len(prices) < period {
 		return nil
 	}
 
-	sma := make([]float64, len(prices)-period+1)
-
-	for i := 0; i <= len(prices)-period; i++ {
-		sum := 0.0
-		for j := range period {
-			sum += prices[i+j]
-		}
-
-		sma[i] = sum / float64(period)
-	}
-
-	return sma
+	return indicators.ComputeSMA(prices, period)[period-1:]
 }
@@ -0,0 +1,125 @@
+package application
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+	"github.com/mkaganm/algo-trade/processor/internal/indicators"
+)
+
+const (
+	SMACrossoverName = "sma_crossover"
+	// fullStrengthSpread is the relative distance between the averages at which
+	// a signal reaches full strength.
+	fullStrengthSpread = 0.01
+	// fullStrengthSlope is the relative change of the short average over one
+	// bar at which a signal reaches full strength.
+	fullStrengthSlope = 0.002
+)
+
+// SMACrossover buys while the short SMA is above the long SMA and sells while it is below.
+type SMACrossover struct {
+	shortPeriod int
+	longPeriod  int
+	shortSMA    *indicators.SMA
+	longSMA     *indicators.SMA
+}
+
+func NewSMACrossover(shortPeriod, longPeriod int) (*SMACrossover, error) {
+	if shortPeriod <= 0 || longPeriod <= shortPeriod {
+		return nil, fmt.Errorf("%w: short %d, long %d", ErrInvalidStrategyParams, shortPeriod, longPeriod)
+	}
+
+	return &SMACrossover{
+		shortPeriod: shortPeriod,
+		longPeriod:  longPeriod,
+		shortSMA:    indicators.NewSMA(shortPeriod),
+		longSMA:     indicators.NewSMA(longPeriod),
+	}, nil
+}
+
+// NewSMACrossoverFromParams reads the "short" and "long" periods.
+func NewSMACrossoverFromParams(params map[string]float64) (ports.Strategy, error) {
+	return NewSMACrossover(int(params["short"]), int(params["long"]))
+}
+
+func (s *SMACrossover) Name() string {
+	return SMACrossoverName
+}
+
+func (s *SMACrossover) Params() map[string]float64 {
+	return map[string]float64{
+		"short": float64(s.shortPeriod),
+		"long":  float64(s.longPeriod),
+	}
+}
+
+func (s *SMACrossover) RequiredHistory() int {
+	return s.longPeriod
+}
+
+func (s *SMACrossover) Evaluate(series domain.Series) (domain.Signal, error) {
+	prices := series.Closes()
+	if len(prices) < s.longPeriod {
+		return domain.Signal{}, ErrNotEnoughDataPoints
+	}
+
+	prices = prices[len(prices)-s.longPeriod:]
+
+	shortSMA := calculateSMA(prices, s.shortPeriod)
+	longSMA := calculateSMA(prices, s.longPeriod)
+
+	lastShortSMA := shortSMA[len(shortSMA)-1]
+	lastLongSMA := longSMA[len(longSMA)-1]
+	previousShortSMA := shortSMA[len(shortSMA)-2]
+
+	return crossoverSignal(lastShortSMA, lastLongSMA, previousShortSMA), nil
+}
+
+// Update advances the rolling averages by one bar in O(1).
+func (s *SMACrossover) Update(candle domain.Candle) (domain.Signal, bool) {
+	previousShortSMA := s.shortSMA.Value()
+	shortSMA := s.shortSMA.Update(candle.Close)
+	longSMA := s.longSMA.Update(candle.Close)
+
+	if !s.longSMA.Ready() {
+		return domain.Signal{}, false
+	}
+
+	return crossoverSignal(shortSMA, longSMA, previousShortSMA), true
+}
+
+// crossoverSignal scales the strength with the distance between the averages
+// or, if larger, with how fast the short one moved in the direction of the
+// signal over the last bar, both relative to the long one. The distance is
+// close to zero right at the cross, while the slope is not.
+func crossoverSignal(shortSMA, longSMA, previousShortSMA float64) domain.Signal {
+	action := selectSignal(shortSMA, longSMA)
+
+	slope := shortSMA - previousShortSMA
+
+	switch action {
+	case domain.Sell:
+		slope = -slope
+	case domain.Neutral:
+		slope = 0
+	}
+
+	var strength float64
+	if longSMA != 0 {
+		distance := math.Abs(shortSMA-longSMA) / math.Abs(longSMA) / fullStrengthSpread
+		momentum := slope / math.Abs(longSMA) / fullStrengthSlope
+		strength = min(max(distance, momentum), 1)
+	}
+
+	return domain.Signal{
+		Action:   action,
+		Strength: strength,
+		Indicators: map[string]float64{
+			"shortSMA": shortSMA,
+			"longSMA":  longSMA,
+		},
+	}
+}
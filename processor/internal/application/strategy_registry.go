@@ -0,0 +1,67 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+var (
+	ErrUnknownStrategy       = errors.New("unknown strategy")
+	ErrInvalidStrategyParams = errors.New("invalid strategy parameters")
+)
+
+// StrategyFactory creates a strategy from its parameters.
+type StrategyFactory func(params map[string]float64) (ports.Strategy, error)
+
+// StrategyRegistry maps strategy names to their factories.
+type StrategyRegistry struct {
+	factories map[string]StrategyFactory
+}
+
+func NewStrategyRegistry() *StrategyRegistry {
+	return &StrategyRegistry{factories: make(map[string]StrategyFactory)}
+}
+
+// DefaultStrategyRegistry returns a registry with the built-in strategies.
+func DefaultStrategyRegistry() *StrategyRegistry {
+	registry := NewStrategyRegistry()
+	registry.Register(SMACrossoverName, NewSMACrossoverFromParams)
+	registry.Register(BookImbalanceName, NewBookImbalanceFromParams)
+
+	return registry
+}
+
+// Register adds a factory, replacing any factory registered under the same name.
+func (r *StrategyRegistry) Register(name string, factory StrategyFactory) {
+	r.factories[name] = factory
+}
+
+// New creates the named strategy.
+func (r *StrategyRegistry) New(name string, params map[string]float64) (ports.Strategy, error) {
+	factory, ok := r.factories[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, name)
+	}
+
+	strategy, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create strategy %q: %w", name, err)
+	}
+
+	return strategy, nil
+}
+
+// Names returns the registered strategy names in alphabetical order.
+func (r *StrategyRegistry) Names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,217 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errStrategyFailed = errors.New("strategy failed")
+
+type stubCandleRepository struct {
+	candles []domain.Candle
+	limit   int
+}
+
+func (r *stubCandleRepository) SaveCandles(_ context.Context, _ []domain.Candle) error {
+	return nil
+}
+
+func (r *stubCandleRepository) GetCandles(
+	_ context.Context,
+	_ string,
+	_ domain.Timeframe,
+	_, _ time.Time,
+) ([]domain.Candle, error) {
+	return r.candles, nil
+}
+
+func (r *stubCandleRepository) GetLatestCandles(
+	_ context.Context,
+	_ string,
+	_ domain.Timeframe,
+	limit int,
+) ([]domain.Candle, error) {
+	r.limit = limit
+
+	return r.candles[max(0, len(r.candles)-limit):], nil
+}
+
+type stubSignalSink struct {
+	saved     []domain.TradeSignal
+	published []domain.TradeSignal
+	saveErr   error
+}
+
+func (s *stubSignalSink) SaveSignal(_ context.Context, signal domain.TradeSignal) error {
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+
+	s.saved = append(s.saved, signal)
+
+	return nil
+}
+
+func (s *stubSignalSink) PublishSignal(_ context.Context, signal domain.TradeSignal) error {
+	s.published = append(s.published, signal)
+
+	return nil
+}
+
+type stubStatusRepository struct {
+	statuses map[string]domain.SignalStatus
+}
+
+func (r *stubStatusRepository) SaveStatus(_ context.Context, status domain.SignalStatus) error {
+	r.statuses[statusKey(status.Symbol, status.Timeframe, status.StrategyID)] = status
+
+	return nil
+}
+
+func (r *stubStatusRepository) GetStatuses(_ context.Context) ([]domain.SignalStatus, error) {
+	statuses := make([]domain.SignalStatus, 0, len(r.statuses))
+	for _, status := range r.statuses {
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func newTestEmitter(sink *stubSignalSink, window time.Duration) (*SignalEmitter, *stubStatusRepository) {
+	statusRepo := &stubStatusRepository{statuses: map[string]domain.SignalStatus{}}
+
+	return NewSignalEmitter(sink, statusRepo, nil, window), statusRepo
+}
+
+type failingStrategy struct{}
+
+func (failingStrategy) Name() string               { return "failing" }
+func (failingStrategy) Params() map[string]float64 { return nil }
+func (failingStrategy) RequiredHistory() int       { return 1 }
+
+func (failingStrategy) Evaluate(_ domain.Series) (domain.Signal, error) {
+	return domain.Signal{}, errStrategyFailed
+}
+
+func closedCandles(closes ...float64) []domain.Candle {
+	candles := make([]domain.Candle, len(closes))
+	for i, price := range closes {
+		candles[i] = domain.Candle{Close: price, Closed: true}
+	}
+
+	return candles
+}
+
+func TestStrategyRegistryCreatesRegisteredStrategies(t *testing.T) {
+	registry := DefaultStrategyRegistry()
+
+	strategy, err := registry.New(SMACrossoverName, map[string]float64{"short": 2, "long": 4})
+	require.NoError(t, err)
+	assert.Equal(t, SMACrossoverName, strategy.Name())
+	assert.Equal(t, 4, strategy.RequiredHistory())
+	assert.Equal(t, map[string]float64{"short": 2, "long": 4}, strategy.Params())
+
+	_, err = registry.New("unknown", nil)
+	require.ErrorIs(t, err, ErrUnknownStrategy)
+
+	_, err = registry.New(SMACrossoverName, map[string]float64{"short": 4, "long": 2})
+	require.ErrorIs(t, err, ErrInvalidStrategyParams)
+}
+
+func TestSMACrossoverEvaluate(t *testing.T) {
+	strategy, err := NewSMACrossover(2, 4)
+	require.NoError(t, err)
+
+	signal, err := strategy.Evaluate(closedCandles(1, 2, 3, 4, 5))
+	require.NoError(t, err)
+	assert.Equal(t, domain.Buy, signal.Action)
+	assert.InDelta(t, 4.5, signal.Indicators["shortSMA"], 0)
+	assert.InDelta(t, 3.5, signal.Indicators["longSMA"], 0)
+	assert.InDelta(t, 1.0, signal.Strength, 0)
+
+	// The averages are 0.1% apart and the short one is flat, a tenth of the
+	// full strength spread.
+	signal, err = strategy.Evaluate(closedCandles(99.7, 100.1, 100.1, 100.1))
+	require.NoError(t, err)
+	assert.Equal(t, domain.Buy, signal.Action)
+	assert.InDelta(t, 0.1/100/fullStrengthSpread, signal.Strength, 1e-9)
+
+	_, err = strategy.Evaluate(closedCandles(1, 2, 3))
+	assert.ErrorIs(t, err, ErrNotEnoughDataPoints)
+}
+
+func TestSMACrossoverStrengthAtTheCross(t *testing.T) {
+	strategy, err := NewSMACrossover(2, 4)
+	require.NoError(t, err)
+
+	closes := []float64{100, 100, 100, 99.9, 100.12}
+
+	var signals []domain.Signal
+
+	for _, candle := range closedCandles(closes...) {
+		if signal, ok := strategy.Update(candle); ok {
+			signals = append(signals, signal)
+		}
+	}
+
+	require.Len(t, signals, 2)
+	assert.Equal(t, domain.Sell, signals[0].Action)
+
+	// The short average just crossed above the long one, 0.005% apart
+	cross := signals[1]
+	assert.Equal(t, domain.Buy, cross.Action)
+	assert.InDelta(t, 0.005, cross.Indicators["shortSMA"]-cross.Indicators["longSMA"], 1e-9)
+	assert.InDelta(t, 0.06/100.005/fullStrengthSlope, cross.Strength, 1e-9)
+	assert.Greater(t, cross.Strength, 0.1)
+}
+
+func TestGenerateSignalsRunsEveryStrategy(t *testing.T) {
+	fast, err := NewSMACrossover(1, 2)
+	require.NoError(t, err)
+
+	slow, err := NewSMACrossover(2, 5)
+	require.NoError(t, err)
+
+	repo := &stubCandleRepository{candles: append(closedCandles(5, 4, 3, 2, 1), domain.Candle{Close: 10})}
+	sink := &stubSignalSink{}
+	emitter, statusRepo := newTestEmitter(sink, time.Hour)
+
+	processor := NewSignalProcessor(repo, nil, emitter, []ports.Strategy{fast, slow, failingStrategy{}})
+
+	// The first evaluation only establishes the state of each strategy.
+	signals, err := processor.GenerateSignals(context.Background(), "BTCUSDT", "1m")
+	require.ErrorIs(t, err, errStrategyFailed)
+	assert.Empty(t, signals)
+	assert.Len(t, statusRepo.statuses, 2)
+
+	// The longest history plus the possibly open bar is requested once.
+	assert.Equal(t, 6, repo.limit)
+
+	repo.candles = closedCandles(1, 2, 3, 4, 10)
+
+	signals, err = processor.GenerateSignals(context.Background(), "BTCUSDT", "1m")
+	require.ErrorIs(t, err, errStrategyFailed)
+
+	require.Len(t, signals, 2)
+	assert.Equal(t, SMACrossoverName, signals[0].Strategy)
+	assert.Equal(t, domain.Buy, signals[0].Signal)
+	assert.InDelta(t, 10.0, signals[0].Indicators["shortSMA"], 0)
+	assert.Equal(t, "BTCUSDT", signals[0].Symbol)
+	assert.Equal(t, domain.Timeframe("1m"), signals[0].Timeframe)
+	assert.InDelta(t, 10.0, signals[0].Price, 0)
+	assert.Equal(t, "sma_crossover(long=2,short=1)", signals[0].StrategyID)
+	assert.Equal(t, map[string]float64{"short": 2, "long": 5}, signals[1].Params)
+	assert.Equal(t, signals, sink.saved)
+
+	// The trend continues, nothing new to act on.
+	signals, err = processor.GenerateSignals(context.Background(), "BTCUSDT", "1m")
+	require.ErrorIs(t, err, errStrategyFailed)
+	assert.Empty(t, signals)
+}
@@ -0,0 +1,39 @@
+package application
+
+import (
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+// AsStreaming returns strategy itself if it keeps rolling state, otherwise it
+// wraps it so that Evaluate runs on a sliding window of the last bars.
+func AsStreaming(strategy ports.Strategy) ports.StreamingStrategy {
+	if streaming, ok := strategy.(ports.StreamingStrategy); ok {
+		return streaming
+	}
+
+	return &windowedStrategy{Strategy: strategy}
+}
+
+type windowedStrategy struct {
+	ports.Strategy
+	series domain.Series
+}
+
+func (w *windowedStrategy) Update(candle domain.Candle) (domain.Signal, bool) {
+	w.series = append(w.series, candle)
+	if extra := len(w.series) - w.RequiredHistory(); extra > 0 {
+		w.series = append(w.series[:0], w.series[extra:]...)
+	}
+
+	if len(w.series) < w.RequiredHistory() {
+		return domain.Signal{}, false
+	}
+
+	signal, err := w.Evaluate(w.series)
+	if err != nil {
+		return domain.Signal{}, false
+	}
+
+	return signal, true
+}
@@ -0,0 +1,97 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/mkaganm/algo-trade/processor/internal/core/ports"
+)
+
+// TrendFilter gates the entries of lower timeframes on the trend of a higher
+// one: a BUY only passes while the strategy on the higher timeframe says BUY.
+// SELL signals exit positions and always pass, as do signals of the filter
+// timeframe or above.
+type TrendFilter struct {
+	candleRepo ports.CandleRepository
+	timeframe  domain.Timeframe
+	strategy   ports.Strategy
+
+	mu     sync.Mutex
+	trends map[string]trend
+}
+
+// trend is the state of the filter strategy on one symbol as of a bar.
+type trend struct {
+	bar    time.Time
+	action string
+}
+
+func NewTrendFilter(
+	candleRepo ports.CandleRepository,
+	timeframe domain.Timeframe,
+	strategy ports.Strategy,
+) *TrendFilter {
+	return &TrendFilter{
+		candleRepo: candleRepo,
+		timeframe:  timeframe,
+		strategy:   strategy,
+		trends:     make(map[string]trend),
+	}
+}
+
+// Allow implements ports.SignalGate.
+func (f *TrendFilter) Allow(ctx context.Context, signal domain.TradeSignal) (bool, error) {
+	if signal.Signal != domain.Buy || signal.Timeframe.Duration() >= f.timeframe.Duration() {
+		return true, nil
+	}
+
+	action, err := f.Trend(ctx, signal.Symbol, signal.Timestamp)
+	if err != nil {
+		return false, err
+	}
+
+	return action == domain.Buy, nil
+}
+
+// Trend evaluates the filter strategy on the bars of symbol closed by at. The
+// result is cached until the next bar of the filter timeframe starts.
+func (f *TrendFilter) Trend(ctx context.Context, symbol string, at time.Time) (string, error) {
+	bar := at.Truncate(f.timeframe.Duration())
+
+	f.mu.Lock()
+	cached, ok := f.trends[symbol]
+	f.mu.Unlock()
+
+	if ok && cached.bar.Equal(bar) {
+		return cached.action, nil
+	}
+
+	history := f.strategy.RequiredHistory()
+
+	// One extra bar as the latest one may still be open
+	from := bar.Add(-time.Duration(history+1) * f.timeframe.Duration())
+
+	candles, err := f.candleRepo.GetCandles(ctx, symbol, f.timeframe, from, bar)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s candles: %w", f.timeframe, err)
+	}
+
+	series := closedSeries(candles)
+	if len(series) < history || len(series) == 0 {
+		return "", fmt.Errorf("%s trend of %s: %w", f.timeframe, symbol, ErrNotEnoughData)
+	}
+
+	signal, err := f.strategy.Evaluate(series[len(series)-history:])
+	if err != nil {
+		return "", fmt.Errorf("%s trend of %s: %w", f.timeframe, symbol, err)
+	}
+
+	f.mu.Lock()
+	f.trends[symbol] = trend{bar: bar, action: signal.Action}
+	f.mu.Unlock()
+
+	return signal.Action, nil
+}
@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTrendFilter(t *testing.T, closes ...float64) *TrendFilter {
+	t.Helper()
+
+	strategy, err := NewSMACrossover(2, 3)
+	require.NoError(t, err)
+
+	return NewTrendFilter(&stubCandleRepository{candles: closedCandles(closes...)}, "1h", strategy)
+}
+
+func TestTrendFilterGatesLowerTimeframes(t *testing.T) {
+	ctx := context.Background()
+	filter := newTestTrendFilter(t, 4, 3, 2, 1) // downtrend
+
+	steps := []struct {
+		action    string
+		timeframe domain.Timeframe
+		allowed   bool
+	}{
+		{action: domain.Buy, timeframe: "1m", allowed: false}, // against the trend
+		{action: domain.Sell, timeframe: "1m", allowed: true}, // exits always pass
+		{action: domain.Buy, timeframe: "1h", allowed: true},  // not a lower timeframe
+		{action: domain.Buy, timeframe: "1d", allowed: true},
+	}
+
+	for i, step := range steps {
+		signal := domain.TradeSignal{Signal: step.action, Symbol: "BTCUSDT", Timeframe: step.timeframe, Timestamp: baseTime}
+
+		allowed, err := filter.Allow(ctx, signal)
+		require.NoError(t, err)
+		assert.Equalf(t, step.allowed, allowed, "step %d", i)
+	}
+}
+
+func TestTrendFilterBlocksWithoutHistory(t *testing.T) {
+	filter := newTestTrendFilter(t, 1, 2)
+
+	allowed, err := filter.Allow(context.Background(), domain.TradeSignal{
+		Signal:    domain.Buy,
+		Symbol:    "BTCUSDT",
+		Timeframe: "1m",
+		Timestamp: baseTime,
+	})
+	require.ErrorIs(t, err, ErrNotEnoughData)
+	assert.False(t, allowed)
+}
+
+func TestSignalEmitterRetriesGatedTransitions(t *testing.T) {
+	ctx := context.Background()
+	sink := &stubSignalSink{}
+	statusRepo := &stubStatusRepository{statuses: map[string]domain.SignalStatus{}}
+	candleRepo := &stubCandleRepository{candles: closedCandles(4, 3, 2, 1)} // downtrend
+
+	strategy, err := NewSMACrossover(2, 3)
+	require.NoError(t, err)
+
+	emitter := NewSignalEmitter(sink, statusRepo, NewTrendFilter(candleRepo, "1h", strategy), time.Minute)
+
+	emitter.Emit(ctx, "BTCUSDT", emitterSignal(domain.Sell, baseTime))
+	assert.False(t, emitter.Emit(ctx, "BTCUSDT", emitterSignal(domain.Buy, baseTime.Add(time.Minute))))
+
+	// The gated crossover leaves the state as it was
+	assert.Equal(t, domain.Sell, emitter.Statuses()[0].State)
+
+	// Once the trend turns up the next bar still crosses over
+	candleRepo.candles = closedCandles(1, 2, 3, 4)
+	assert.True(t, emitter.Emit(ctx, "BTCUSDT", emitterSignal(domain.Buy, baseTime.Add(time.Hour))))
+
+	require.Len(t, sink.saved, 1)
+	assert.Equal(t, domain.Buy, emitter.Statuses()[0].State)
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseParamSpace(t *testing.T) {
+	space, err := ParseParamSpace("short=5:20:5, long=50|100|200")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]float64{
+		"short": {5, 10, 15, 20},
+		"long":  {50, 100, 200},
+	}, space)
+
+	space, err = ParseParamSpace("threshold=0.1:0.3:0.1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]float64{"threshold": {0.1, 0.2, 0.3}}, space)
+
+	_, err = ParseParamSpace("short=20:5:5")
+	require.ErrorIs(t, err, ErrInvalidParamSpace)
+
+	_, err = ParseParamSpace("short=a|b")
+	require.ErrorIs(t, err, ErrInvalidParamSpace)
+}
+
+func TestFormatStrategyRoundTrips(t *testing.T) {
+	strategy := StrategyConfig{Name: "sma_crossover", Params: map[string]float64{"short": 20, "long": 150}}
+
+	formatted := FormatStrategy(strategy)
+	assert.Equal(t, "sma_crossover:long=150,short=20", formatted)
+	assert.Equal(t, []StrategyConfig{strategy}, ParseStrategies(formatted))
+}
+
+func TestParseSignalTargets(t *testing.T) {
+	targets := parseSignalTargets("btcusdt/1m/sma_crossover:short=5,long=20; ETHUSDT/1h/book_imbalance:threshold=0.3")
+	assert.Equal(t, []SignalTarget{
+		{Symbol: "BTCUSDT", Timeframe: "1m", Strategy: StrategyConfig{
+			Name: "sma_crossover", Params: map[string]float64{"short": 5, "long": 20},
+		}},
+		{Symbol: "ETHUSDT", Timeframe: "1h", Strategy: StrategyConfig{
+			Name: "book_imbalance", Params: map[string]float64{"threshold": 0.3},
+		}},
+	}, targets)
+
+	strategies := []StrategyConfig{targets[0].Strategy, targets[1].Strategy}
+	assert.Len(t, crossTargets([]string{"BTCUSDT", "ETHUSDT"}, []domain.Timeframe{"1m", "5m"}, strategies), 8)
+}
+
+func TestParseTrendFilter(t *testing.T) {
+	filter := parseTrendFilter("1h:sma_crossover:short=20,long=50")
+	require.NotNil(t, filter)
+	assert.Equal(t, domain.Timeframe("1h"), filter.Timeframe)
+	assert.Equal(t, "sma_crossover", filter.Strategy.Name)
+	assert.Equal(t, map[string]float64{"short": 20, "long": 50}, filter.Strategy.Params)
+
+	assert.Nil(t, parseTrendFilter(""))
+	assert.Nil(t, parseTrendFilter("2h:sma_crossover:short=20,long=50"))
+}
+
+func TestWriteEnvValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("SYMBOL=BTCUSDT\nSTRATEGIES=sma_crossover:short=50,long=200\n"), 0o600))
+
+	require.NoError(t, WriteEnvValue(path, "STRATEGIES", "sma_crossover:long=150,short=20"))
+	require.NoError(t, WriteEnvValue(path, "SIGNAL_MODE", "stream"))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "SYMBOL=BTCUSDT\nSTRATEGIES=sma_crossover:long=150,short=20\nSIGNAL_MODE=stream\n", string(content))
+}
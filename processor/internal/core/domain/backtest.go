@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// Position sides of a backtest trade.
+const (
+	Long  = "LONG"
+	Short = "SHORT"
+)
+
+// BacktestTrade is a simulated round trip. Prices include slippage, Fees are
+// the entry and exit fees and PnL is net of them. Return is PnL relative to
+// the entry notional.
+type BacktestTrade struct {
+	Side       string    `json:"side"`
+	EntryTime  time.Time `json:"entryTime"`
+	EntryPrice float64   `json:"entryPrice"`
+	ExitTime   time.Time `json:"exitTime"`
+	ExitPrice  float64   `json:"exitPrice"`
+	Quantity   float64   `json:"quantity"`
+	Fees       float64   `json:"fees"`
+	PnL        float64   `json:"pnl"`
+	Return     float64   `json:"return"`
+}
+
+// EquityPoint is the marked-to-market equity at the close of a bar.
+type EquityPoint struct {
+	Time     time.Time `json:"time"`
+	Equity   float64   `json:"equity"`
+	Position float64   `json:"position"`
+}
+
+// BacktestMetrics summarizes a backtest. Sharpe and Sortino are annualized
+// from bar returns, MaxDrawdown, WinRate and Exposure are fractions.
+type BacktestMetrics struct {
+	InitialCapital float64 `json:"initialCapital"`
+	FinalEquity    float64 `json:"finalEquity"`
+	TotalReturn    float64 `json:"totalReturn"`
+	Sharpe         float64 `json:"sharpe"`
+	Sortino        float64 `json:"sortino"`
+	MaxDrawdown    float64 `json:"maxDrawdown"`
+	WinRate        float64 `json:"winRate"`
+	Exposure       float64 `json:"exposure"`
+	Trades         int     `json:"trades"`
+}
+
+// BacktestResult is the outcome of replaying history through a strategy.
+type BacktestResult struct {
+	Symbol     string          `json:"symbol"`
+	Timeframe  Timeframe       `json:"timeframe"`
+	StrategyID string          `json:"strategyId"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	Metrics    BacktestMetrics `json:"metrics"`
+	Trades     []BacktestTrade `json:"trades"`
+	Equity     []EquityPoint   `json:"equity"`
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// Book feature names, as attached to candles for strategies.
+const (
+	FeatureMidPrice   = "midPrice"
+	FeatureMicroprice = "microprice"
+	FeatureSpread     = "spread"
+	FeatureImbalance  = "imbalance"
+	FeatureOFI        = "ofi"
+	FeaturePressure   = "pressure"
+)
+
+// PriceLevel is a single aggregated price level of an order book side.
+type PriceLevel struct {
+	Price    float64 `bson:"price"    json:"price"`
+	Quantity float64 `bson:"quantity" json:"quantity"`
+}
+
+// BookSnapshot is a top-N view of the local order book the collector
+// reconstructs from the depth stream, best levels first.
+type BookSnapshot struct {
+	Symbol       string       `bson:"symbol"`
+	LastUpdateID int64        `bson:"lastUpdateId"`
+	Bids         []PriceLevel `bson:"bids"`
+	Asks         []PriceLevel `bson:"asks"`
+	Timestamp    time.Time    `bson:"timestamp"`
+}
+
+// BookFeatures are the microstructure features of one book snapshot.
+// Imbalance and Pressure are in [-1, 1], positive when bids outweigh asks.
+// OFI is the order flow imbalance since the previous snapshot in base units.
+type BookFeatures struct {
+	Symbol     string    `bson:"symbol"     json:"symbol"`
+	Timestamp  time.Time `bson:"timestamp"  json:"timestamp"`
+	MidPrice   float64   `bson:"midPrice"   json:"midPrice"`
+	Microprice float64   `bson:"microprice" json:"microprice"`
+	Spread     float64   `bson:"spread"     json:"spread"`
+	Imbalance  float64   `bson:"imbalance"  json:"imbalance"`
+	OFI        float64   `bson:"ofi"        json:"ofi"`
+	Pressure   float64   `bson:"pressure"   json:"pressure"`
+}
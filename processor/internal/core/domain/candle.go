@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrUnsupportedTimeframe = errors.New("unsupported timeframe")
+
+// Timeframe is a candle interval such as "1m" or "1h".
+type Timeframe string
+
+var timeframeDurations = map[Timeframe]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// ParseTimeframe validates a timeframe string.
+func ParseTimeframe(value string) (Timeframe, error) {
+	timeframe := Timeframe(value)
+	if _, ok := timeframeDurations[timeframe]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedTimeframe, value)
+	}
+
+	return timeframe, nil
+}
+
+// Duration returns the length of one bar.
+func (t Timeframe) Duration() time.Duration {
+	return timeframeDurations[t]
+}
+
+// Trade is an executed trade stored by the collector.
+type Trade struct {
+	Symbol      string    `bson:"symbol"`
+	TradeID     int64     `bson:"tradeId"`
+	Price       float64   `bson:"price"`
+	Quantity    float64   `bson:"quantity"`
+	QuoteVolume float64   `bson:"quoteVolume"`
+	BuyerMaker  bool      `bson:"buyerMaker"`
+	TradeTime   time.Time `bson:"tradeTime"`
+}
+
+// Candle is an OHLCV bar. Closed is false while the bar is still being built.
+// Features holds the order book features of the bar when they are available;
+// they are stored in their own collection, not with the candle.
+type Candle struct {
+	Symbol      string    `bson:"symbol"      json:"symbol"`
+	Timeframe   Timeframe `bson:"timeframe"   json:"timeframe"`
+	OpenTime    time.Time `bson:"openTime"    json:"openTime"`
+	CloseTime   time.Time `bson:"closeTime"   json:"closeTime"`
+	Open        float64   `bson:"open"        json:"open"`
+	High        float64   `bson:"high"        json:"high"`
+	Low         float64   `bson:"low"         json:"low"`
+	Close       float64   `bson:"close"       json:"close"`
+	Volume      float64   `bson:"volume"      json:"volume"`
+	QuoteVolume float64   `bson:"quoteVolume" json:"quoteVolume"`
+	Trades      int64     `bson:"trades"      json:"trades"`
+	Closed      bool      `bson:"closed"      json:"closed"`
+
+	Features map[string]float64 `bson:"-" json:"features,omitempty"`
+}
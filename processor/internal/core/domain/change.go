@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrChangeStreamUnsupported = errors.New("change streams are not supported by the database")
+	ErrResumeTokenLost         = errors.New("resume token is no longer available")
+)
+
+// ChangeEvent is a new or updated document in a watched collection: a closed
+// candle, or a depth update which has no timeframe.
+type ChangeEvent struct {
+	Symbol      string
+	Timeframe   Timeframe
+	Time        time.Time
+	ResumeToken []byte
+}
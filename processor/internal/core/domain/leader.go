@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrStaleFencingToken = errors.New("fencing token is stale, leadership was lost")
+
+type fencingTokenKey struct{}
+
+// WithFencingToken returns a context carrying the fencing token of the
+// current leadership term, checked by fenced writes.
+func WithFencingToken(ctx context.Context, token int64) context.Context {
+	return context.WithValue(ctx, fencingTokenKey{}, token)
+}
+
+// FencingToken returns the fencing token of ctx, if any.
+func FencingToken(ctx context.Context) (int64, bool) {
+	token, ok := ctx.Value(fencingTokenKey{}).(int64)
+
+	return token, ok
+}
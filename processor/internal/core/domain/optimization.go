@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// OptimizationRun is the backtest of one parameter set, scored by the
+// objective of the optimization.
+type OptimizationRun struct {
+	Params     map[string]float64 `json:"params"`
+	StrategyID string             `json:"strategyId"`
+	Score      float64            `json:"score"`
+	Metrics    BacktestMetrics    `json:"metrics"`
+}
+
+// WalkForwardFold is one in-sample/out-of-sample split: the best parameters
+// of the in-sample window and how they did on the following window.
+type WalkForwardFold struct {
+	InSampleFrom     time.Time       `json:"inSampleFrom"`
+	InSampleTo       time.Time       `json:"inSampleTo"`
+	OutOfSampleFrom  time.Time       `json:"outOfSampleFrom"`
+	OutOfSampleTo    time.Time       `json:"outOfSampleTo"`
+	InSample         OptimizationRun `json:"inSample"`
+	OutOfSample      BacktestMetrics `json:"outOfSample"`
+	OutOfSampleScore float64         `json:"outOfSampleScore"`
+}
+
+// OptimizationResult ranks the parameter sets of a strategy on the whole
+// history, best first. Folds hold the walk-forward validation, if any; with
+// folds Best is the selection of the latest fold rather than the top run.
+type OptimizationResult struct {
+	Strategy         string             `json:"strategy"`
+	Symbol           string             `json:"symbol"`
+	Timeframe        Timeframe          `json:"timeframe"`
+	Objective        string             `json:"objective"`
+	Best             map[string]float64 `json:"best"`
+	Runs             []OptimizationRun  `json:"runs"`
+	Folds            []WalkForwardFold  `json:"folds,omitempty"`
+	OutOfSampleScore float64            `json:"outOfSampleScore"`
+}
@@ -1,6 +1,13 @@
 package domain
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
 
 const (
 	Buy     = "BUY"
@@ -8,9 +15,73 @@ const (
 	Neutral = "NEUTRAL"
 )
 
+// TradeSignal is a signal emitted by a strategy on a symbol and timeframe,
+// tagged with the strategy that produced it. Strength is a normalized [0, 1]
+// confidence the trader can size positions by, Price the close of the bar the
+// signal was generated on, BarTime its close time, and Indicators the values
+// that justified it. ID is stable across deliveries and evaluations of the same
+// bar so consumers can drop duplicates.
 type TradeSignal struct {
-	Signal    string    `bson:"signal"    json:"signal"`
-	ShortSMA  float64   `bson:"shortSMA"  json:"shortSMA"`
-	LongSMA   float64   `bson:"longSMA"   json:"longSMA"`
-	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
+	ID         string             `bson:"_id"        json:"id"`
+	Signal     string             `bson:"signal"     json:"signal"`
+	Strength   float64            `bson:"strength"   json:"strength"`
+	Symbol     string             `bson:"symbol"     json:"symbol"`
+	Timeframe  Timeframe          `bson:"timeframe"  json:"timeframe"`
+	Price      float64            `bson:"price"      json:"price"`
+	Strategy   string             `bson:"strategy"   json:"strategy"`
+	StrategyID string             `bson:"strategyId" json:"strategyId"`
+	Params     map[string]float64 `bson:"params"     json:"params"`
+	Indicators map[string]float64 `bson:"indicators" json:"indicators"`
+	BarTime    time.Time          `bson:"barTime"    json:"barTime"`
+	Timestamp  time.Time          `bson:"timestamp"  json:"timestamp"`
+}
+
+// OutboxSignal is a stored signal waiting to be published with the fencing
+// token of the leadership term that stored it, zero without one.
+type OutboxSignal struct {
+	Signal       TradeSignal
+	FencingToken int64
+}
+
+// SignalStatus is the steady state of a strategy on a symbol: the latest
+// evaluated signal, since when it holds and the last actionable signal emitted.
+type SignalStatus struct {
+	Symbol       string             `bson:"symbol"       json:"symbol"`
+	Timeframe    Timeframe          `bson:"timeframe"    json:"timeframe"`
+	StrategyID   string             `bson:"strategyId"   json:"strategyId"`
+	State        string             `bson:"state"        json:"state"`
+	Since        time.Time          `bson:"since"        json:"since"`
+	UpdatedAt    time.Time          `bson:"updatedAt"    json:"updatedAt"`
+	Indicators   map[string]float64 `bson:"indicators"   json:"indicators"`
+	LastSignal   string             `bson:"lastSignal"   json:"lastSignal"`
+	LastSignalAt time.Time          `bson:"lastSignalAt" json:"lastSignalAt"`
+}
+
+// signalIDLength is the number of hex digits of a signal ID.
+const signalIDLength = 32
+
+// SignalID derives the ID of a signal from the strategy, market, action and
+// bar, so evaluating the same bar again yields the same ID.
+func SignalID(signal TradeSignal) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%s|%s|%d",
+		signal.Symbol, signal.Timeframe, signal.StrategyID, signal.Signal, signal.BarTime.UnixNano()))
+
+	return hex.EncodeToString(sum[:])[:signalIDLength]
+}
+
+// StrategyID identifies a strategy configuration, e.g. "sma_crossover(long=200,short=50)".
+func StrategyID(name string, params map[string]float64) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s=%g", key, params[key])
+	}
+
+	return fmt.Sprintf("%s(%s)", name, strings.Join(parts, ","))
 }
@@ -0,0 +1,22 @@
+package domain
+
+// Series is a sequence of closed candles, oldest first, that a strategy evaluates.
+type Series []Candle
+
+// Closes returns the close prices of the series.
+func (s Series) Closes() []float64 {
+	closes := make([]float64, len(s))
+	for i, candle := range s {
+		closes[i] = candle.Close
+	}
+
+	return closes
+}
+
+// Signal is the decision of a strategy together with the indicator values it
+// was based on. Strength is normalized to [0, 1], 0 for a neutral decision.
+type Signal struct {
+	Action     string
+	Strength   float64
+	Indicators map[string]float64
+}
@@ -2,21 +2,101 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
 )
 
 // OrderBookRepository is the secondary port (interface) for order book data access.
 type OrderBookRepository interface {
-	GetLatestRecords(ctx context.Context, limit int) ([]domain.OrderBookRecord, error)
+	GetLatestRecords(ctx context.Context, symbol string, limit int) ([]domain.OrderBookRecord, error)
 }
 
-// SignalRepository is the secondary port (interface) for signal storage.
+// SignalRepository is the secondary port (interface) for signal storage. Saved
+// signals wait in the outbox until they are published.
 type SignalRepository interface {
 	SaveSignal(ctx context.Context, signal domain.TradeSignal) error
 }
 
+// SignalOutbox is the secondary port (interface) for stored signals that are not published yet.
+type SignalOutbox interface {
+	GetUnpublished(ctx context.Context, limit int) ([]domain.OutboxSignal, error)
+	MarkPublished(ctx context.Context, id string, at time.Time) error
+	// MarkDiscarded takes a signal that must never be published out of the outbox.
+	MarkDiscarded(ctx context.Context, id string, at time.Time) error
+	// AdoptUnpublished moves the unpublished signals of older leadership
+	// terms to the term of token.
+	AdoptUnpublished(ctx context.Context, token int64) error
+}
+
 // SignalPublisher is the secondary port (interface) for publishing signals.
 type SignalPublisher interface {
 	PublishSignal(ctx context.Context, signal domain.TradeSignal) error
 }
+
+// SignalGate decides whether an actionable signal may be emitted, such as a
+// higher-timeframe trend filter.
+type SignalGate interface {
+	Allow(ctx context.Context, signal domain.TradeSignal) (bool, error)
+}
+
+// ChangeWatcher is the secondary port (interface) for following new documents as they land.
+type ChangeWatcher interface {
+	// Watch calls handle for every change after resumeToken, or from now without
+	// one, until ctx is done or handle fails.
+	Watch(ctx context.Context, resumeToken []byte, handle func(context.Context, domain.ChangeEvent) error) error
+}
+
+// ResumeTokenRepository is the secondary port (interface) for the positions of change streams.
+type ResumeTokenRepository interface {
+	GetResumeToken(ctx context.Context, stream string) ([]byte, error)
+	SaveResumeToken(ctx context.Context, stream string, token []byte) error
+}
+
+// LeaderLock is the secondary port (interface) for the lease that elects one
+// replica as the leader.
+type LeaderLock interface {
+	// Acquire takes the lease for owner if it is free and returns the fencing
+	// token of the new term, which grows with every term, or 0 if it is taken.
+	Acquire(ctx context.Context, owner string, ttl time.Duration) (int64, error)
+	// Renew extends the lease of owner and reports whether owner still holds it.
+	Renew(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	// Release frees the lease if owner holds it.
+	Release(ctx context.Context, owner string) error
+}
+
+// TradeRepository is the secondary port (interface) for trade data access.
+type TradeRepository interface {
+	GetTrades(ctx context.Context, symbol string, from, to time.Time) ([]domain.Trade, error)
+	// EachTrade calls fn for the trades of symbol executed in [from, to), oldest
+	// first, without loading them all at once.
+	EachTrade(ctx context.Context, symbol string, from, to time.Time, fn func(trade domain.Trade) error) error
+}
+
+// CandleRepository is the secondary port (interface) for candle storage.
+type CandleRepository interface {
+	SaveCandles(ctx context.Context, candles []domain.Candle) error
+	GetCandles(ctx context.Context, symbol string, timeframe domain.Timeframe, from, to time.Time) ([]domain.Candle, error)
+	GetLatestCandles(ctx context.Context, symbol string, timeframe domain.Timeframe, limit int) ([]domain.Candle, error)
+}
+
+// SignalStatusRepository is the secondary port (interface) for the steady state of strategies.
+type SignalStatusRepository interface {
+	SaveStatus(ctx context.Context, status domain.SignalStatus) error
+	GetStatuses(ctx context.Context) ([]domain.SignalStatus, error)
+}
+
+// BookSnapshotRepository is the secondary port (interface) for the order book
+// snapshots stored by the collector.
+type BookSnapshotRepository interface {
+	// EachSnapshot calls fn for the snapshots of symbol taken in [from, to),
+	// oldest first, without loading them all at once.
+	EachSnapshot(ctx context.Context, symbol string, from, to time.Time, fn func(snapshot domain.BookSnapshot) error) error
+}
+
+// BookFeatureRepository is the secondary port (interface) for the order book feature time series.
+type BookFeatureRepository interface {
+	SaveFeatures(ctx context.Context, features []domain.BookFeatures) error
+	GetFeatures(ctx context.Context, symbol string, from, to time.Time) ([]domain.BookFeatures, error)
+	LatestFeatureTime(ctx context.Context, symbol string) (time.Time, bool, error)
+}
@@ -1,11 +1,12 @@
 package ports
 
 import (
+	"context"
+
 	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
 )
 
 // SignalService is the primary port (interface) for signal processing.
 type SignalService interface {
-	GenerateSignal() (*domain.TradeSignal, error)
-	CalculateSMAs(prices []float64) (shortSMA, longSMA []float64, err error)
+	GenerateSignals(ctx context.Context, symbol string, timeframe domain.Timeframe) ([]domain.TradeSignal, error)
 }
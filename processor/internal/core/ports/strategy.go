@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+)
+
+// Strategy is the secondary port (interface) for trading strategies.
+type Strategy interface {
+	// Name identifies the strategy in the registry and on emitted signals.
+	Name() string
+	// Params returns the parameters the strategy was created with.
+	Params() map[string]float64
+	// RequiredHistory is the number of closed bars Evaluate needs.
+	RequiredHistory() int
+	// Evaluate decides on the latest bar of series.
+	Evaluate(series domain.Series) (domain.Signal, error)
+}
+
+// StreamingStrategy is a Strategy that keeps rolling indicator state and is
+// fed one closed bar at a time.
+type StreamingStrategy interface {
+	Strategy
+	// Update feeds the next closed bar. ok is false while the strategy warms up.
+	Update(candle domain.Candle) (signal domain.Signal, ok bool)
+}
@@ -0,0 +1,107 @@
+// Package indicators implements technical indicators with two APIs: streaming
+// types that are updated one value at a time in O(1) and batch Compute
+// functions that map a whole series. Batch outputs have the same length as
+// their input and hold NaN until the indicator has enough history.
+package indicators
+
+import (
+	"fmt"
+)
+
+const (
+	percent             = 100
+	midpoint            = percent / 2
+	typicalPriceDivisor = 3
+)
+
+// Bar is the input of indicators that need more than a single price.
+type Bar struct {
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// TypicalPrice returns (high + low + close) / 3.
+func (b Bar) TypicalPrice() float64 {
+	return (b.High + b.Low + b.Close) / typicalPriceDivisor
+}
+
+func mustPositive(name string, period int) {
+	if period < 1 {
+		panic(fmt.Sprintf("indicators: %s period must be positive, got %d", name, period))
+	}
+}
+
+// compute feeds values to update and collects the outputs.
+func compute[T any](values []T, update func(T) float64) []float64 {
+	result := make([]float64, len(values))
+	for i, value := range values {
+		result[i] = update(value)
+	}
+
+	return result
+}
+
+// window is a fixed size ring buffer.
+type window struct {
+	values []float64
+	next   int
+	count  int
+}
+
+func newWindow(size int) *window {
+	return &window{values: make([]float64, size)}
+}
+
+// push adds value and returns the value it evicted, if the window was full.
+func (w *window) push(value float64) (float64, bool) {
+	evicted, full := w.values[w.next], w.count == len(w.values)
+	w.values[w.next] = value
+	w.next = (w.next + 1) % len(w.values)
+
+	if !full {
+		w.count++
+	}
+
+	return evicted, full
+}
+
+func (w *window) full() bool {
+	return w.count == len(w.values)
+}
+
+// extremum tracks the maximum (or minimum) of a sliding window with a monotonic deque.
+type extremum struct {
+	size    int
+	better  func(a, b float64) bool
+	indexes []int
+	values  []float64
+	seen    int
+}
+
+func newExtremum(size int, better func(a, b float64) bool) *extremum {
+	return &extremum{size: size, better: better}
+}
+
+func (e *extremum) push(value float64) float64 {
+	for len(e.values) > 0 && !e.better(e.values[len(e.values)-1], value) {
+		e.values = e.values[:len(e.values)-1]
+		e.indexes = e.indexes[:len(e.indexes)-1]
+	}
+
+	e.values = append(e.values, value)
+	e.indexes = append(e.indexes, e.seen)
+	e.seen++
+
+	if e.indexes[0] <= e.seen-1-e.size {
+		e.values = e.values[1:]
+		e.indexes = e.indexes[1:]
+	}
+
+	return e.values[0]
+}
+
+func greater(a, b float64) bool { return a > b }
+
+func less(a, b float64) bool { return a < b }
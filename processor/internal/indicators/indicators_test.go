@@ -0,0 +1,189 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tolerance = 1e-6
+
+var (
+	nan = math.NaN()
+
+	closes = []float64{
+		44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+		45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+	}
+
+	bars = []Bar{
+		{High: 48.70, Low: 47.79, Close: 48.16, Volume: 100},
+		{High: 48.72, Low: 48.14, Close: 48.61, Volume: 120},
+		{High: 48.90, Low: 48.39, Close: 48.75, Volume: 90},
+		{High: 48.87, Low: 48.37, Close: 48.63, Volume: 80},
+		{High: 48.82, Low: 48.24, Close: 48.74, Volume: 150},
+		{High: 49.05, Low: 48.64, Close: 49.03, Volume: 110},
+		{High: 49.20, Low: 48.94, Close: 49.07, Volume: 95},
+		{High: 49.35, Low: 48.86, Close: 49.32, Volume: 130},
+		{High: 49.92, Low: 49.50, Close: 49.91, Volume: 170},
+		{High: 50.19, Low: 49.87, Close: 50.13, Volume: 160},
+		{High: 50.12, Low: 49.20, Close: 49.53, Volume: 140},
+		{High: 49.66, Low: 48.90, Close: 49.50, Volume: 100},
+		{High: 49.88, Low: 49.43, Close: 49.75, Volume: 105},
+		{High: 50.19, Low: 49.73, Close: 50.03, Volume: 125},
+	}
+)
+
+func assertSeries(t *testing.T, expected, actual []float64) {
+	t.Helper()
+
+	require.Len(t, actual, len(expected))
+
+	for i := range expected {
+		if math.IsNaN(expected[i]) {
+			assert.Truef(t, math.IsNaN(actual[i]), "index %d: expected NaN, got %v", i, actual[i])
+
+			continue
+		}
+
+		assert.InDeltaf(t, expected[i], actual[i], tolerance, "index %d", i)
+	}
+}
+
+func TestSingleValueIndicators(t *testing.T) {
+	tests := []struct {
+		name     string
+		compute  func() []float64
+		expected []float64
+	}{
+		{
+			name:     "SMA",
+			compute:  func() []float64 { return ComputeSMA(closes[:6], 3) },
+			expected: []float64{nan, nan, 44.193333, 43.95, 44.03, 44.256667},
+		},
+		{
+			name:    "EMA",
+			compute: func() []float64 { return ComputeEMA(closes, 5) },
+			expected: []float64{
+				nan, nan, nan, nan, 44.104, 44.346, 44.597333, 44.871556, 45.19437, 45.48958,
+				45.623053, 45.758702, 45.709135, 45.899423, 46.026282, 46.017521, 46.021681, 46.151121, 46.17408, 45.996054,
+			},
+		},
+		{
+			name:    "WMA",
+			compute: func() []float64 { return ComputeWMA(closes, 4) },
+			expected: []float64{
+				nan, nan, nan, 43.941, 44.054, 44.368, 44.716, 45.097, 45.465, 45.778,
+				45.89, 45.979, 45.839, 45.99, 46.121, 46.101, 46.096, 46.201, 46.217, 46.007,
+			},
+		},
+		{
+			name:    "RSI",
+			compute: func() []float64 { return ComputeRSI(closes, 14) },
+			expected: []float64{
+				nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan,
+				70.464135, 66.249619, 66.480942, 69.346853, 66.294713, 57.915021,
+			},
+		},
+		{
+			name:     "RSI without losses",
+			compute:  func() []float64 { return ComputeRSI([]float64{1, 2, 3, 3}, 2) },
+			expected: []float64{nan, nan, 100, 100},
+		},
+		{
+			name:    "ATR",
+			compute: func() []float64 { return ComputeATR(bars, 5) },
+			expected: []float64{
+				nan, nan, nan, nan, 0.616, 0.5748, 0.51184,
+				0.507472, 0.525978, 0.484782, 0.573826, 0.611061, 0.578848, 0.555079,
+			},
+		},
+		{
+			name:     "OBV",
+			compute:  func() []float64 { return ComputeOBV(bars) },
+			expected: []float64{0, 120, 210, 130, 280, 390, 485, 615, 785, 945, 805, 705, 810, 935},
+		},
+		{
+			name:     "VWAP",
+			compute:  func() []float64 { return ComputeVWAP(bars[:4]) },
+			expected: []float64{48.216667, 48.365758, 48.456989, 48.491111},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assertSeries(t, test.expected, test.compute())
+		})
+	}
+}
+
+func TestMACD(t *testing.T) {
+	series := ComputeMACD(closes, 3, 6, 4)
+
+	assertSeries(t, []float64{0.067886, 0.124953, 0.08677, -0.063549}, series.MACD[16:])
+	assertSeries(t, []float64{0.131045, 0.128608, 0.111873, 0.041704}, series.Signal[16:])
+	assert.InDelta(t, -0.063549-0.041704, series.Histogram[19], tolerance)
+
+	// The signal line needs slow + signal - 1 values.
+	assert.True(t, math.IsNaN(series.Signal[7]))
+	assert.False(t, math.IsNaN(series.Signal[8]))
+}
+
+func TestBollingerBands(t *testing.T) {
+	series := ComputeBollingerBands(closes, 5, 2)
+
+	assertSeries(t, []float64{46.517238, 46.496649, 46.57303}, series.Upper[17:])
+	assertSeries(t, []float64{46.2, 46.188, 46.06}, series.Middle[17:])
+	assertSeries(t, []float64{45.882762, 45.879351, 45.54697}, series.Lower[17:])
+	assert.True(t, math.IsNaN(series.Middle[3]))
+}
+
+func TestStochastic(t *testing.T) {
+	series := ComputeStochastic(bars, 5, 3)
+
+	assertSeries(t, []float64{
+		nan, nan, nan, nan, 85.585586, 97.802198, 86.458333,
+		97.297297, 99.404762, 96.129032, 50.37594, 48.120301, 65.891473, 87.596899,
+	}, series.K)
+	assertSeries(t, []float64{
+		nan, nan, nan, nan, nan, nan, 89.948706,
+		93.852609, 94.386798, 97.610364, 81.969911, 64.875091, 54.795904, 67.202891,
+	}, series.D)
+}
+
+func TestADX(t *testing.T) {
+	series := ComputeADX(bars, 4)
+
+	assertSeries(t, []float64{
+		nan, nan, nan, nan, nan, nan, nan,
+		53.162899, 62.437402, 70.05002, 53.018712, 44.880825, 33.869237, 31.792813,
+	}, series.ADX)
+	assertSeries(t, []float64{
+		nan, nan, nan, nan, 9.21659, 18.650307, 24.327158,
+		26.009045, 46.986163, 53.633103, 31.756373, 21.985159, 27.243227, 35.688219,
+	}, series.PlusDI)
+	assertSeries(t, []float64{
+		nan, nan, nan, nan, 6.912442, 5.521472, 4.71863,
+		3.455926, 2.405131, 1.97755, 30.556974, 33.300583, 26.792314, 21.156655,
+	}, series.MinusDI)
+}
+
+func TestStreamingMatchesBatch(t *testing.T) {
+	ema := NewEMA(5)
+	for _, value := range closes {
+		ema.Update(value)
+	}
+
+	assert.True(t, ema.Ready())
+	assert.InDelta(t, 45.996054, ema.Value(), tolerance)
+
+	vwap := NewVWAP()
+	vwap.Update(bars[0])
+	vwap.Reset()
+	assert.False(t, vwap.Ready())
+	assert.InDelta(t, bars[1].TypicalPrice(), vwap.Update(bars[1]), tolerance)
+
+	assert.Panics(t, func() { NewSMA(0) })
+}
@@ -0,0 +1,153 @@
+package indicators
+
+import "math"
+
+const (
+	emaSmoothing = 2
+	// triangular divides period * (period + 1) to sum the weights 1..period.
+	triangular = 2
+)
+
+// SMA is the simple moving average of the last period values.
+type SMA struct {
+	window *window
+	sum    float64
+}
+
+// NewSMA panics if period is not positive.
+func NewSMA(period int) *SMA {
+	mustPositive("SMA", period)
+
+	return &SMA{window: newWindow(period)}
+}
+
+// Update adds value and returns the average, NaN until period values were seen.
+func (s *SMA) Update(value float64) float64 {
+	if evicted, full := s.window.push(value); full {
+		s.sum -= evicted
+	}
+
+	s.sum += value
+
+	return s.Value()
+}
+
+func (s *SMA) Value() float64 {
+	if !s.Ready() {
+		return math.NaN()
+	}
+
+	return s.sum / float64(s.window.count)
+}
+
+func (s *SMA) Ready() bool {
+	return s.window.full()
+}
+
+func ComputeSMA(values []float64, period int) []float64 {
+	return compute(values, NewSMA(period).Update)
+}
+
+// EMA is the exponential moving average with smoothing 2 / (period + 1),
+// seeded with the simple average of the first period values.
+type EMA struct {
+	period int
+	alpha  float64
+	count  int
+	sum    float64
+	value  float64
+}
+
+// NewEMA panics if period is not positive.
+func NewEMA(period int) *EMA {
+	mustPositive("EMA", period)
+
+	return &EMA{
+		period: period,
+		alpha:  emaSmoothing / float64(period+1),
+	}
+}
+
+// Update adds value and returns the average, NaN until period values were seen.
+func (e *EMA) Update(value float64) float64 {
+	e.count++
+
+	switch {
+	case e.count < e.period:
+		e.sum += value
+	case e.count == e.period:
+		e.value = (e.sum + value) / float64(e.period)
+	default:
+		e.value += e.alpha * (value - e.value)
+	}
+
+	return e.Value()
+}
+
+func (e *EMA) Value() float64 {
+	if !e.Ready() {
+		return math.NaN()
+	}
+
+	return e.value
+}
+
+func (e *EMA) Ready() bool {
+	return e.count >= e.period
+}
+
+func ComputeEMA(values []float64, period int) []float64 {
+	return compute(values, NewEMA(period).Update)
+}
+
+// WMA is the linearly weighted moving average; the newest value has weight
+// period and the oldest weight 1.
+type WMA struct {
+	window   *window
+	sum      float64
+	weighted float64
+	divisor  float64
+}
+
+// NewWMA panics if period is not positive.
+func NewWMA(period int) *WMA {
+	mustPositive("WMA", period)
+
+	return &WMA{
+		window:  newWindow(period),
+		divisor: float64(period*(period+1)) / triangular,
+	}
+}
+
+// Update adds value and returns the average, NaN until period values were seen.
+func (w *WMA) Update(value float64) float64 {
+	period := float64(len(w.window.values))
+
+	evicted, full := w.window.push(value)
+	if full {
+		// Every value loses one weight step, the evicted one drops out and the new one gets full weight.
+		w.weighted += period*value - w.sum
+		w.sum += value - evicted
+	} else {
+		w.weighted += float64(w.window.count) * value
+		w.sum += value
+	}
+
+	return w.Value()
+}
+
+func (w *WMA) Value() float64 {
+	if !w.Ready() {
+		return math.NaN()
+	}
+
+	return w.weighted / w.divisor
+}
+
+func (w *WMA) Ready() bool {
+	return w.window.full()
+}
+
+func ComputeWMA(values []float64, period int) []float64 {
+	return compute(values, NewWMA(period).Update)
+}
@@ -0,0 +1,221 @@
+package indicators
+
+import "math"
+
+// RSI is Wilder's relative strength index of the last period changes.
+type RSI struct {
+	period   int
+	count    int
+	previous float64
+	avgGain  float64
+	avgLoss  float64
+}
+
+// NewRSI panics if period is not positive.
+func NewRSI(period int) *RSI {
+	mustPositive("RSI", period)
+
+	return &RSI{period: period}
+}
+
+// Update adds value and returns the RSI, NaN until period changes were seen.
+func (r *RSI) Update(value float64) float64 {
+	r.count++
+
+	if r.count == 1 {
+		r.previous = value
+
+		return math.NaN()
+	}
+
+	change := value - r.previous
+	r.previous = value
+	gain, loss := max(change, 0), max(-change, 0)
+	period := float64(r.period)
+
+	if r.count <= r.period+1 {
+		// Simple average of the first period changes.
+		r.avgGain += gain / period
+		r.avgLoss += loss / period
+	} else {
+		r.avgGain = (r.avgGain*(period-1) + gain) / period
+		r.avgLoss = (r.avgLoss*(period-1) + loss) / period
+	}
+
+	return r.Value()
+}
+
+func (r *RSI) Value() float64 {
+	if !r.Ready() {
+		return math.NaN()
+	}
+
+	if r.avgLoss == 0 {
+		if r.avgGain == 0 {
+			return midpoint
+		}
+
+		return percent
+	}
+
+	return percent - percent/(1+r.avgGain/r.avgLoss)
+}
+
+func (r *RSI) Ready() bool {
+	return r.count > r.period
+}
+
+func ComputeRSI(values []float64, period int) []float64 {
+	return compute(values, NewRSI(period).Update)
+}
+
+// MACDValue is one output of the MACD.
+type MACDValue struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+// MACD is the difference of a fast and a slow EMA with an EMA signal line of
+// that difference.
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+	value  MACDValue
+}
+
+// NewMACD panics if a period is not positive.
+func NewMACD(fastPeriod, slowPeriod, signalPeriod int) *MACD {
+	return &MACD{
+		fast:   NewEMA(fastPeriod),
+		slow:   NewEMA(slowPeriod),
+		signal: NewEMA(signalPeriod),
+	}
+}
+
+// Update adds value and returns the MACD. Fields are NaN until their EMAs are ready.
+func (m *MACD) Update(value float64) MACDValue {
+	fast, slow := m.fast.Update(value), m.slow.Update(value)
+
+	m.value = MACDValue{MACD: math.NaN(), Signal: math.NaN(), Histogram: math.NaN()}
+
+	if m.fast.Ready() && m.slow.Ready() {
+		m.value.MACD = fast - slow
+		m.value.Signal = m.signal.Update(m.value.MACD)
+		m.value.Histogram = m.value.MACD - m.value.Signal
+	}
+
+	return m.value
+}
+
+func (m *MACD) Value() MACDValue {
+	return m.value
+}
+
+func (m *MACD) Ready() bool {
+	return m.signal.Ready()
+}
+
+// MACDSeries holds the batch outputs of the MACD.
+type MACDSeries struct {
+	MACD      []float64
+	Signal    []float64
+	Histogram []float64
+}
+
+func ComputeMACD(values []float64, fastPeriod, slowPeriod, signalPeriod int) MACDSeries {
+	macd := NewMACD(fastPeriod, slowPeriod, signalPeriod)
+	series := MACDSeries{
+		MACD:      make([]float64, len(values)),
+		Signal:    make([]float64, len(values)),
+		Histogram: make([]float64, len(values)),
+	}
+
+	for i, value := range values {
+		out := macd.Update(value)
+		series.MACD[i], series.Signal[i], series.Histogram[i] = out.MACD, out.Signal, out.Histogram
+	}
+
+	return series
+}
+
+// StochasticValue is one output of the stochastic oscillator.
+type StochasticValue struct {
+	K float64
+	D float64
+}
+
+// Stochastic is the position of the close within the high-low range of the
+// last kPeriod bars (%K) and its dPeriod simple average (%D).
+type Stochastic struct {
+	highest *extremum
+	lowest  *extremum
+	kPeriod int
+	count   int
+	d       *SMA
+	value   StochasticValue
+}
+
+// NewStochastic panics if a period is not positive.
+func NewStochastic(kPeriod, dPeriod int) *Stochastic {
+	mustPositive("Stochastic %K", kPeriod)
+
+	return &Stochastic{
+		highest: newExtremum(kPeriod, greater),
+		lowest:  newExtremum(kPeriod, less),
+		kPeriod: kPeriod,
+		d:       NewSMA(dPeriod),
+	}
+}
+
+// Update adds bar and returns %K and %D, NaN until enough bars were seen.
+// A flat range yields a %K of 50.
+func (s *Stochastic) Update(bar Bar) StochasticValue {
+	s.count++
+	highest, lowest := s.highest.push(bar.High), s.lowest.push(bar.Low)
+
+	s.value = StochasticValue{K: math.NaN(), D: math.NaN()}
+
+	if s.count < s.kPeriod {
+		return s.value
+	}
+
+	s.value.K = midpoint
+	if highest > lowest {
+		s.value.K = percent * (bar.Close - lowest) / (highest - lowest)
+	}
+
+	s.value.D = s.d.Update(s.value.K)
+
+	return s.value
+}
+
+func (s *Stochastic) Value() StochasticValue {
+	return s.value
+}
+
+func (s *Stochastic) Ready() bool {
+	return s.d.Ready()
+}
+
+// StochasticSeries holds the batch outputs of the stochastic oscillator.
+type StochasticSeries struct {
+	K []float64
+	D []float64
+}
+
+func ComputeStochastic(bars []Bar, kPeriod, dPeriod int) StochasticSeries {
+	stochastic := NewStochastic(kPeriod, dPeriod)
+	series := StochasticSeries{
+		K: make([]float64, len(bars)),
+		D: make([]float64, len(bars)),
+	}
+
+	for i, bar := range bars {
+		out := stochastic.Update(bar)
+		series.K[i], series.D[i] = out.K, out.D
+	}
+
+	return series
+}
@@ -0,0 +1,127 @@
+package indicators
+
+import "math"
+
+// ADXValue is one output of the ADX.
+type ADXValue struct {
+	ADX     float64
+	PlusDI  float64
+	MinusDI float64
+}
+
+// ADX is Wilder's average directional index with the +DI and -DI lines it is
+// built from. The DI lines are ready after period+1 bars, the ADX after 2*period.
+type ADX struct {
+	period  int
+	count   int
+	prev    Bar
+	trueSum float64
+	plusSum float64
+	minSum  float64
+	dxCount int
+	adx     float64
+	value   ADXValue
+}
+
+// NewADX panics if period is not positive.
+func NewADX(period int) *ADX {
+	mustPositive("ADX", period)
+
+	return &ADX{period: period}
+}
+
+func (a *ADX) Update(bar Bar) ADXValue {
+	a.count++
+	prev := a.prev
+	a.prev = bar
+
+	a.value = ADXValue{ADX: math.NaN(), PlusDI: math.NaN(), MinusDI: math.NaN()}
+
+	if a.count == 1 {
+		return a.value
+	}
+
+	upMove, downMove := bar.High-prev.High, prev.Low-bar.Low
+	trueRange := max(bar.High-bar.Low, math.Abs(bar.High-prev.Close), math.Abs(bar.Low-prev.Close))
+
+	var plusDM, minusDM float64
+	if upMove > downMove && upMove > 0 {
+		plusDM = upMove
+	}
+
+	if downMove > upMove && downMove > 0 {
+		minusDM = downMove
+	}
+
+	period := float64(a.period)
+
+	// Wilder smoothing of the sums, seeded with the plain sum of the first period values.
+	if a.count <= a.period+1 {
+		a.trueSum += trueRange
+		a.plusSum += plusDM
+		a.minSum += minusDM
+	} else {
+		a.trueSum += trueRange - a.trueSum/period
+		a.plusSum += plusDM - a.plusSum/period
+		a.minSum += minusDM - a.minSum/period
+	}
+
+	if a.count <= a.period {
+		return a.value
+	}
+
+	a.value.PlusDI, a.value.MinusDI = 0, 0
+	if a.trueSum > 0 {
+		a.value.PlusDI = percent * a.plusSum / a.trueSum
+		a.value.MinusDI = percent * a.minSum / a.trueSum
+	}
+
+	var dx float64
+	if total := a.value.PlusDI + a.value.MinusDI; total > 0 {
+		dx = percent * math.Abs(a.value.PlusDI-a.value.MinusDI) / total
+	}
+
+	a.dxCount++
+	if a.dxCount <= a.period {
+		a.adx += dx / period
+	} else {
+		a.adx = (a.adx*(period-1) + dx) / period
+	}
+
+	if a.dxCount >= a.period {
+		a.value.ADX = a.adx
+	}
+
+	return a.value
+}
+
+func (a *ADX) Value() ADXValue {
+	return a.value
+}
+
+func (a *ADX) Ready() bool {
+	return a.dxCount >= a.period
+}
+
+// ADXSeries holds the batch outputs of the ADX.
+type ADXSeries struct {
+	ADX     []float64
+	PlusDI  []float64
+	MinusDI []float64
+}
+
+func ComputeADX(bars []Bar, period int) ADXSeries {
+	adx := NewADX(period)
+	series := ADXSeries{
+		ADX:     make([]float64, len(bars)),
+		PlusDI:  make([]float64, len(bars)),
+		MinusDI: make([]float64, len(bars)),
+	}
+
+	for i, bar := range bars {
+		out := adx.Update(bar)
+		series.ADX[i], series.PlusDI[i], series.MinusDI[i] = out.ADX, out.PlusDI, out.MinusDI
+	}
+
+	return series
+}
@@ -0,0 +1,137 @@
+package indicators
+
+import "math"
+
+// BandsValue is one output of the Bollinger Bands.
+type BandsValue struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+}
+
+// BollingerBands are the period simple average of the values plus and minus
+// multiplier population standard deviations.
+type BollingerBands struct {
+	window     *window
+	multiplier float64
+	sum        float64
+	sumSquares float64
+}
+
+// NewBollingerBands panics if period is not positive.
+func NewBollingerBands(period int, multiplier float64) *BollingerBands {
+	mustPositive("Bollinger Bands", period)
+
+	return &BollingerBands{
+		window:     newWindow(period),
+		multiplier: multiplier,
+	}
+}
+
+// Update adds value and returns the bands, NaN until period values were seen.
+func (b *BollingerBands) Update(value float64) BandsValue {
+	if evicted, full := b.window.push(value); full {
+		b.sum -= evicted
+		b.sumSquares -= evicted * evicted
+	}
+
+	b.sum += value
+	b.sumSquares += value * value
+
+	return b.Value()
+}
+
+func (b *BollingerBands) Value() BandsValue {
+	if !b.Ready() {
+		return BandsValue{Upper: math.NaN(), Middle: math.NaN(), Lower: math.NaN()}
+	}
+
+	n := float64(b.window.count)
+	mean := b.sum / n
+	// Rounding can push the variance of a flat window slightly below zero.
+	deviation := math.Sqrt(max(b.sumSquares/n-mean*mean, 0))
+
+	return BandsValue{
+		Upper:  mean + b.multiplier*deviation,
+		Middle: mean,
+		Lower:  mean - b.multiplier*deviation,
+	}
+}
+
+func (b *BollingerBands) Ready() bool {
+	return b.window.full()
+}
+
+// BandsSeries holds the batch outputs of the Bollinger Bands.
+type BandsSeries struct {
+	Upper  []float64
+	Middle []float64
+	Lower  []float64
+}
+
+func ComputeBollingerBands(values []float64, period int, multiplier float64) BandsSeries {
+	bands := NewBollingerBands(period, multiplier)
+	series := BandsSeries{
+		Upper:  make([]float64, len(values)),
+		Middle: make([]float64, len(values)),
+		Lower:  make([]float64, len(values)),
+	}
+
+	for i, value := range values {
+		out := bands.Update(value)
+		series.Upper[i], series.Middle[i], series.Lower[i] = out.Upper, out.Middle, out.Lower
+	}
+
+	return series
+}
+
+// ATR is Wilder's average true range. The first bar's true range is its high-low range.
+type ATR struct {
+	period    int
+	count     int
+	prevClose float64
+	value     float64
+}
+
+// NewATR panics if period is not positive.
+func NewATR(period int) *ATR {
+	mustPositive("ATR", period)
+
+	return &ATR{period: period}
+}
+
+// Update adds bar and returns the ATR, NaN until period bars were seen.
+func (a *ATR) Update(bar Bar) float64 {
+	trueRange := bar.High - bar.Low
+	if a.count > 0 {
+		trueRange = max(trueRange, math.Abs(bar.High-a.prevClose), math.Abs(bar.Low-a.prevClose))
+	}
+
+	a.count++
+	a.prevClose = bar.Close
+	period := float64(a.period)
+
+	if a.count <= a.period {
+		a.value += trueRange / period
+	} else {
+		a.value = (a.value*(period-1) + trueRange) / period
+	}
+
+	return a.Value()
+}
+
+func (a *ATR) Value() float64 {
+	if !a.Ready() {
+		return math.NaN()
+	}
+
+	return a.value
+}
+
+func (a *ATR) Ready() bool {
+	return a.count >= a.period
+}
+
+func ComputeATR(bars []Bar, period int) []float64 {
+	return compute(bars, NewATR(period).Update)
+}
@@ -0,0 +1,85 @@
+package indicators
+
+import "math"
+
+// VWAP is the volume weighted average typical price since the last Reset.
+type VWAP struct {
+	priceVolume float64
+	volume      float64
+}
+
+func NewVWAP() *VWAP {
+	return &VWAP{}
+}
+
+// Update adds bar and returns the VWAP, NaN while no volume was traded.
+func (v *VWAP) Update(bar Bar) float64 {
+	v.priceVolume += bar.TypicalPrice() * bar.Volume
+	v.volume += bar.Volume
+
+	return v.Value()
+}
+
+func (v *VWAP) Value() float64 {
+	if !v.Ready() {
+		return math.NaN()
+	}
+
+	return v.priceVolume / v.volume
+}
+
+func (v *VWAP) Ready() bool {
+	return v.volume > 0
+}
+
+// Reset starts a new session.
+func (v *VWAP) Reset() {
+	v.priceVolume, v.volume = 0, 0
+}
+
+func ComputeVWAP(bars []Bar) []float64 {
+	return compute(bars, NewVWAP().Update)
+}
+
+// OBV is the on-balance volume: the running sum of volume, added on up closes
+// and subtracted on down closes. It starts at zero on the first bar.
+type OBV struct {
+	started   bool
+	prevClose float64
+	value     float64
+}
+
+func NewOBV() *OBV {
+	return &OBV{}
+}
+
+func (o *OBV) Update(bar Bar) float64 {
+	switch {
+	case !o.started:
+		o.started = true
+	case bar.Close > o.prevClose:
+		o.value += bar.Volume
+	case bar.Close < o.prevClose:
+		o.value -= bar.Volume
+	}
+
+	o.prevClose = bar.Close
+
+	return o.value
+}
+
+func (o *OBV) Value() float64 {
+	if !o.Ready() {
+		return math.NaN()
+	}
+
+	return o.value
+}
+
+func (o *OBV) Ready() bool {
+	return o.started
+}
+
+func ComputeOBV(bars []Bar) []float64 {
+	return compute(bars, NewOBV().Update)
+}
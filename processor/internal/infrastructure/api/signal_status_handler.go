@@ -0,0 +1,24 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+)
+
+// SignalStatusProvider returns the steady state of every strategy and symbol.
+type SignalStatusProvider interface {
+	Statuses() []domain.SignalStatus
+}
+
+type SignalStatusHandler struct {
+	provider SignalStatusProvider
+}
+
+func NewSignalStatusHandler(provider SignalStatusProvider) *SignalStatusHandler {
+	return &SignalStatusHandler{provider: provider}
+}
+
+// List returns the current state of every strategy, also while no actionable signal is emitted.
+func (h *SignalStatusHandler) List(c *fiber.Ctx) error {
+	return c.JSON(h.provider.Statuses())
+}
@@ -0,0 +1,169 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// snapshotBatchSize is the number of snapshots fetched per cursor round trip.
+const snapshotBatchSize = 500
+
+// MongoBookSnapshotRepository reads the order book snapshots stored by the collector.
+type MongoBookSnapshotRepository struct {
+	client       *mongo.Client
+	databaseName string
+	collection   string
+}
+
+func NewMongoBookSnapshotRepository(client *mongo.Client, dbName, collection string) *MongoBookSnapshotRepository {
+	return &MongoBookSnapshotRepository{
+		client:       client,
+		databaseName: dbName,
+		collection:   collection,
+	}
+}
+
+// EachSnapshot calls fn for the snapshots of symbol taken in [from, to),
+// oldest first, decoding one cursor batch at a time.
+func (r *MongoBookSnapshotRepository) EachSnapshot(
+	ctx context.Context,
+	symbol string,
+	from, to time.Time,
+	fn func(snapshot domain.BookSnapshot) error,
+) error {
+	collection := r.client.Database(r.databaseName).Collection(r.collection)
+
+	filter := bson.M{
+		"symbol":    symbol,
+		"timestamp": bson.M{"$gte": from, "$lt": to},
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}).SetBatchSize(snapshotBatchSize)
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return fmt.Errorf("failed to fetch book snapshots: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var snapshot domain.BookSnapshot
+		if err := cursor.Decode(&snapshot); err != nil {
+			return fmt.Errorf("failed to decode book snapshot: %w", err)
+		}
+
+		if err := fn(snapshot); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate book snapshots: %w", err)
+	}
+
+	return nil
+}
+
+// MongoBookFeatureRepository stores the order book features keyed by symbol and timestamp.
+type MongoBookFeatureRepository struct {
+	client       *mongo.Client
+	databaseName string
+	collection   string
+}
+
+func NewMongoBookFeatureRepository(
+	client *mongo.Client,
+	dbName, collection string,
+) (*MongoBookFeatureRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), repositoryTimeout)
+	defer cancel()
+
+	repo := &MongoBookFeatureRepository{
+		client:       client,
+		databaseName: dbName,
+		collection:   collection,
+	}
+
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "symbol", Value: 1}, {Key: "timestamp", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	if _, err := repo.coll().Indexes().CreateOne(ctx, indexModel); err != nil {
+		return nil, fmt.Errorf("failed to create book feature index: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *MongoBookFeatureRepository) coll() *mongo.Collection {
+	return r.client.Database(r.databaseName).Collection(r.collection)
+}
+
+// SaveFeatures upserts features so that rebuilding a range is idempotent.
+func (r *MongoBookFeatureRepository) SaveFeatures(ctx context.Context, features []domain.BookFeatures) error {
+	models := make([]mongo.WriteModel, 0, len(features))
+
+	for _, feature := range features {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"symbol": feature.Symbol, "timestamp": feature.Timestamp}).
+			SetReplacement(feature).
+			SetUpsert(true))
+	}
+
+	if _, err := r.coll().BulkWrite(ctx, models); err != nil {
+		return fmt.Errorf("failed to upsert book features: %w", err)
+	}
+
+	return nil
+}
+
+// GetFeatures returns the features of symbol in [from, to), oldest first.
+func (r *MongoBookFeatureRepository) GetFeatures(
+	ctx context.Context,
+	symbol string,
+	from, to time.Time,
+) ([]domain.BookFeatures, error) {
+	filter := bson.M{
+		"symbol":    symbol,
+		"timestamp": bson.M{"$gte": from, "$lt": to},
+	}
+
+	cursor, err := r.coll().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch book features: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var features []domain.BookFeatures
+	if err = cursor.All(ctx, &features); err != nil {
+		return nil, fmt.Errorf("failed to decode book features: %w", err)
+	}
+
+	return features, nil
+}
+
+// LatestFeatureTime returns the timestamp of the latest feature of symbol.
+func (r *MongoBookFeatureRepository) LatestFeatureTime(ctx context.Context, symbol string) (time.Time, bool, error) {
+	var latest domain.BookFeatures
+
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+
+	err := r.coll().FindOne(ctx, bson.M{"symbol": symbol}, findOptions).Decode(&latest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, false, nil
+	}
+
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to fetch latest book feature: %w", err)
+	}
+
+	return latest.Timestamp, true, nil
+}
@@ -0,0 +1,129 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCandleRepository stores candles in one collection per timeframe,
+// e.g. candles_1m, keyed by symbol and open time.
+type MongoCandleRepository struct {
+	client           *mongo.Client
+	databaseName     string
+	collectionPrefix string
+}
+
+func NewMongoCandleRepository(
+	client *mongo.Client,
+	dbName, collectionPrefix string,
+	timeframes []domain.Timeframe,
+) (*MongoCandleRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), repositoryTimeout)
+	defer cancel()
+
+	repo := &MongoCandleRepository{
+		client:           client,
+		databaseName:     dbName,
+		collectionPrefix: collectionPrefix,
+	}
+
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "symbol", Value: 1}, {Key: "openTime", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	for _, timeframe := range timeframes {
+		if _, err := repo.collection(timeframe).Indexes().CreateOne(ctx, indexModel); err != nil {
+			return nil, fmt.Errorf("failed to create %s candle index: %w", timeframe, err)
+		}
+	}
+
+	return repo, nil
+}
+
+func (r *MongoCandleRepository) collection(timeframe domain.Timeframe) *mongo.Collection {
+	return r.client.Database(r.databaseName).Collection(r.collectionPrefix + string(timeframe))
+}
+
+// SaveCandles upserts candles so that bars that are still open get updated.
+func (r *MongoCandleRepository) SaveCandles(ctx context.Context, candles []domain.Candle) error {
+	models := make(map[domain.Timeframe][]mongo.WriteModel)
+
+	for _, candle := range candles {
+		models[candle.Timeframe] = append(models[candle.Timeframe], mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"symbol": candle.Symbol, "openTime": candle.OpenTime}).
+			SetReplacement(candle).
+			SetUpsert(true))
+	}
+
+	for timeframe, writes := range models {
+		if _, err := r.collection(timeframe).BulkWrite(ctx, writes); err != nil {
+			return fmt.Errorf("failed to upsert %s candles: %w", timeframe, err)
+		}
+	}
+
+	return nil
+}
+
+// GetCandles returns the candles opened in [from, to), oldest first.
+func (r *MongoCandleRepository) GetCandles(
+	ctx context.Context,
+	symbol string,
+	timeframe domain.Timeframe,
+	from, to time.Time,
+) ([]domain.Candle, error) {
+	filter := bson.M{
+		"symbol":   symbol,
+		"openTime": bson.M{"$gte": from, "$lt": to},
+	}
+
+	return r.find(ctx, timeframe, filter, options.Find().SetSort(bson.D{{Key: "openTime", Value: 1}}))
+}
+
+// GetLatestCandles returns the latest limit candles, oldest first.
+func (r *MongoCandleRepository) GetLatestCandles(
+	ctx context.Context,
+	symbol string,
+	timeframe domain.Timeframe,
+	limit int,
+) ([]domain.Candle, error) {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "openTime", Value: -1}}).
+		SetLimit(int64(limit))
+
+	candles, err := r.find(ctx, timeframe, bson.M{"symbol": symbol}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(candles)
+
+	return candles, nil
+}
+
+func (r *MongoCandleRepository) find(
+	ctx context.Context,
+	timeframe domain.Timeframe,
+	filter bson.M,
+	findOptions *options.FindOptions,
+) ([]domain.Candle, error) {
+	cursor, err := r.collection(timeframe).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch candles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var candles []domain.Candle
+	if err = cursor.All(ctx, &candles); err != nil {
+		return nil, fmt.Errorf("failed to decode candles: %w", err)
+	}
+
+	return candles, nil
+}
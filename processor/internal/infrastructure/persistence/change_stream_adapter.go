@@ -0,0 +1,146 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Server error codes of change streams.
+const (
+	codeChangeStreamFatal       = 280
+	codeChangeStreamHistoryLost = 286
+	codeNotReplicaSet           = 40573
+)
+
+// changeDocument is the part of a change stream event the watchers use.
+type changeDocument struct {
+	FullDocument bson.Raw `bson:"fullDocument"`
+}
+
+// MongoChangeWatcher follows inserts and updates of collections with a Mongo
+// change stream, which needs a replica set.
+type MongoChangeWatcher struct {
+	client       *mongo.Client
+	databaseName string
+	pipeline     mongo.Pipeline
+	decode       func(bson.Raw) (domain.ChangeEvent, error)
+}
+
+// NewMongoCandleWatcher watches the candle collections of timeframes for closed bars.
+func NewMongoCandleWatcher(
+	client *mongo.Client,
+	dbName, collectionPrefix string,
+	timeframes []domain.Timeframe,
+) *MongoChangeWatcher {
+	collections := make([]string, 0, len(timeframes))
+	for _, timeframe := range timeframes {
+		collections = append(collections, collectionPrefix+string(timeframe))
+	}
+
+	return &MongoChangeWatcher{
+		client:       client,
+		databaseName: dbName,
+		pipeline:     changePipeline(collections, bson.E{Key: "fullDocument.closed", Value: true}),
+		decode: func(document bson.Raw) (domain.ChangeEvent, error) {
+			var candle domain.Candle
+			if err := bson.Unmarshal(document, &candle); err != nil {
+				return domain.ChangeEvent{}, fmt.Errorf("failed to decode candle: %w", err)
+			}
+
+			return domain.ChangeEvent{Symbol: candle.Symbol, Timeframe: candle.Timeframe, Time: candle.CloseTime}, nil
+		},
+	}
+}
+
+// NewMongoDepthWatcher watches the depth updates stored by the collector.
+func NewMongoDepthWatcher(client *mongo.Client, dbName, collection string) *MongoChangeWatcher {
+	return &MongoChangeWatcher{
+		client:       client,
+		databaseName: dbName,
+		pipeline:     changePipeline([]string{collection}),
+		decode: func(document bson.Raw) (domain.ChangeEvent, error) {
+			var record domain.OrderBookRecord
+			if err := bson.Unmarshal(document, &record); err != nil {
+				return domain.ChangeEvent{}, fmt.Errorf("failed to decode depth update: %w", err)
+			}
+
+			return domain.ChangeEvent{Symbol: record.Data.Symbol, Time: record.Timestamp}, nil
+		},
+	}
+}
+
+func changePipeline(collections []string, filters ...bson.E) mongo.Pipeline {
+	match := bson.D{
+		{Key: "operationType", Value: bson.M{"$in": bson.A{"insert", "update", "replace"}}},
+		{Key: "ns.coll", Value: bson.M{"$in": collections}},
+	}
+
+	return mongo.Pipeline{{{Key: "$match", Value: append(match, filters...)}}}
+}
+
+func (w *MongoChangeWatcher) Watch(
+	ctx context.Context,
+	resumeToken []byte,
+	handle func(context.Context, domain.ChangeEvent) error,
+) error {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if len(resumeToken) > 0 {
+		opts.SetStartAfter(bson.Raw(resumeToken))
+	}
+
+	stream, err := w.client.Database(w.databaseName).Watch(ctx, w.pipeline, opts)
+	if err != nil {
+		return watchError(err)
+	}
+
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var change changeDocument
+		if err := stream.Decode(&change); err != nil {
+			return fmt.Errorf("failed to decode change: %w", err)
+		}
+
+		// The document of an update may be gone by the time it is looked up
+		if change.FullDocument == nil {
+			continue
+		}
+
+		event, err := w.decode(change.FullDocument)
+		if err != nil {
+			return err
+		}
+
+		event.ResumeToken = stream.ResumeToken()
+
+		if err := handle(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return watchError(stream.Err())
+}
+
+// watchError maps the server errors of change streams to domain errors.
+func watchError(err error) error {
+	var serverErr mongo.ServerError
+
+	switch {
+	case err == nil:
+		return nil
+	case !errors.As(err, &serverErr):
+		return fmt.Errorf("change stream failed: %w", err)
+	case serverErr.HasErrorCode(codeNotReplicaSet):
+		return fmt.Errorf("%w: %w", domain.ErrChangeStreamUnsupported, err)
+	case serverErr.HasErrorCode(codeChangeStreamHistoryLost), serverErr.HasErrorCode(codeChangeStreamFatal):
+		return fmt.Errorf("%w: %w", domain.ErrResumeTokenLost, err)
+	default:
+		return fmt.Errorf("change stream failed: %w", err)
+	}
+}
@@ -0,0 +1,78 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Lua scripts of the leader lease. The fencing counter is only incremented
+// when the lease is taken, so every term gets a higher token.
+var (
+	acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0`)
+
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// FenceKey is the key of the fencing counter of the lease at lockKey.
+func FenceKey(lockKey string) string {
+	return lockKey + ":fence"
+}
+
+// RedisLeaderLock is a lease in Redis: a key holding the owner that expires
+// unless it is renewed, and a counter handing out fencing tokens.
+type RedisLeaderLock struct {
+	client *redis.Client
+	key    string
+}
+
+func NewRedisLeaderLock(addr, key string) *RedisLeaderLock {
+	return &RedisLeaderLock{
+		client: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+		key: key,
+	}
+}
+
+func (l *RedisLeaderLock) Acquire(ctx context.Context, owner string, ttl time.Duration) (int64, error) {
+	token, err := acquireScript.Run(ctx, l.client, []string{l.key, FenceKey(l.key)}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire leader lock: %w", err)
+	}
+
+	return token, nil
+}
+
+func (l *RedisLeaderLock) Renew(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew leader lock: %w", err)
+	}
+
+	return renewed == 1, nil
+}
+
+func (l *RedisLeaderLock) Release(ctx context.Context, owner string) error {
+	if err := releaseScript.Run(ctx, l.client, []string{l.key}, owner).Err(); err != nil {
+		return fmt.Errorf("failed to release leader lock: %w", err)
+	}
+
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 
 const (
 	repositoryTimeout = 10 * time.Second
+	depthCollection   = "depth"
 )
 
 type MongoOrderBookRepository struct {
@@ -37,16 +38,24 @@ func NewMongoOrderBookRepository(uri, dbName, collection string) (*MongoOrderBoo
 	}, nil
 }
 
-func (r *MongoOrderBookRepository) GetLatestRecords(ctx context.Context, limit int) ([]domain.OrderBookRecord, error) {
-	r.collection = "depth"
+// Client returns the underlying client so other repositories can share it.
+func (r *MongoOrderBookRepository) Client() *mongo.Client {
+	return r.client
+}
 
-	collection := r.client.Database(r.databaseName).Collection(r.collection)
+// GetLatestRecords returns the latest limit depth updates of symbol, newest first.
+func (r *MongoOrderBookRepository) GetLatestRecords(
+	ctx context.Context,
+	symbol string,
+	limit int,
+) ([]domain.OrderBookRecord, error) {
+	collection := r.client.Database(r.databaseName).Collection(depthCollection)
 
 	findOptions := options.Find()
 	findOptions.SetSort(bson.D{{Key: "timestamp", Value: -1}})
 	findOptions.SetLimit(int64(limit))
 
-	cursor, err := collection.Find(ctx, bson.D{}, findOptions)
+	cursor, err := collection.Find(ctx, bson.M{"data.symbol": symbol}, findOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch records: %w", err)
 	}
@@ -59,16 +68,3 @@ func (r *MongoOrderBookRepository) GetLatestRecords(ctx context.Context, limit i
 
 	return records, nil
 }
-
-func (r *MongoOrderBookRepository) SaveSignal(ctx context.Context, signal domain.TradeSignal) error {
-	r.collection = "trade_signals"
-
-	collection := r.client.Database(r.databaseName).Collection(r.collection)
-
-	_, err := collection.InsertOne(ctx, signal)
-	if err != nil {
-		return fmt.Errorf("failed to insert signal: %w", err)
-	}
-
-	return nil
-}
@@ -2,36 +2,71 @@ package persistence
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
 )
 
+// indicatorFieldPrefix marks the stream fields holding indicator values, so
+// that they never collide with the signal fields.
+const indicatorFieldPrefix = "ind_"
+
+// fencedAddScript adds a stream entry unless a newer leadership term than
+// the fencing token in ARGV[1] began, with the fields following it.
+var fencedAddScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[2]))
+if current and current > tonumber(ARGV[1]) then
+	return false
+end
+return redis.call("XADD", KEYS[1], "*", unpack(ARGV, 2))`)
+
+// RedisSignalPublisher adds signals to a Redis stream. Signals published with
+// a fencing token in the context are rejected once a newer leader was elected.
 type RedisSignalPublisher struct {
 	client    *redis.Client
 	streamKey string
+	fenceKey  string
 }
 
-func NewRedisSignalPublisher(addr, streamKey string) *RedisSignalPublisher {
+func NewRedisSignalPublisher(addr, streamKey, fenceKey string) *RedisSignalPublisher {
 	return &RedisSignalPublisher{
 		client: redis.NewClient(&redis.Options{
 			Addr: addr,
 		}),
 		streamKey: streamKey,
+		fenceKey:  fenceKey,
 	}
 }
 
 func (p *RedisSignalPublisher) PublishSignal(ctx context.Context, signal domain.TradeSignal) error {
+	values := map[string]interface{}{
+		"signalId":   signal.ID,
+		"signal":     signal.Signal,
+		"strength":   strconv.FormatFloat(signal.Strength, 'f', -1, 64),
+		"symbol":     signal.Symbol,
+		"timeframe":  string(signal.Timeframe),
+		"price":      strconv.FormatFloat(signal.Price, 'f', -1, 64),
+		"strategy":   signal.Strategy,
+		"strategyId": signal.StrategyID,
+		"time":       signal.Timestamp.Format(time.RFC3339),
+	}
+
+	// Indicator values are prefixed fields, e.g. ind_shortSMA and ind_longSMA for the SMA crossover.
+	for name, value := range signal.Indicators {
+		values[indicatorFieldPrefix+name] = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	if token, ok := domain.FencingToken(ctx); ok && p.fenceKey != "" {
+		return p.fencedAdd(ctx, token, values)
+	}
+
 	_, err := p.client.XAdd(ctx, &redis.XAddArgs{
 		Stream: p.streamKey,
-		Values: map[string]interface{}{
-			"signal":   signal.Signal,
-			"shortSMA": signal.ShortSMA,
-			"longSMA":  signal.LongSMA,
-			"time":     signal.Timestamp.Format(time.RFC3339),
-		},
+		Values: values,
 	}).Result()
 	if err != nil {
 		return fmt.Errorf("failed to add signal to Redis Stream: %w", err)
@@ -39,3 +74,23 @@ func (p *RedisSignalPublisher) PublishSignal(ctx context.Context, signal domain.
 
 	return nil
 }
+
+func (p *RedisSignalPublisher) fencedAdd(ctx context.Context, token int64, values map[string]interface{}) error {
+	args := make([]interface{}, 0, 1+2*len(values))
+	args = append(args, token)
+
+	for name, value := range values {
+		args = append(args, name, value)
+	}
+
+	err := fencedAddScript.Run(ctx, p.client, []string{p.streamKey, p.fenceKey}, args...).Err()
+	if errors.Is(err, redis.Nil) {
+		return domain.ErrStaleFencingToken
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to add signal to Redis Stream: %w", err)
+	}
+
+	return nil
+}
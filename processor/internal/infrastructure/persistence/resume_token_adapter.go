@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// resumeTokenDocument is the stored position of one change stream.
+type resumeTokenDocument struct {
+	Stream    string    `bson:"_id"`
+	Token     bson.Raw  `bson:"token"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+// MongoResumeTokenRepository keeps one resume token document per change stream.
+type MongoResumeTokenRepository struct {
+	client       *mongo.Client
+	databaseName string
+	collection   string
+}
+
+func NewMongoResumeTokenRepository(client *mongo.Client, dbName, collection string) *MongoResumeTokenRepository {
+	return &MongoResumeTokenRepository{
+		client:       client,
+		databaseName: dbName,
+		collection:   collection,
+	}
+}
+
+// GetResumeToken returns the stored token of stream, nil without one.
+func (r *MongoResumeTokenRepository) GetResumeToken(ctx context.Context, stream string) ([]byte, error) {
+	collection := r.client.Database(r.databaseName).Collection(r.collection)
+
+	var document resumeTokenDocument
+
+	err := collection.FindOne(ctx, bson.M{"_id": stream}).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch resume token: %w", err)
+	}
+
+	return document.Token, nil
+}
+
+// SaveResumeToken stores the token of stream, an empty token removes it.
+func (r *MongoResumeTokenRepository) SaveResumeToken(ctx context.Context, stream string, token []byte) error {
+	collection := r.client.Database(r.databaseName).Collection(r.collection)
+
+	if len(token) == 0 {
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": stream}); err != nil {
+			return fmt.Errorf("failed to delete resume token: %w", err)
+		}
+
+		return nil
+	}
+
+	document := resumeTokenDocument{Stream: stream, Token: token, UpdatedAt: time.Now().UTC()}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": stream}, document, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save resume token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// outboxDocument is a stored signal with its delivery state and the fencing
+// token of the leadership term that stored it.
+type outboxDocument struct {
+	domain.TradeSignal `bson:",inline"`
+
+	FencingToken int64      `bson:"fencingToken,omitempty"`
+	Published    bool       `bson:"published"`
+	PublishedAt  *time.Time `bson:"publishedAt,omitempty"`
+	Discarded    bool       `bson:"discarded,omitempty"`
+}
+
+// MongoSignalOutbox stores signals unpublished and marks them published once
+// the relay delivered them.
+type MongoSignalOutbox struct {
+	client       *mongo.Client
+	databaseName string
+	collection   string
+}
+
+func NewMongoSignalOutbox(client *mongo.Client, dbName, collection string) (*MongoSignalOutbox, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), repositoryTimeout)
+	defer cancel()
+
+	repo := &MongoSignalOutbox{
+		client:       client,
+		databaseName: dbName,
+		collection:   collection,
+	}
+
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "published", Value: 1}, {Key: "timestamp", Value: 1}},
+	}
+
+	if _, err := repo.coll().Indexes().CreateOne(ctx, indexModel); err != nil {
+		return nil, fmt.Errorf("failed to create signal outbox index: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *MongoSignalOutbox) coll() *mongo.Collection {
+	return r.client.Database(r.databaseName).Collection(r.collection)
+}
+
+// SaveSignal stores signal as unpublished with the fencing token of ctx, if
+// any. Saving a signal again is a no-op.
+func (r *MongoSignalOutbox) SaveSignal(ctx context.Context, signal domain.TradeSignal) error {
+	token, _ := domain.FencingToken(ctx)
+
+	_, err := r.coll().InsertOne(ctx, outboxDocument{TradeSignal: signal, FencingToken: token})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to insert signal: %w", err)
+	}
+
+	return nil
+}
+
+// GetUnpublished returns up to limit unpublished signals, oldest first.
+func (r *MongoSignalOutbox) GetUnpublished(ctx context.Context, limit int) ([]domain.OutboxSignal, error) {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.coll().Find(ctx, bson.M{"published": false}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch unpublished signals: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var documents []outboxDocument
+	if err = cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode unpublished signals: %w", err)
+	}
+
+	signals := make([]domain.OutboxSignal, len(documents))
+	for i, document := range documents {
+		signals[i] = domain.OutboxSignal{Signal: document.TradeSignal, FencingToken: document.FencingToken}
+	}
+
+	return signals, nil
+}
+
+func (r *MongoSignalOutbox) MarkPublished(ctx context.Context, id string, at time.Time) error {
+	update := bson.M{"$set": bson.M{"published": true, "publishedAt": at}}
+
+	if _, err := r.coll().UpdateByID(ctx, id, update); err != nil {
+		return fmt.Errorf("failed to mark signal published: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDiscarded flags the signal discarded and takes it out of the unpublished ones.
+func (r *MongoSignalOutbox) MarkDiscarded(ctx context.Context, id string, at time.Time) error {
+	update := bson.M{"$set": bson.M{"published": true, "discarded": true, "publishedAt": at}}
+
+	if _, err := r.coll().UpdateByID(ctx, id, update); err != nil {
+		return fmt.Errorf("failed to mark signal discarded: %w", err)
+	}
+
+	return nil
+}
+
+// AdoptUnpublished moves the unpublished signals stored with an older fencing
+// token, or none, to token.
+func (r *MongoSignalOutbox) AdoptUnpublished(ctx context.Context, token int64) error {
+	filter := bson.M{
+		"published": false,
+		"$or": bson.A{
+			bson.M{"fencingToken": bson.M{"$lt": token}},
+			bson.M{"fencingToken": bson.M{"$exists": false}},
+		},
+	}
+
+	if _, err := r.coll().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"fencingToken": token}}); err != nil {
+		return fmt.Errorf("failed to adopt unpublished signals: %w", err)
+	}
+
+	return nil
+}
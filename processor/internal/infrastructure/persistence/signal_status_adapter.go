@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSignalStatusRepository keeps one status document per symbol, timeframe and strategy.
+type MongoSignalStatusRepository struct {
+	client       *mongo.Client
+	databaseName string
+	collection   string
+}
+
+func NewMongoSignalStatusRepository(client *mongo.Client, dbName, collection string) *MongoSignalStatusRepository {
+	return &MongoSignalStatusRepository{
+		client:       client,
+		databaseName: dbName,
+		collection:   collection,
+	}
+}
+
+func (r *MongoSignalStatusRepository) SaveStatus(ctx context.Context, status domain.SignalStatus) error {
+	collection := r.client.Database(r.databaseName).Collection(r.collection)
+
+	filter := bson.M{"symbol": status.Symbol, "timeframe": status.Timeframe, "strategyId": status.StrategyID}
+
+	_, err := collection.ReplaceOne(ctx, filter, status, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save signal status: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MongoSignalStatusRepository) GetStatuses(ctx context.Context) ([]domain.SignalStatus, error) {
+	collection := r.client.Database(r.databaseName).Collection(r.collection)
+
+	cursor, err := collection.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signal statuses: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var statuses []domain.SignalStatus
+	if err = cursor.All(ctx, &statuses); err != nil {
+		return nil, fmt.Errorf("failed to decode signal statuses: %w", err)
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// tradeBatchSize is the number of trades fetched per cursor round trip.
+const tradeBatchSize = 1000
+
+// MongoTradeRepository reads the trades stored by the collector.
+type MongoTradeRepository struct {
+	client       *mongo.Client
+	databaseName string
+	collection   string
+}
+
+func NewMongoTradeRepository(client *mongo.Client, dbName, collection string) *MongoTradeRepository {
+	return &MongoTradeRepository{
+		client:       client,
+		databaseName: dbName,
+		collection:   collection,
+	}
+}
+
+// GetTrades returns the trades of symbol executed in [from, to), oldest first.
+func (r *MongoTradeRepository) GetTrades(
+	ctx context.Context,
+	symbol string,
+	from, to time.Time,
+) ([]domain.Trade, error) {
+	cursor, err := r.find(ctx, symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var trades []domain.Trade
+	if err = cursor.All(ctx, &trades); err != nil {
+		return nil, fmt.Errorf("failed to decode trades: %w", err)
+	}
+
+	return trades, nil
+}
+
+// EachTrade calls fn for the trades of symbol executed in [from, to), oldest
+// first, decoding one cursor batch at a time.
+func (r *MongoTradeRepository) EachTrade(
+	ctx context.Context,
+	symbol string,
+	from, to time.Time,
+	fn func(trade domain.Trade) error,
+) error {
+	cursor, err := r.find(ctx, symbol, from, to)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var trade domain.Trade
+		if err := cursor.Decode(&trade); err != nil {
+			return fmt.Errorf("failed to decode trade: %w", err)
+		}
+
+		if err := fn(trade); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate trades: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MongoTradeRepository) find(ctx context.Context, symbol string, from, to time.Time) (*mongo.Cursor, error) {
+	collection := r.client.Database(r.databaseName).Collection(r.collection)
+
+	filter := bson.M{
+		"symbol":    symbol,
+		"tradeTime": bson.M{"$gte": from, "$lt": to},
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "tradeTime", Value: 1}, {Key: "tradeId", Value: 1}}).
+		SetBatchSize(tradeBatchSize)
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trades: %w", err)
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,103 @@
+// Package report writes backtest and optimization results as JSON and CSV files.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+)
+
+const dirPermissions = 0o755
+
+// WriteBacktest writes result.json, equity.csv and trades.csv to dir.
+func WriteBacktest(dir string, result domain.BacktestResult) error {
+	if err := os.MkdirAll(dir, dirPermissions); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	files := map[string]func(io.Writer, domain.BacktestResult) error{
+		"result.json": WriteJSON,
+		"equity.csv":  WriteEquityCSV,
+		"trades.csv":  WriteTradesCSV,
+	}
+
+	for name, write := range files {
+		if err := writeFile(filepath.Join(dir, name), result, write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeFile[T any](path string, result T, write func(io.Writer, T) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+
+	if err := write(file, result); err != nil {
+		file.Close()
+
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return file.Close()
+}
+
+// WriteJSON writes the whole result, metrics included.
+func WriteJSON(w io.Writer, result domain.BacktestResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(result)
+}
+
+// WriteEquityCSV writes the equity curve.
+func WriteEquityCSV(w io.Writer, result domain.BacktestResult) error {
+	rows := [][]string{{"time", "equity", "position"}}
+
+	for _, point := range result.Equity {
+		rows = append(rows, []string{
+			point.Time.Format(time.RFC3339),
+			formatFloat(point.Equity),
+			formatFloat(point.Position),
+		})
+	}
+
+	return csv.NewWriter(w).WriteAll(rows)
+}
+
+// WriteTradesCSV writes the trade list.
+func WriteTradesCSV(w io.Writer, result domain.BacktestResult) error {
+	rows := [][]string{{
+		"side", "entryTime", "entryPrice", "exitTime", "exitPrice", "quantity", "fees", "pnl", "return",
+	}}
+
+	for _, trade := range result.Trades {
+		rows = append(rows, []string{
+			trade.Side,
+			trade.EntryTime.Format(time.RFC3339),
+			formatFloat(trade.EntryPrice),
+			trade.ExitTime.Format(time.RFC3339),
+			formatFloat(trade.ExitPrice),
+			formatFloat(trade.Quantity),
+			formatFloat(trade.Fees),
+			formatFloat(trade.PnL),
+			formatFloat(trade.Return),
+		})
+	}
+
+	return csv.NewWriter(w).WriteAll(rows)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
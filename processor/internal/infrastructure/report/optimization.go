@@ -0,0 +1,62 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mkaganm/algo-trade/processor/internal/core/domain"
+)
+
+// WriteOptimization writes optimization.json and the ranking as runs.csv to dir.
+func WriteOptimization(dir string, result domain.OptimizationResult) error {
+	if err := os.MkdirAll(dir, dirPermissions); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	files := map[string]func(io.Writer, domain.OptimizationResult) error{
+		"optimization.json": func(w io.Writer, result domain.OptimizationResult) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+
+			return encoder.Encode(result)
+		},
+		"runs.csv": WriteRunsCSV,
+	}
+
+	for name, write := range files {
+		if err := writeFile(filepath.Join(dir, name), result, write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteRunsCSV writes the ranked parameter sets.
+func WriteRunsCSV(w io.Writer, result domain.OptimizationResult) error {
+	rows := [][]string{{
+		"rank", "strategyId", "score", "totalReturn", "sharpe", "sortino", "maxDrawdown", "winRate", "exposure", "trades",
+	}}
+
+	for i, run := range result.Runs {
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			run.StrategyID,
+			formatFloat(run.Score),
+			formatFloat(run.Metrics.TotalReturn),
+			formatFloat(run.Metrics.Sharpe),
+			formatFloat(run.Metrics.Sortino),
+			formatFloat(run.Metrics.MaxDrawdown),
+			formatFloat(run.Metrics.WinRate),
+			formatFloat(run.Metrics.Exposure),
+			strconv.Itoa(run.Metrics.Trades),
+		})
+	}
+
+	return csv.NewWriter(w).WriteAll(rows)
+}
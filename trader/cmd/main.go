@@ -3,14 +3,27 @@ package main
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
+	"github.com/mkaganm/algo-trade/trader/internal/adapters/binance"
 	"github.com/mkaganm/algo-trade/trader/internal/adapters/http"
+	"github.com/mkaganm/algo-trade/trader/internal/adapters/mongoadapter"
+	"github.com/mkaganm/algo-trade/trader/internal/adapters/paper"
 	"github.com/mkaganm/algo-trade/trader/internal/adapters/redisdapter"
 	"github.com/mkaganm/algo-trade/trader/internal/app"
 	"github.com/mkaganm/algo-trade/trader/internal/config"
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+	"github.com/mkaganm/algo-trade/trader/internal/ports"
 	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	exchangeTimeout     = 10 * time.Second
+	mongoConnectTimeout = 10 * time.Second
 )
 
 func main() {
@@ -25,11 +38,134 @@ func main() {
 
 	// Initialize repository and use case
 	redisRepo := redisdapter.NewRedisRepository(rdb)
-	messageProcessor := app.NewMessageProcessor(redisRepo)
+
+	// MongoDB client, the orders are recorded when it is configured
+	var orderRepo ports.OrderRepository
+
+	var mongoClient *mongo.Client
+
+	if cfg.MongoURI != "" {
+		connectCtx, cancel := context.WithTimeout(context.Background(), mongoConnectTimeout)
+
+		client, err := mongo.Connect(connectCtx, options.Client().ApplyURI(cfg.MongoURI))
+
+		cancel()
+
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+
+		defer func() {
+			if err := client.Disconnect(context.Background()); err != nil {
+				log.Printf("Failed to disconnect MongoDB client: %v", err)
+			}
+		}()
+
+		mongoClient = client
+
+		orderRepo, err = mongoadapter.NewMongoOrderRepository(client, cfg.DatabaseName, cfg.OrderColName)
+		if err != nil {
+			log.Fatalf("Failed to initialize order repository: %v", err)
+		}
+	}
 
 	// Initialize cron job
 	c := cron.New()
 
+	var exchange ports.Exchange
+
+	switch cfg.Exchange {
+	case config.ExchangeBinance:
+		exchange = binance.NewSpotClient(
+			cfg.BinanceBaseURL,
+			cfg.BinanceAPIKey,
+			cfg.BinanceAPISecret,
+			cfg.BinanceRecvWindow,
+			exchangeTimeout,
+		)
+
+		if orderRepo != nil {
+			exchange = app.NewRecordingExchange(exchange, orderRepo, domain.ModeLive)
+		}
+	case config.ExchangePaper:
+		if mongoClient == nil {
+			log.Fatal("Paper trading needs MONGO_URI for the order book and the results")
+		}
+
+		paperExchange := paper.NewExchange(
+			mongoadapter.NewMongoBookRepository(mongoClient, cfg.DatabaseName, cfg.SnapshotColName),
+			orderRepo,
+			cfg.PaperBalances,
+			paper.Config{
+				MakerFee:   cfg.PaperMakerFee,
+				TakerFee:   cfg.PaperTakerFee,
+				Latency:    cfg.PaperLatency,
+				MaxBookAge: cfg.PaperMaxBookAge,
+			},
+		)
+
+		// The balances continue from the orders recorded by previous runs
+		restoreCtx, cancel := context.WithTimeout(context.Background(), exchangeTimeout)
+
+		err := paperExchange.Restore(restoreCtx)
+
+		cancel()
+
+		if err != nil {
+			log.Fatalf("Failed to restore the paper exchange: %v", err)
+		}
+
+		// Resting limit orders fill once the book crosses them
+		_, err = c.AddFunc("@every "+cfg.PaperMatchPeriod.String(), func() {
+			if err := paperExchange.Match(context.Background()); err != nil {
+				log.Printf("Failed to match paper orders: %v", err)
+			}
+		})
+		if err != nil {
+			log.Printf("Failed to add paper matching job: %v", err)
+		}
+
+		exchange = paperExchange
+	}
+
+	// The portfolio is kept per mode and reconciled with the exchange balances on startup
+	var portfolio *app.PortfolioManager
+
+	if exchange != nil {
+		mode := domain.ModeLive
+		if cfg.Exchange == config.ExchangePaper {
+			mode = domain.ModePaper
+		}
+
+		portfolio = app.NewPortfolioManager(
+			redisdapter.NewRedisPortfolioRepository(rdb, redisdapter.PortfolioKeyPrefix+mode),
+			exchange,
+			cfg.QuoteAsset,
+			cfg.DustValue,
+		)
+
+		reconcileCtx, cancel := context.WithTimeout(context.Background(), exchangeTimeout)
+
+		err := portfolio.Reconcile(reconcileCtx)
+
+		cancel()
+
+		if err != nil {
+			log.Fatalf("Failed to reconcile the portfolio: %v", err)
+		}
+	}
+
+	messageProcessor := app.NewMessageProcessor(
+		redisRepo,
+		exchange,
+		portfolio,
+		cfg.MinSignalStrength,
+		cfg.OrderQuoteAmount,
+	)
+
+	// Messages the previous run read but did not finish are handled first
+	messageProcessor.Recover(context.Background())
+
 	_, err := c.AddFunc("@every 5s", func() {
 		messageProcessor.ProcessMessages(context.Background())
 	})
@@ -48,6 +184,10 @@ func main() {
 	healthHandler := http.NewHealthHandler(redisRepo)
 	healthHandler.RegisterRoutes(app)
 
+	if portfolio != nil {
+		http.NewPortfolioHandler(portfolio).RegisterRoutes(app)
+	}
+
 	// Start the server
 	log.Printf("Starting server on port %s...", cfg.AppPort)
 	log.Println(app.Listen(":" + cfg.AppPort))
@@ -0,0 +1,92 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+)
+
+// Binance error codes the adapter tells apart.
+const (
+	codeUnknown          = -1000
+	codeDisconnected     = -1001
+	codeTooManyRequests  = -1003
+	codeTimeout          = -1007
+	codeInvalidTimestamp = -1021
+	codeInvalidSignature = -1022
+	codeInvalidQuantity  = -1013
+	codeNewOrderRejected = -2010
+	codeCancelRejected   = -2011
+	codeNoSuchOrder      = -2013
+	codeBadAPIKeyFormat  = -2014
+	codeRejectedAPIKey   = -2015
+	// -1100 to -1130 are request parameter errors, e.g. -1111 for a too precise quantity
+	codeParameterFirst = -1100
+	codeParameterLast  = -1130
+	statusIPBanned     = 418
+)
+
+// APIError is an error response of the Binance API. It unwraps to the
+// matching exchange error of the domain package, if any.
+type APIError struct {
+	StatusCode int
+	Code       int    `json:"code"`
+	Message    string `json:"msg"`
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("binance error %d (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Unwrap maps the error code, or the HTTP status without one, to a domain error.
+func (e *APIError) Unwrap() error {
+	switch e.Code {
+	case codeTooManyRequests:
+		return domain.ErrRateLimited
+	case codeInvalidTimestamp:
+		return domain.ErrInvalidTimestamp
+	case codeInvalidSignature, codeBadAPIKeyFormat, codeRejectedAPIKey:
+		return domain.ErrUnauthorized
+	case codeNoSuchOrder, codeCancelRejected:
+		return domain.ErrOrderNotFound
+	case codeNewOrderRejected:
+		if strings.Contains(strings.ToLower(e.Message), "insufficient balance") {
+			return domain.ErrInsufficientBalance
+		}
+
+		return domain.ErrOrderRejected
+	case codeInvalidQuantity:
+		return domain.ErrInvalidOrder
+	case codeTimeout:
+		// The backend may have executed the request anyway
+		return domain.ErrOrderStatusUnknown
+	case codeUnknown, codeDisconnected:
+		return domain.ErrExchangeUnavailable
+	}
+
+	switch {
+	case e.Code <= codeParameterFirst && e.Code >= codeParameterLast:
+		return domain.ErrInvalidOrder
+	case e.StatusCode == http.StatusTooManyRequests || e.StatusCode == statusIPBanned:
+		return domain.ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return domain.ErrUnauthorized
+	case e.StatusCode >= http.StatusInternalServerError:
+		return domain.ErrExchangeUnavailable
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+)
+
+// Filter types of the exchange info the adapter applies.
+const (
+	filterLotSize = "LOT_SIZE"
+	filterPrice   = "PRICE_FILTER"
+)
+
+// stepTolerance absorbs the float error of values that are whole multiples
+// of their step, so they are not floored a step too low.
+const stepTolerance = 1e-9
+
+type exchangeInfoResponse struct {
+	Symbols []struct {
+		Symbol              string `json:"symbol"`
+		QuoteAssetPrecision int    `json:"quoteAssetPrecision"`
+		Filters             []struct {
+			FilterType string `json:"filterType"`
+			StepSize   string `json:"stepSize"`
+			TickSize   string `json:"tickSize"`
+		} `json:"filters"`
+	} `json:"symbols"`
+}
+
+// symbolFilters are the steps order values of a symbol must be multiples of.
+type symbolFilters struct {
+	lotSize  step
+	tickSize step
+	quote    step
+}
+
+// step is the increment of a decimal value. The zero step leaves values as
+// they are.
+type step struct {
+	size     float64
+	decimals int
+	text     string
+}
+
+// parseStep parses a step such as "0.00100000", zero disables it.
+func parseStep(text string) (step, error) {
+	size, err := strconv.ParseFloat(text, 64)
+	if err != nil || size < 0 {
+		return step{}, fmt.Errorf("invalid step %q", text)
+	}
+
+	if size == 0 {
+		return step{}, nil
+	}
+
+	decimals := 0
+	if _, fraction, found := strings.Cut(strings.TrimRight(text, "0"), "."); found {
+		decimals = len(fraction)
+	}
+
+	return step{size: size, decimals: decimals, text: text}, nil
+}
+
+// precisionStep is the step of a value with precision decimals.
+func precisionStep(precision int) step {
+	if precision <= 0 {
+		return step{}
+	}
+
+	return step{size: math.Pow10(-precision), decimals: precision, text: strconv.Itoa(precision) + " decimals"}
+}
+
+// floor formats value floored to a multiple of the step. ok is false when
+// that leaves nothing.
+func (s step) floor(value float64) (string, bool) {
+	if s.size == 0 {
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	}
+
+	steps := math.Floor(value/s.size + stepTolerance)
+	if steps <= 0 {
+		return "", false
+	}
+
+	return strconv.FormatFloat(steps*s.size, 'f', s.decimals, 64), true
+}
+
+func (s step) String() string {
+	return s.text
+}
+
+// symbolFilters returns the filters of symbol, loading them on first use.
+func (c *SpotClient) symbolFilters(ctx context.Context, symbol string) (symbolFilters, error) {
+	c.mu.Lock()
+	filters, ok := c.filters[symbol]
+	c.mu.Unlock()
+
+	if ok {
+		return filters, nil
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+
+	var response exchangeInfoResponse
+	if err := c.do(ctx, http.MethodGet, exchangeInfoPath, params, false, &response); err != nil {
+		return symbolFilters{}, err
+	}
+
+	filters, err := response.filters(symbol)
+	if err != nil {
+		return symbolFilters{}, err
+	}
+
+	c.mu.Lock()
+	c.filters[symbol] = filters
+	c.mu.Unlock()
+
+	return filters, nil
+}
+
+func (r exchangeInfoResponse) filters(symbol string) (symbolFilters, error) {
+	for _, info := range r.Symbols {
+		if info.Symbol != symbol {
+			continue
+		}
+
+		filters := symbolFilters{quote: precisionStep(info.QuoteAssetPrecision)}
+
+		for _, filter := range info.Filters {
+			var err error
+
+			switch filter.FilterType {
+			case filterLotSize:
+				filters.lotSize, err = parseStep(filter.StepSize)
+			case filterPrice:
+				filters.tickSize, err = parseStep(filter.TickSize)
+			}
+
+			if err != nil {
+				return symbolFilters{}, fmt.Errorf("%s filter of %s: %w", filter.FilterType, symbol, err)
+			}
+		}
+
+		return filters, nil
+	}
+
+	return symbolFilters{}, fmt.Errorf("%w: unknown symbol %q", domain.ErrInvalidOrder, symbol)
+}
@@ -0,0 +1,389 @@
+// Package binance implements the exchange port on the Binance Spot REST API.
+package binance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+)
+
+const (
+	orderPath        = "/api/v3/order"
+	openOrdersPath   = "/api/v3/openOrders"
+	accountPath      = "/api/v3/account"
+	timePath         = "/api/v3/time"
+	exchangeInfoPath = "/api/v3/exchangeInfo"
+	apiKeyHeader     = "X-MBX-APIKEY"
+)
+
+// SpotClient places and queries orders with signed requests. Request
+// timestamps follow the server clock: the offset is measured again whenever
+// the server rejects a timestamp and the request is retried once. Order
+// quantities are floored to the filters of their symbol, loaded once.
+type SpotClient struct {
+	baseURL    string
+	apiKey     string
+	secret     []byte
+	recvWindow time.Duration
+	httpClient *http.Client
+
+	// offset is the server clock minus the local clock in milliseconds
+	offset atomic.Int64
+
+	mu      sync.Mutex
+	filters map[string]symbolFilters
+}
+
+func NewSpotClient(baseURL, apiKey, secret string, recvWindow, timeout time.Duration) *SpotClient {
+	return &SpotClient{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		secret:     []byte(secret),
+		recvWindow: recvWindow,
+		httpClient: &http.Client{Timeout: timeout},
+		filters:    make(map[string]symbolFilters),
+	}
+}
+
+// orderResponse is an order as returned by the order endpoints. Decimal
+// values are strings.
+type orderResponse struct {
+	Symbol              string `json:"symbol"`
+	OrderID             int64  `json:"orderId"`
+	ClientOrderID       string `json:"clientOrderId"`
+	Price               string `json:"price"`
+	OrigQty             string `json:"origQty"`
+	ExecutedQty         string `json:"executedQty"`
+	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
+	Status              string `json:"status"`
+	Type                string `json:"type"`
+	Side                string `json:"side"`
+	Time                int64  `json:"time"`
+	TransactTime        int64  `json:"transactTime"`
+	Fills               []struct {
+		Commission      string `json:"commission"`
+		CommissionAsset string `json:"commissionAsset"`
+	} `json:"fills"`
+}
+
+type accountResponse struct {
+	Balances []struct {
+		Asset  string `json:"asset"`
+		Free   string `json:"free"`
+		Locked string `json:"locked"`
+	} `json:"balances"`
+}
+
+// PlaceOrder places request with its quantities floored to the filters of
+// the symbol. A request that timed out returns ErrOrderStatusUnknown, the
+// order may exist then.
+func (c *SpotClient) PlaceOrder(ctx context.Context, request domain.OrderRequest) (domain.Order, error) {
+	filters, err := c.symbolFilters(ctx, request.Symbol)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	params := url.Values{}
+	params.Set("symbol", request.Symbol)
+	params.Set("side", request.Side)
+	params.Set("type", request.Type)
+	// FULL returns the executed quantities of market orders right away
+	params.Set("newOrderRespType", "FULL")
+
+	decimals := []struct {
+		name  string
+		value float64
+		step  step
+	}{
+		{"quantity", request.Quantity, filters.lotSize},
+		{"quoteOrderQty", request.QuoteQuantity, filters.quote},
+		{"price", request.Price, filters.tickSize},
+	}
+
+	for _, decimal := range decimals {
+		if decimal.value <= 0 {
+			continue
+		}
+
+		value, ok := decimal.step.floor(decimal.value)
+		if !ok {
+			return domain.Order{}, fmt.Errorf("%w: %s %g of %s is below the step %s",
+				domain.ErrInvalidOrder, decimal.name, decimal.value, request.Symbol, decimal.step)
+		}
+
+		params.Set(decimal.name, value)
+	}
+
+	if request.TimeInForce != "" {
+		params.Set("timeInForce", request.TimeInForce)
+	}
+
+	if request.ClientOrderID != "" {
+		params.Set("newClientOrderId", request.ClientOrderID)
+	}
+
+	var response orderResponse
+	if err := c.signed(ctx, http.MethodPost, orderPath, params, &response); err != nil {
+		return domain.Order{}, err
+	}
+
+	return response.order()
+}
+
+func (c *SpotClient) CancelOrder(ctx context.Context, symbol string, orderID int64) (domain.Order, error) {
+	var response orderResponse
+	if err := c.signed(ctx, http.MethodDelete, orderPath, orderParams(symbol, orderID), &response); err != nil {
+		return domain.Order{}, err
+	}
+
+	return response.order()
+}
+
+func (c *SpotClient) GetOrder(ctx context.Context, symbol string, orderID int64) (domain.Order, error) {
+	var response orderResponse
+	if err := c.signed(ctx, http.MethodGet, orderPath, orderParams(symbol, orderID), &response); err != nil {
+		return domain.Order{}, err
+	}
+
+	return response.order()
+}
+
+// GetOrderByClientID looks the order up by the client order ID it was placed with.
+func (c *SpotClient) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (domain.Order, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("origClientOrderId", clientOrderID)
+
+	var response orderResponse
+	if err := c.signed(ctx, http.MethodGet, orderPath, params, &response); err != nil {
+		return domain.Order{}, err
+	}
+
+	return response.order()
+}
+
+// OpenOrders returns the open orders of symbol, of every symbol when empty.
+func (c *SpotClient) OpenOrders(ctx context.Context, symbol string) ([]domain.Order, error) {
+	params := url.Values{}
+	if symbol != "" {
+		params.Set("symbol", symbol)
+	}
+
+	var responses []orderResponse
+	if err := c.signed(ctx, http.MethodGet, openOrdersPath, params, &responses); err != nil {
+		return nil, err
+	}
+
+	orders := make([]domain.Order, 0, len(responses))
+
+	for _, response := range responses {
+		order, err := response.order()
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// Balances returns the assets with a free or locked amount.
+func (c *SpotClient) Balances(ctx context.Context) ([]domain.Balance, error) {
+	params := url.Values{}
+	params.Set("omitZeroBalances", "true")
+
+	var response accountResponse
+	if err := c.signed(ctx, http.MethodGet, accountPath, params, &response); err != nil {
+		return nil, err
+	}
+
+	balances := make([]domain.Balance, 0, len(response.Balances))
+
+	for _, balance := range response.Balances {
+		free, err := strconv.ParseFloat(balance.Free, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid free balance of %s: %w", balance.Asset, err)
+		}
+
+		locked, err := strconv.ParseFloat(balance.Locked, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locked balance of %s: %w", balance.Asset, err)
+		}
+
+		balances = append(balances, domain.Balance{Asset: balance.Asset, Free: free, Locked: locked})
+	}
+
+	return balances, nil
+}
+
+// SyncTime measures the offset of the server clock.
+func (c *SpotClient) SyncTime(ctx context.Context) error {
+	var response struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+
+	sent := time.Now()
+
+	if err := c.do(ctx, http.MethodGet, timePath, nil, false, &response); err != nil {
+		return err
+	}
+
+	// The server read its clock about halfway through the round trip
+	local := sent.Add(time.Since(sent) / 2).UnixMilli()
+	c.offset.Store(response.ServerTime - local)
+
+	return nil
+}
+
+// signed sends a signed request, syncing the clock and retrying once when the
+// server rejects the timestamp.
+func (c *SpotClient) signed(ctx context.Context, method, path string, params url.Values, out any) error {
+	err := c.do(ctx, method, path, params, true, out)
+	if !errors.Is(err, domain.ErrInvalidTimestamp) {
+		return err
+	}
+
+	if syncErr := c.SyncTime(ctx); syncErr != nil {
+		return errors.Join(err, syncErr)
+	}
+
+	return c.do(ctx, method, path, params, true, out)
+}
+
+func (c *SpotClient) do(ctx context.Context, method, path string, params url.Values, sign bool, out any) error {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+
+	if sign {
+		query.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli()+c.offset.Load(), 10))
+		query.Set("recvWindow", strconv.FormatInt(c.recvWindow.Milliseconds(), 10))
+	}
+
+	payload := query.Encode()
+
+	// The signature covers the payload exactly as sent and comes last
+	if sign {
+		payload += "&signature=" + c.signature(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path+"?"+payload, nil)
+	if err != nil {
+		return err
+	}
+
+	if sign {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// A request that timed out may have been executed, unlike a refused one
+		if method != http.MethodGet && timedOut(err) {
+			return fmt.Errorf("%w: %w", domain.ErrOrderStatusUnknown, err)
+		}
+
+		return fmt.Errorf("%w: %w", domain.ErrExchangeUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// signature is the HMAC-SHA256 of the query string with the API secret.
+func (c *SpotClient) signature(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func orderParams(symbol string, orderID int64) url.Values {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", strconv.FormatInt(orderID, 10))
+
+	return params
+}
+
+func (r orderResponse) order() (domain.Order, error) {
+	order := domain.Order{
+		ID:            r.OrderID,
+		ClientOrderID: r.ClientOrderID,
+		Symbol:        r.Symbol,
+		Side:          r.Side,
+		Type:          r.Type,
+		Status:        r.Status,
+		Time:          time.UnixMilli(max(r.Time, r.TransactTime)).UTC(),
+	}
+
+	decimals := []struct {
+		value  string
+		target *float64
+	}{
+		{r.Price, &order.Price},
+		{r.OrigQty, &order.Quantity},
+		{r.ExecutedQty, &order.ExecutedQuantity},
+		{r.CummulativeQuoteQty, &order.QuoteQuantity},
+	}
+
+	for _, decimal := range decimals {
+		if decimal.value == "" {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(decimal.value, 64)
+		if err != nil {
+			return domain.Order{}, fmt.Errorf("invalid decimal %q in order %d: %w", decimal.value, r.OrderID, err)
+		}
+
+		*decimal.target = value
+	}
+
+	for _, fill := range r.Fills {
+		commission, err := strconv.ParseFloat(fill.Commission, 64)
+		if err != nil {
+			return domain.Order{}, fmt.Errorf("invalid commission %q in order %d: %w", fill.Commission, r.OrderID, err)
+		}
+
+		order.Commission += commission
+		order.CommissionAsset = fill.CommissionAsset
+	}
+
+	return order, nil
+}
+
+func timedOut(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
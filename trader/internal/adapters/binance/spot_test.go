@@ -0,0 +1,246 @@
+package binance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAPIKey = "key"
+	testSecret = "secret"
+)
+
+// verifySignature checks that the signature is the last parameter and covers
+// everything before it.
+func verifySignature(t *testing.T, r *http.Request) {
+	t.Helper()
+
+	payload, signature, found := strings.Cut(r.URL.RawQuery, "&signature=")
+	require.True(t, found, "signature missing from %q", r.URL.RawQuery)
+
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(payload))
+
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), signature)
+	assert.Equal(t, testAPIKey, r.Header.Get(apiKeyHeader))
+	assert.NotEmpty(t, r.URL.Query().Get("timestamp"))
+	assert.Equal(t, "5000", r.URL.Query().Get("recvWindow"))
+}
+
+func newTestClient(url string) *SpotClient {
+	return NewSpotClient(url, testAPIKey, testSecret, 5*time.Second, time.Second)
+}
+
+// serveExchangeInfo answers exchange info requests with the filters of BTCUSDT
+// and reports whether r was one.
+func serveExchangeInfo(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Path != exchangeInfoPath {
+		return false
+	}
+
+	_, _ = fmt.Fprint(w, `{"symbols":[{"symbol":"BTCUSDT","quoteAssetPrecision":8,"filters":[
+		{"filterType":"PRICE_FILTER","minPrice":"0.01000000","tickSize":"0.01000000"},
+		{"filterType":"LOT_SIZE","minQty":"0.00001000","stepSize":"0.00001000"}]}]}`)
+
+	return true
+}
+
+func TestSpotClientPlacesMarketOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serveExchangeInfo(w, r) {
+			return
+		}
+
+		verifySignature(t, r)
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, orderPath, r.URL.Path)
+
+		query := r.URL.Query()
+		assert.Equal(t, "BTCUSDT", query.Get("symbol"))
+		assert.Equal(t, domain.Buy, query.Get("side"))
+		assert.Equal(t, domain.OrderTypeMarket, query.Get("type"))
+		assert.Equal(t, "50.50000000", query.Get("quoteOrderQty"))
+		assert.Equal(t, "signal-1", query.Get("newClientOrderId"))
+		assert.Empty(t, query.Get("quantity"))
+
+		_, _ = fmt.Fprint(w, `{"symbol":"BTCUSDT","orderId":28,"clientOrderId":"signal-1","transactTime":1704164645000,
+			"price":"0.00000000","origQty":"0.00100000","executedQty":"0.00100000","cummulativeQuoteQty":"50.50000000",
+			"status":"FILLED","type":"MARKET","side":"BUY","fills":[
+			{"price":"50000.00","qty":"0.00050000","commission":"0.00000050","commissionAsset":"BTC"},
+			{"price":"51000.00","qty":"0.00050000","commission":"0.00000050","commissionAsset":"BTC"}]}`)
+	}))
+	defer server.Close()
+
+	order, err := newTestClient(server.URL).PlaceOrder(context.Background(), domain.OrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          domain.Buy,
+		Type:          domain.OrderTypeMarket,
+		QuoteQuantity: 50.5,
+		ClientOrderID: "signal-1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(28), order.ID)
+	assert.Equal(t, domain.OrderStatusFilled, order.Status)
+	assert.InDelta(t, 0.001, order.ExecutedQuantity, 1e-12)
+	assert.InDelta(t, 50500, order.AveragePrice(), 1e-6)
+	assert.InDelta(t, 0.000001, order.Commission, 1e-12)
+	assert.Equal(t, "BTC", order.CommissionAsset)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), order.Time)
+}
+
+func TestSpotClientMapsErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusBadRequest, `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`,
+			domain.ErrInsufficientBalance},
+		{http.StatusBadRequest, `{"code":-2010,"msg":"Market is closed."}`, domain.ErrOrderRejected},
+		{http.StatusBadRequest, `{"code":-1111,"msg":"Precision is over the maximum defined for this asset."}`,
+			domain.ErrInvalidOrder},
+		{http.StatusBadRequest, `{"code":-2013,"msg":"Order does not exist."}`, domain.ErrOrderNotFound},
+		{http.StatusUnauthorized, `{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`,
+			domain.ErrUnauthorized},
+		{http.StatusTooManyRequests, `{"code":-1003,"msg":"Too many requests."}`, domain.ErrRateLimited},
+		{http.StatusBadGateway, `bad gateway`, domain.ErrExchangeUnavailable},
+		{http.StatusInternalServerError, `{"code":-1007,"msg":"Timeout waiting for response from backend server."}`,
+			domain.ErrOrderStatusUnknown},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(tt.status)
+			_, _ = fmt.Fprint(w, tt.body)
+		}))
+
+		_, err := newTestClient(server.URL).GetOrder(context.Background(), "BTCUSDT", 1)
+		server.Close()
+
+		require.ErrorIsf(t, err, tt.want, "response %d %s", tt.status, tt.body)
+	}
+}
+
+func TestSpotClientResyncsClockOnInvalidTimestamp(t *testing.T) {
+	serverTime := time.Now().Add(time.Hour).UnixMilli()
+	orderRequests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == timePath {
+			_, _ = fmt.Fprintf(w, `{"serverTime":%d}`, serverTime)
+
+			return
+		}
+
+		verifySignature(t, r)
+
+		orderRequests++
+
+		var timestamp int64
+		_, _ = fmt.Sscan(r.URL.Query().Get("timestamp"), &timestamp)
+
+		// Timestamps more than a second ahead of or behind the server are rejected
+		if timestamp < serverTime-1000 || timestamp > serverTime+1000 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`)
+
+			return
+		}
+
+		_, _ = fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	orders, err := newTestClient(server.URL).OpenOrders(context.Background(), "BTCUSDT")
+	require.NoError(t, err)
+
+	assert.Empty(t, orders)
+	assert.Equal(t, 2, orderRequests)
+}
+
+func TestSpotClientFloorsOrdersToTheSymbolFilters(t *testing.T) {
+	var queries []url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serveExchangeInfo(w, r) {
+			return
+		}
+
+		queries = append(queries, r.URL.Query())
+		_, _ = fmt.Fprint(w, `{"symbol":"BTCUSDT","orderId":1,"status":"NEW","type":"LIMIT","side":"SELL"}`)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+
+	_, err := client.PlaceOrder(context.Background(), domain.OrderRequest{
+		Symbol:      "BTCUSDT",
+		Side:        domain.Sell,
+		Type:        domain.OrderTypeLimit,
+		Quantity:    0.1234567891,
+		Price:       50000.129,
+		TimeInForce: "GTC",
+	})
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	assert.Equal(t, "0.12345", queries[0].Get("quantity"))
+	assert.Equal(t, "50000.12", queries[0].Get("price"))
+
+	// Nothing is left of a quantity below the step
+	_, err = client.PlaceOrder(context.Background(), domain.OrderRequest{
+		Symbol:   "BTCUSDT",
+		Side:     domain.Sell,
+		Type:     domain.OrderTypeMarket,
+		Quantity: 0.000009,
+	})
+	require.ErrorIs(t, err, domain.ErrInvalidOrder)
+	assert.Len(t, queries, 1)
+}
+
+func TestSpotClientReportsUnknownStatusOnTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serveExchangeInfo(w, r) {
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			time.Sleep(100 * time.Millisecond)
+
+			return
+		}
+
+		assert.Equal(t, "signal-1", r.URL.Query().Get("origClientOrderId"))
+		_, _ = fmt.Fprint(w, `{"symbol":"BTCUSDT","orderId":7,"clientOrderId":"signal-1","status":"FILLED"}`)
+	}))
+	defer server.Close()
+
+	client := NewSpotClient(server.URL, testAPIKey, testSecret, 5*time.Second, 50*time.Millisecond)
+
+	_, err := client.PlaceOrder(context.Background(), domain.OrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          domain.Buy,
+		Type:          domain.OrderTypeMarket,
+		QuoteQuantity: 10,
+		ClientOrderID: "signal-1",
+	})
+	require.ErrorIs(t, err, domain.ErrOrderStatusUnknown)
+
+	order, err := client.GetOrderByClientID(context.Background(), "BTCUSDT", "signal-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), order.ID)
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mkaganm/algo-trade/trader/internal/ports"
+)
+
+type PortfolioHandler struct {
+	portfolioService ports.PortfolioService
+}
+
+func NewPortfolioHandler(portfolioService ports.PortfolioService) *PortfolioHandler {
+	return &PortfolioHandler{portfolioService: portfolioService}
+}
+
+func (h *PortfolioHandler) RegisterRoutes(app *fiber.App) {
+	app.Get("/portfolio", h.Portfolio)
+}
+
+// Portfolio returns the cash, the positions with their profit and the totals.
+func (h *PortfolioHandler) Portfolio(c *fiber.Ctx) error {
+	portfolio := h.portfolioService.Portfolio()
+
+	positions := make([]fiber.Map, 0, len(portfolio.Positions))
+	for _, position := range portfolio.Positions {
+		positions = append(positions, fiber.Map{
+			"symbol":            position.Symbol,
+			"quantity":          position.Quantity,
+			"averageEntryPrice": position.AverageEntryPrice,
+			"lastPrice":         position.LastPrice,
+			"markedAt":          position.MarkedAt,
+			"realizedPnl":       position.RealizedPnL,
+			"unrealizedPnl":     position.UnrealizedPnL(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"quoteAsset":    portfolio.QuoteAsset,
+		"cash":          portfolio.Cash,
+		"equity":        portfolio.Equity(),
+		"realizedPnl":   portfolio.RealizedPnL(),
+		"unrealizedPnl": portfolio.UnrealizedPnL(),
+		"positions":     positions,
+		"updatedAt":     portfolio.UpdatedAt,
+	})
+}
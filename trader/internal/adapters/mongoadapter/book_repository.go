@@ -0,0 +1,51 @@
+// Package mongoadapter reads the market data of the collector and records
+// the orders of the trader in MongoDB.
+package mongoadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const repositoryTimeout = 10 * time.Second
+
+// MongoBookRepository reads the order book snapshots stored by the collector.
+type MongoBookRepository struct {
+	client       *mongo.Client
+	databaseName string
+	collection   string
+}
+
+func NewMongoBookRepository(client *mongo.Client, dbName, collection string) *MongoBookRepository {
+	return &MongoBookRepository{
+		client:       client,
+		databaseName: dbName,
+		collection:   collection,
+	}
+}
+
+func (r *MongoBookRepository) LatestSnapshot(ctx context.Context, symbol string) (domain.BookSnapshot, bool, error) {
+	collection := r.client.Database(r.databaseName).Collection(r.collection)
+
+	var snapshot domain.BookSnapshot
+
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+
+	err := collection.FindOne(ctx, bson.M{"symbol": symbol}, findOptions).Decode(&snapshot)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.BookSnapshot{}, false, nil
+	}
+
+	if err != nil {
+		return domain.BookSnapshot{}, false, fmt.Errorf("failed to fetch latest book snapshot: %w", err)
+	}
+
+	return snapshot, true, nil
+}
@@ -0,0 +1,88 @@
+package mongoadapter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoOrderRepository keeps one document per order and mode, replaced on
+// every change of the order.
+type MongoOrderRepository struct {
+	client       *mongo.Client
+	databaseName string
+	collection   string
+}
+
+type orderDocument struct {
+	Mode         string `bson:"mode"`
+	domain.Order `bson:",inline"`
+	UpdatedAt    time.Time `bson:"updatedAt"`
+}
+
+func NewMongoOrderRepository(client *mongo.Client, dbName, collection string) (*MongoOrderRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), repositoryTimeout)
+	defer cancel()
+
+	repo := &MongoOrderRepository{
+		client:       client,
+		databaseName: dbName,
+		collection:   collection,
+	}
+
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "mode", Value: 1}, {Key: "orderId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	if _, err := repo.coll().Indexes().CreateOne(ctx, indexModel); err != nil {
+		return nil, fmt.Errorf("failed to create order index: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *MongoOrderRepository) coll() *mongo.Collection {
+	return r.client.Database(r.databaseName).Collection(r.collection)
+}
+
+func (r *MongoOrderRepository) SaveOrder(ctx context.Context, mode string, order domain.Order) error {
+	document := orderDocument{Mode: mode, Order: order, UpdatedAt: time.Now().UTC()}
+
+	_, err := r.coll().ReplaceOne(ctx,
+		bson.M{"mode": mode, "orderId": order.ID},
+		document,
+		options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save order: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MongoOrderRepository) Orders(ctx context.Context, mode string) ([]domain.Order, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "orderId", Value: 1}})
+
+	cursor, err := r.coll().Find(ctx, bson.M{"mode": mode}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var documents []orderDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode orders: %w", err)
+	}
+
+	orders := make([]domain.Order, len(documents))
+	for i, document := range documents {
+		orders[i] = document.Order
+	}
+
+	return orders, nil
+}
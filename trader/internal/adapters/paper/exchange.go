@@ -0,0 +1,490 @@
+// Package paper implements the exchange port with simulated fills against the
+// order book snapshots of the collector.
+package paper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+	"github.com/mkaganm/algo-trade/trader/internal/ports"
+)
+
+// Config controls the fill model.
+type Config struct {
+	// MakerFee is the fee rate of fills of resting limit orders.
+	MakerFee float64
+	// TakerFee is the fee rate of fills against the book on placement.
+	TakerFee float64
+	// Latency delays every order before it reaches the book.
+	Latency time.Duration
+	// MaxBookAge is the age from which a snapshot is too stale to fill
+	// against, no limit when zero.
+	MaxBookAge time.Duration
+}
+
+// Exchange fills market orders by walking the latest book snapshot and limit
+// orders when the book crosses their price. Fees are charged in the received
+// asset like on Binance. Every order change is recorded under the paper mode,
+// and Restore rebuilds the balances and open orders from the records.
+type Exchange struct {
+	books  ports.BookRepository
+	orders ports.OrderRepository
+	config Config
+
+	mu       sync.Mutex
+	nextID   int64
+	balances map[string]*domain.Balance
+	placed   map[int64]*domain.Order
+	// seen is the time of the last snapshot an open order was filled
+	// against, so a snapshot fills an order only once.
+	seen map[int64]time.Time
+}
+
+func NewExchange(
+	books ports.BookRepository,
+	orders ports.OrderRepository,
+	balances []domain.Balance,
+	config Config,
+) *Exchange {
+	e := &Exchange{
+		books:  books,
+		orders: orders,
+		config: config,
+		// IDs continue to grow across restarts, so recorded orders are not overwritten
+		nextID:   time.Now().UnixMilli(),
+		balances: make(map[string]*domain.Balance),
+		placed:   make(map[int64]*domain.Order),
+		seen:     make(map[int64]time.Time),
+	}
+
+	for _, balance := range balances {
+		e.balances[balance.Asset] = &domain.Balance{Asset: balance.Asset, Free: balance.Free, Locked: balance.Locked}
+	}
+
+	return e
+}
+
+// Restore replays the recorded paper orders onto the initial balances, so a
+// restart continues with the balances and open orders the last run left. It
+// must be called before trading.
+func (e *Exchange) Restore(ctx context.Context) error {
+	orders, err := e.orders.Orders(ctx, domain.ModePaper)
+	if err != nil {
+		return fmt.Errorf("failed to load paper orders: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Open orders only fill against snapshots taken after the restart
+	now := time.Now()
+
+	for i := range orders {
+		order := &orders[i]
+
+		base, quote, ok := domain.SplitSymbol(order.Symbol)
+		if !ok {
+			return fmt.Errorf("%w: unknown symbol %q of paper order %d", domain.ErrInvalidOrder, order.Symbol, order.ID)
+		}
+
+		e.settle(*order, base, quote)
+
+		if order.Open() {
+			e.lock(order, base, quote, order.Quantity-order.ExecutedQuantity)
+			e.seen[order.ID] = now
+		}
+
+		e.placed[order.ID] = order
+		e.nextID = max(e.nextID, order.ID)
+	}
+
+	return nil
+}
+
+// settle books the executed part of a recorded order on the free balances.
+func (e *Exchange) settle(order domain.Order, base, quote string) {
+	paid, received := e.balance(quote), e.balance(base)
+	spent, gained := order.QuoteQuantity, order.ExecutedQuantity
+
+	if order.Side == domain.Sell {
+		paid, received = received, paid
+		spent, gained = order.ExecutedQuantity, order.QuoteQuantity
+	}
+
+	paid.Free -= spent
+	received.Free += gained
+
+	if order.Commission != 0 {
+		e.balance(order.CommissionAsset).Free -= order.Commission
+	}
+}
+
+func (e *Exchange) PlaceOrder(ctx context.Context, request domain.OrderRequest) (domain.Order, error) {
+	base, quote, err := validate(request)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return domain.Order{}, ctx.Err()
+	case <-time.After(e.config.Latency):
+	}
+
+	book, err := e.latestBook(ctx, request.Symbol)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	e.mu.Lock()
+
+	order, err := e.place(request, base, quote, book)
+
+	e.mu.Unlock()
+
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	e.record(ctx, order)
+
+	return order, nil
+}
+
+// place fills request against book as taker and rests the remainder of limit
+// orders. Orders the balances cannot cover are rejected as a whole.
+func (e *Exchange) place(
+	request domain.OrderRequest,
+	base, quote string,
+	book domain.BookSnapshot,
+) (domain.Order, error) {
+	levels := book.Asks
+	if request.Side == domain.Sell {
+		levels = book.Bids
+	}
+
+	executed, cost := walk(slices.Clone(levels), request.Side, request.Price, request.Quantity, request.QuoteQuantity)
+
+	remaining := 0.0
+	if request.Type == domain.OrderTypeLimit && !complete(executed, request.Quantity) {
+		remaining = request.Quantity - executed
+	}
+
+	if request.Side == domain.Buy && e.balance(quote).Free < cost+remaining*request.Price {
+		return domain.Order{}, fmt.Errorf("%w: %s for %s", domain.ErrInsufficientBalance, quote, request.Symbol)
+	}
+
+	if request.Side == domain.Sell && e.balance(base).Free < executed+remaining {
+		return domain.Order{}, fmt.Errorf("%w: %s for %s", domain.ErrInsufficientBalance, base, request.Symbol)
+	}
+
+	e.nextID++
+
+	order := &domain.Order{
+		ID:            e.nextID,
+		ClientOrderID: request.ClientOrderID,
+		Symbol:        request.Symbol,
+		Side:          request.Side,
+		Type:          request.Type,
+		Status:        domain.OrderStatusNew,
+		Price:         request.Price,
+		Quantity:      request.Quantity,
+		Time:          time.Now().UTC(),
+	}
+
+	e.fill(order, base, quote, executed, cost, e.config.TakerFee, false)
+
+	switch {
+	case request.Type == domain.OrderTypeMarket:
+		// Market orders never rest, what the book cannot fill expires
+		order.Quantity = max(order.Quantity, order.ExecutedQuantity)
+		order.Status = domain.OrderStatusExpired
+
+		if complete(executed, request.Quantity) || complete(cost, request.QuoteQuantity) {
+			order.Status = domain.OrderStatusFilled
+		}
+	case remaining > 0:
+		e.lock(order, base, quote, remaining)
+		e.seen[order.ID] = book.Timestamp
+
+		if executed > 0 {
+			order.Status = domain.OrderStatusPartiallyFilled
+		}
+	default:
+		order.Status = domain.OrderStatusFilled
+	}
+
+	e.placed[order.ID] = order
+
+	return *order, nil
+}
+
+func (e *Exchange) CancelOrder(ctx context.Context, symbol string, orderID int64) (domain.Order, error) {
+	e.mu.Lock()
+
+	order, ok := e.placed[orderID]
+	if !ok || order.Symbol != symbol || !order.Open() {
+		e.mu.Unlock()
+
+		return domain.Order{}, fmt.Errorf("%w: %s order %d", domain.ErrOrderNotFound, symbol, orderID)
+	}
+
+	base, quote, _ := domain.SplitSymbol(symbol)
+	e.lock(order, base, quote, -(order.Quantity - order.ExecutedQuantity))
+	order.Status = domain.OrderStatusCanceled
+	delete(e.seen, orderID)
+	canceled := *order
+
+	e.mu.Unlock()
+
+	e.record(ctx, canceled)
+
+	return canceled, nil
+}
+
+func (e *Exchange) GetOrder(_ context.Context, symbol string, orderID int64) (domain.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order, ok := e.placed[orderID]
+	if !ok || order.Symbol != symbol {
+		return domain.Order{}, fmt.Errorf("%w: %s order %d", domain.ErrOrderNotFound, symbol, orderID)
+	}
+
+	return *order, nil
+}
+
+func (e *Exchange) GetOrderByClientID(_ context.Context, symbol, clientOrderID string) (domain.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, order := range e.placed {
+		if order.Symbol == symbol && clientOrderID != "" && order.ClientOrderID == clientOrderID {
+			return *order, nil
+		}
+	}
+
+	return domain.Order{}, fmt.Errorf("%w: %s order %q", domain.ErrOrderNotFound, symbol, clientOrderID)
+}
+
+// OpenOrders returns the open orders of symbol, of every symbol when empty,
+// oldest first.
+func (e *Exchange) OpenOrders(_ context.Context, symbol string) ([]domain.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.openOrders(symbol), nil
+}
+
+// Balances returns the assets with a free or locked amount.
+func (e *Exchange) Balances(_ context.Context) ([]domain.Balance, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	balances := make([]domain.Balance, 0, len(e.balances))
+
+	for _, balance := range e.balances {
+		if balance.Free != 0 || balance.Locked != 0 {
+			balances = append(balances, *balance)
+		}
+	}
+
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Asset < balances[j].Asset })
+
+	return balances, nil
+}
+
+// Match fills the resting limit orders the latest snapshots cross, at their
+// limit price with the maker fee. The liquidity of a snapshot is shared by
+// the orders in time priority and fills each order only once.
+func (e *Exchange) Match(ctx context.Context) error {
+	e.mu.Lock()
+
+	symbols := make(map[string]bool)
+	for _, order := range e.openOrders("") {
+		symbols[order.Symbol] = true
+	}
+
+	e.mu.Unlock()
+
+	var errs []error
+
+	for symbol := range symbols {
+		book, err := e.latestBook(ctx, symbol)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		e.mu.Lock()
+
+		filled := e.match(book)
+
+		e.mu.Unlock()
+
+		for _, order := range filled {
+			e.record(ctx, order)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e *Exchange) match(book domain.BookSnapshot) []domain.Order {
+	base, quote, _ := domain.SplitSymbol(book.Symbol)
+	asks, bids := slices.Clone(book.Asks), slices.Clone(book.Bids)
+
+	var filled []domain.Order
+
+	for _, open := range e.openOrders(book.Symbol) {
+		order := e.placed[open.ID]
+		if !book.Timestamp.After(e.seen[order.ID]) {
+			continue
+		}
+
+		e.seen[order.ID] = book.Timestamp
+
+		levels := asks
+		if order.Side == domain.Sell {
+			levels = bids
+		}
+
+		executed, _ := walk(levels, order.Side, order.Price, order.Quantity-order.ExecutedQuantity, 0)
+		if executed == 0 {
+			continue
+		}
+
+		e.fill(order, base, quote, executed, executed*order.Price, e.config.MakerFee, true)
+
+		order.Status = domain.OrderStatusPartiallyFilled
+		if complete(order.ExecutedQuantity, order.Quantity) {
+			order.Status = domain.OrderStatusFilled
+			delete(e.seen, order.ID)
+		}
+
+		filled = append(filled, *order)
+	}
+
+	return filled
+}
+
+// fill books a fill of executed base for cost quote. Resting orders pay from
+// the locked balance.
+func (e *Exchange) fill(order *domain.Order, base, quote string, executed, cost, feeRate float64, resting bool) {
+	if executed == 0 {
+		return
+	}
+
+	paid, received := e.balance(quote), e.balance(base)
+	spent, gained := cost, executed
+	order.CommissionAsset = base
+
+	if order.Side == domain.Sell {
+		paid, received = received, paid
+		spent, gained = executed, cost
+		order.CommissionAsset = quote
+	}
+
+	if resting {
+		paid.Locked -= spent
+	} else {
+		paid.Free -= spent
+	}
+
+	commission := gained * feeRate
+	received.Free += gained - commission
+
+	order.ExecutedQuantity += executed
+	order.QuoteQuantity += cost
+	order.Commission += commission
+}
+
+// lock moves the funds of the remaining quantity of a limit order from free
+// to locked, or back when remaining is negative.
+func (e *Exchange) lock(order *domain.Order, base, quote string, remaining float64) {
+	balance, amount := e.balance(quote), remaining*order.Price
+	if order.Side == domain.Sell {
+		balance, amount = e.balance(base), remaining
+	}
+
+	balance.Free -= amount
+	balance.Locked += amount
+}
+
+func (e *Exchange) balance(asset string) *domain.Balance {
+	balance, ok := e.balances[asset]
+	if !ok {
+		balance = &domain.Balance{Asset: asset}
+		e.balances[asset] = balance
+	}
+
+	return balance
+}
+
+func (e *Exchange) openOrders(symbol string) []domain.Order {
+	var orders []domain.Order
+
+	for _, order := range e.placed {
+		if order.Open() && (symbol == "" || order.Symbol == symbol) {
+			orders = append(orders, *order)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+
+	return orders
+}
+
+func (e *Exchange) latestBook(ctx context.Context, symbol string) (domain.BookSnapshot, error) {
+	book, found, err := e.books.LatestSnapshot(ctx, symbol)
+	if err != nil {
+		return domain.BookSnapshot{}, fmt.Errorf("%w: %w", domain.ErrExchangeUnavailable, err)
+	}
+
+	if !found {
+		return domain.BookSnapshot{}, fmt.Errorf("%w: no order book of %s", domain.ErrExchangeUnavailable, symbol)
+	}
+
+	if age := time.Since(book.Timestamp); e.config.MaxBookAge > 0 && age > e.config.MaxBookAge {
+		return domain.BookSnapshot{}, fmt.Errorf("%w: order book of %s is %s old",
+			domain.ErrExchangeUnavailable, symbol, age.Round(time.Second))
+	}
+
+	return book, nil
+}
+
+// record saves the order, a failure is logged as the simulation went ahead anyway.
+func (e *Exchange) record(ctx context.Context, order domain.Order) {
+	if err := e.orders.SaveOrder(ctx, domain.ModePaper, order); err != nil {
+		log.Printf("Failed to record paper order %d: %v", order.ID, err)
+	}
+}
+
+// validate checks the request the way the exchange does and returns the
+// assets of its symbol.
+func validate(request domain.OrderRequest) (base, quote string, err error) {
+	base, quote, ok := domain.SplitSymbol(request.Symbol)
+
+	switch {
+	case !ok:
+		return "", "", fmt.Errorf("%w: unknown symbol %q", domain.ErrInvalidOrder, request.Symbol)
+	case request.Side != domain.Buy && request.Side != domain.Sell:
+		return "", "", fmt.Errorf("%w: unknown side %q", domain.ErrInvalidOrder, request.Side)
+	case request.Type == domain.OrderTypeMarket && (request.Quantity > 0) == (request.QuoteQuantity > 0):
+		return "", "", fmt.Errorf("%w: market orders take either a quantity or a quote quantity", domain.ErrInvalidOrder)
+	case request.Type == domain.OrderTypeLimit && (request.Quantity <= 0 || request.Price <= 0):
+		return "", "", fmt.Errorf("%w: limit orders take a quantity and a price", domain.ErrInvalidOrder)
+	case request.Type != domain.OrderTypeMarket && request.Type != domain.OrderTypeLimit:
+		return "", "", fmt.Errorf("%w: unsupported order type %q", domain.ErrInvalidOrder, request.Type)
+	}
+
+	return base, quote, nil
+}
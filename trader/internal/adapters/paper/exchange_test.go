@@ -0,0 +1,265 @@
+package paper
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fee = 0.001
+
+type stubBookRepository struct {
+	book domain.BookSnapshot
+}
+
+func (r *stubBookRepository) LatestSnapshot(_ context.Context, symbol string) (domain.BookSnapshot, bool, error) {
+	return r.book, r.book.Symbol == symbol, nil
+}
+
+type stubOrderRepository struct {
+	saved []domain.Order
+}
+
+func (r *stubOrderRepository) SaveOrder(_ context.Context, mode string, order domain.Order) error {
+	if mode == domain.ModePaper {
+		r.saved = append(r.saved, order)
+	}
+
+	return nil
+}
+
+// Orders returns the latest saved state of every order.
+func (r *stubOrderRepository) Orders(_ context.Context, _ string) ([]domain.Order, error) {
+	var orders []domain.Order
+
+	for _, order := range r.saved {
+		index := slices.IndexFunc(orders, func(saved domain.Order) bool { return saved.ID == order.ID })
+		if index < 0 {
+			orders = append(orders, order)
+
+			continue
+		}
+
+		orders[index] = order
+	}
+
+	return orders, nil
+}
+
+func book(bids, asks []domain.PriceLevel) domain.BookSnapshot {
+	return domain.BookSnapshot{Symbol: "BTCUSDT", Bids: bids, Asks: asks, Timestamp: time.Now()}
+}
+
+func newTestExchange(books *stubBookRepository, orders *stubOrderRepository, balances ...domain.Balance) *Exchange {
+	return NewExchange(books, orders, balances, Config{MakerFee: fee / 2, TakerFee: fee, MaxBookAge: time.Minute})
+}
+
+func balanceOf(t *testing.T, exchange *Exchange, asset string) domain.Balance {
+	t.Helper()
+
+	balances, err := exchange.Balances(context.Background())
+	require.NoError(t, err)
+
+	for _, balance := range balances {
+		if balance.Asset == asset {
+			return balance
+		}
+	}
+
+	return domain.Balance{Asset: asset}
+}
+
+func TestExchangeWalksTheBookForMarketOrders(t *testing.T) {
+	ctx := context.Background()
+	books := &stubBookRepository{book: book(
+		[]domain.PriceLevel{{Price: 99, Quantity: 1}},
+		[]domain.PriceLevel{{Price: 100, Quantity: 1}, {Price: 101, Quantity: 2}},
+	)}
+	orders := &stubOrderRepository{}
+	exchange := newTestExchange(books, orders, domain.Balance{Asset: "USDT", Free: 1000})
+
+	order, err := exchange.PlaceOrder(ctx, domain.OrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          domain.Buy,
+		Type:          domain.OrderTypeMarket,
+		QuoteQuantity: 201,
+		ClientOrderID: "signal-1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.OrderStatusFilled, order.Status)
+	assert.InDelta(t, 2, order.ExecutedQuantity, 1e-9)
+	assert.InDelta(t, 100.5, order.AveragePrice(), 1e-9)
+	assert.InDelta(t, 2*fee, order.Commission, 1e-9)
+	assert.Equal(t, "BTC", order.CommissionAsset)
+	assert.InDelta(t, 799, balanceOf(t, exchange, "USDT").Free, 1e-9)
+	assert.InDelta(t, 2-2*fee, balanceOf(t, exchange, "BTC").Free, 1e-9)
+	require.Len(t, orders.saved, 1)
+
+	// Selling more than the bids hold fills what is there and expires the rest
+	order, err = exchange.PlaceOrder(ctx, domain.OrderRequest{
+		Symbol:   "BTCUSDT",
+		Side:     domain.Sell,
+		Type:     domain.OrderTypeMarket,
+		Quantity: 1.5,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.OrderStatusExpired, order.Status)
+	assert.InDelta(t, 1, order.ExecutedQuantity, 1e-9)
+	assert.InDelta(t, 99*fee, order.Commission, 1e-9)
+	assert.InDelta(t, 799+99-99*fee, balanceOf(t, exchange, "USDT").Free, 1e-9)
+
+	_, err = exchange.PlaceOrder(ctx, domain.OrderRequest{
+		Symbol:   "BTCUSDT",
+		Side:     domain.Sell,
+		Type:     domain.OrderTypeMarket,
+		Quantity: 5,
+	})
+	require.ErrorIs(t, err, domain.ErrInsufficientBalance)
+}
+
+func TestExchangeFillsRestingLimitOrdersWhenCrossed(t *testing.T) {
+	ctx := context.Background()
+	books := &stubBookRepository{book: book(
+		[]domain.PriceLevel{{Price: 99, Quantity: 5}},
+		[]domain.PriceLevel{{Price: 100, Quantity: 5}},
+	)}
+	orders := &stubOrderRepository{}
+	exchange := newTestExchange(books, orders, domain.Balance{Asset: "USDT", Free: 1000})
+
+	place := func(quantity float64) domain.Order {
+		order, err := exchange.PlaceOrder(ctx, domain.OrderRequest{
+			Symbol:      "BTCUSDT",
+			Side:        domain.Buy,
+			Type:        domain.OrderTypeLimit,
+			Quantity:    quantity,
+			Price:       95,
+			TimeInForce: domain.TimeInForceGTC,
+		})
+		require.NoError(t, err)
+
+		return order
+	}
+
+	first, second := place(2), place(3)
+
+	assert.Equal(t, domain.OrderStatusNew, first.Status)
+	assert.Equal(t, domain.Balance{Asset: "USDT", Free: 525, Locked: 475}, balanceOf(t, exchange, "USDT"))
+
+	// The snapshot the orders were placed against does not fill them
+	require.NoError(t, exchange.Match(ctx))
+	assert.Len(t, orders.saved, 2)
+
+	// The price drops through the limit, the older order is filled first
+	books.book = book(
+		[]domain.PriceLevel{{Price: 93, Quantity: 5}},
+		[]domain.PriceLevel{{Price: 94, Quantity: 3}, {Price: 96, Quantity: 5}},
+	)
+	require.NoError(t, exchange.Match(ctx))
+
+	first, err := exchange.GetOrder(ctx, "BTCUSDT", first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusFilled, first.Status)
+	assert.InDelta(t, 95, first.AveragePrice(), 1e-9)
+	assert.InDelta(t, 2*fee/2, first.Commission, 1e-9)
+
+	second, err = exchange.GetOrder(ctx, "BTCUSDT", second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusPartiallyFilled, second.Status)
+	assert.InDelta(t, 1, second.ExecutedQuantity, 1e-9)
+
+	// Canceling releases the funds of the unfilled rest
+	_, err = exchange.CancelOrder(ctx, "BTCUSDT", second.ID)
+	require.NoError(t, err)
+
+	usdt := balanceOf(t, exchange, "USDT")
+	assert.InDelta(t, 1000-3*95, usdt.Free, 1e-9)
+	assert.InDelta(t, 0, usdt.Locked, 1e-9)
+	assert.InDelta(t, 3-3*fee/2, balanceOf(t, exchange, "BTC").Free, 1e-9)
+
+	open, err := exchange.OpenOrders(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, open)
+}
+
+func TestExchangeRejectsStaleBooks(t *testing.T) {
+	books := &stubBookRepository{book: book(nil, []domain.PriceLevel{{Price: 100, Quantity: 1}})}
+	books.book.Timestamp = time.Now().Add(-time.Hour)
+	exchange := newTestExchange(books, &stubOrderRepository{}, domain.Balance{Asset: "USDT", Free: 1000})
+
+	_, err := exchange.PlaceOrder(context.Background(), domain.OrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          domain.Buy,
+		Type:          domain.OrderTypeMarket,
+		QuoteQuantity: 100,
+	})
+	require.ErrorIs(t, err, domain.ErrExchangeUnavailable)
+}
+
+func TestExchangeRestoresFromRecordedOrders(t *testing.T) {
+	ctx := context.Background()
+	books := &stubBookRepository{book: book(
+		[]domain.PriceLevel{{Price: 99, Quantity: 5}},
+		[]domain.PriceLevel{{Price: 100, Quantity: 1}},
+	)}
+	orders := &stubOrderRepository{}
+	initial := domain.Balance{Asset: "USDT", Free: 1000}
+	exchange := newTestExchange(books, orders, initial)
+
+	_, err := exchange.PlaceOrder(ctx, domain.OrderRequest{
+		Symbol:   "BTCUSDT",
+		Side:     domain.Buy,
+		Type:     domain.OrderTypeMarket,
+		Quantity: 1,
+	})
+	require.NoError(t, err)
+
+	resting, err := exchange.PlaceOrder(ctx, domain.OrderRequest{
+		Symbol:      "BTCUSDT",
+		Side:        domain.Sell,
+		Type:        domain.OrderTypeLimit,
+		Quantity:    0.8,
+		Price:       101,
+		TimeInForce: domain.TimeInForceGTC,
+	})
+	require.NoError(t, err)
+
+	books.book = book(
+		[]domain.PriceLevel{{Price: 102, Quantity: 0.5}},
+		[]domain.PriceLevel{{Price: 103, Quantity: 1}},
+	)
+	require.NoError(t, exchange.Match(ctx))
+
+	// A restart starts from the initial balances and replays the recorded orders
+	restarted := newTestExchange(books, orders, initial)
+	require.NoError(t, restarted.Restore(ctx))
+
+	for _, asset := range []string{"USDT", "BTC"} {
+		want := balanceOf(t, exchange, asset)
+		got := balanceOf(t, restarted, asset)
+		assert.InDeltaf(t, want.Free, got.Free, 1e-9, "free %s", asset)
+		assert.InDeltaf(t, want.Locked, got.Locked, 1e-9, "locked %s", asset)
+	}
+
+	open, err := restarted.OpenOrders(ctx, "BTCUSDT")
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, resting.ID, open[0].ID)
+	assert.InDelta(t, 0.5, open[0].ExecutedQuantity, 1e-9)
+
+	// New orders do not reuse the recorded IDs
+	order, err := restarted.PlaceOrder(ctx, domain.OrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          domain.Buy,
+		Type:          domain.OrderTypeMarket,
+		QuoteQuantity: 10,
+	})
+	require.NoError(t, err)
+	assert.Greater(t, order.ID, resting.ID)
+}
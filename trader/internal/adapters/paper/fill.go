@@ -0,0 +1,52 @@
+package paper
+
+import "github.com/mkaganm/algo-trade/trader/internal/domain"
+
+// fillTolerance is the relative shortfall up to which an order counts as
+// fully executed, walking a book by quote amount leaves rounding residue.
+const fillTolerance = 1e-9
+
+// walk takes liquidity from levels, best first, until quantity of the base
+// asset or quoteQuantity of the quote asset is executed, the book side runs
+// out or, when limit is set, the levels no longer cross it. It reduces the
+// levels by what it takes and returns the executed base and its quote cost.
+func walk(levels []domain.PriceLevel, side string, limit, quantity, quoteQuantity float64) (executed, cost float64) {
+	for i := range levels {
+		level := &levels[i]
+
+		if limit > 0 && !crosses(side, level.Price, limit) {
+			break
+		}
+
+		take := level.Quantity
+		if quantity > 0 {
+			take = min(take, quantity-executed)
+		} else {
+			take = min(take, (quoteQuantity-cost)/level.Price)
+		}
+
+		if take <= 0 {
+			break
+		}
+
+		level.Quantity -= take
+		executed += take
+		cost += take * level.Price
+	}
+
+	return executed, cost
+}
+
+// crosses reports whether an order on side at limit trades with a level at price.
+func crosses(side string, price, limit float64) bool {
+	if side == domain.Buy {
+		return price <= limit
+	}
+
+	return price >= limit
+}
+
+// complete reports whether executed reaches a positive target.
+func complete(executed, target float64) bool {
+	return target > 0 && executed >= target*(1-fillTolerance)
+}
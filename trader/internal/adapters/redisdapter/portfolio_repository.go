@@ -0,0 +1,56 @@
+package redisdapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+)
+
+// PortfolioKeyPrefix is followed by the trading mode, so paper and live
+// portfolios are kept apart.
+const PortfolioKeyPrefix = "trader:portfolio:"
+
+// RedisPortfolioRepository stores the portfolio as JSON under a single key.
+type RedisPortfolioRepository struct {
+	client *redis.Client
+	key    string
+}
+
+func NewRedisPortfolioRepository(client *redis.Client, key string) *RedisPortfolioRepository {
+	return &RedisPortfolioRepository{client: client, key: key}
+}
+
+func (r *RedisPortfolioRepository) LoadPortfolio(ctx context.Context) (domain.Portfolio, bool, error) {
+	data, err := r.client.Get(ctx, r.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return domain.Portfolio{}, false, nil
+	}
+
+	if err != nil {
+		return domain.Portfolio{}, false, fmt.Errorf("failed to load portfolio: %w", err)
+	}
+
+	var portfolio domain.Portfolio
+	if err := json.Unmarshal(data, &portfolio); err != nil {
+		return domain.Portfolio{}, false, fmt.Errorf("failed to decode portfolio: %w", err)
+	}
+
+	return portfolio, true, nil
+}
+
+func (r *RedisPortfolioRepository) SavePortfolio(ctx context.Context, portfolio domain.Portfolio) error {
+	data, err := json.Marshal(portfolio)
+	if err != nil {
+		return fmt.Errorf("failed to encode portfolio: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.key, data, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save portfolio: %w", err)
+	}
+
+	return nil
+}
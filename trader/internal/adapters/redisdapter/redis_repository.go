@@ -2,15 +2,28 @@ package redisdapter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
 const (
+	signalStream   = "trade_signals_stream"
+	signalGroup    = "signal_group"
+	signalConsumer = "signal_consumer"
 	readGroupCount = 10
+	// claimStartID is the XAUTOCLAIM cursor at the start and the end of the
+	// pending entries.
+	claimStartID = "0-0"
+	// seenSignalTTL is how long delivered signal IDs are remembered to drop redeliveries.
+	seenSignalTTL    = 24 * time.Hour
+	seenSignalPrefix = "trader:signal:"
 )
 
+var errUnexpectedClaimReply = errors.New("unexpected XAUTOCLAIM reply")
+
 type RedisRepository struct {
 	client *redis.Client
 }
@@ -19,18 +32,26 @@ func NewRedisRepository(client *redis.Client) *RedisRepository {
 	return &RedisRepository{client: client}
 }
 
-func (r *RedisRepository) ReadMessages(ctx context.Context) ([]map[string]interface{}, error) {
-	// Ensure the consumer group exists
-	err := r.client.XGroupCreateMkStream(ctx, "trade_signals_stream", "signal_group", "$").Err()
+// ensureGroup creates the consumer group unless it exists.
+func (r *RedisRepository) ensureGroup(ctx context.Context) error {
+	err := r.client.XGroupCreateMkStream(ctx, signalStream, signalGroup, "$").Err()
 	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
-		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) ReadMessages(ctx context.Context) ([]map[string]interface{}, error) {
+	if err := r.ensureGroup(ctx); err != nil {
+		return nil, err
 	}
 
 	// Read messages from the stream
 	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
-		Group:    "signal_group",
-		Consumer: "signal_consumer",
-		Streams:  []string{"trade_signals_stream", ">"},
+		Group:    signalGroup,
+		Consumer: signalConsumer,
+		Streams:  []string{signalStream, ">"},
 		Count:    readGroupCount,
 		Block:    0,
 	}).Result()
@@ -50,6 +71,81 @@ func (r *RedisRepository) ReadMessages(ctx context.Context) ([]map[string]interf
 	return messages, nil
 }
 
+// ClaimMessages takes over the messages delivered to the group but left
+// unacknowledged for at least minIdle, such as those of a previous run.
+func (r *RedisRepository) ClaimMessages(ctx context.Context, minIdle time.Duration) ([]map[string]interface{}, error) {
+	if err := r.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+
+	var messages []map[string]interface{}
+
+	for start := claimStartID; ; {
+		// Sent raw as go-redis v8 rejects the three element reply of Redis 7
+		reply, err := r.client.Do(ctx, "XAUTOCLAIM", signalStream, signalGroup, signalConsumer,
+			minIdle.Milliseconds(), start, "COUNT", readGroupCount).Slice()
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim pending messages: %w", err)
+		}
+
+		next, claimed, err := parseClaimReply(reply)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, claimed...)
+
+		if next == claimStartID {
+			return messages, nil
+		}
+
+		start = next
+	}
+}
+
+// parseClaimReply reads the next cursor and the claimed messages of an
+// XAUTOCLAIM reply. Entries deleted from the stream meanwhile are skipped.
+func parseClaimReply(reply []interface{}) (string, []map[string]interface{}, error) {
+	if len(reply) < 2 {
+		return "", nil, fmt.Errorf("%w: %d elements", errUnexpectedClaimReply, len(reply))
+	}
+
+	next, ok := reply[0].(string)
+	entries, isSlice := reply[1].([]interface{})
+
+	if !ok || !isSlice {
+		return "", nil, fmt.Errorf("%w: %v", errUnexpectedClaimReply, reply)
+	}
+
+	messages := make([]map[string]interface{}, 0, len(entries))
+
+	for _, entry := range entries {
+		fields, ok := entry.([]interface{})
+		if !ok || len(fields) != 2 {
+			continue
+		}
+
+		id, ok := fields[0].(string)
+		pairs, isSlice := fields[1].([]interface{})
+
+		if !ok || !isSlice {
+			continue
+		}
+
+		message := make(map[string]interface{}, len(pairs)/2+1)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			if key, ok := pairs[i].(string); ok {
+				message[key] = pairs[i+1]
+			}
+		}
+
+		message["id"] = id
+		messages = append(messages, message)
+	}
+
+	return next, messages, nil
+}
+
 // CheckHealth implements the HealthService interface.
 func (r *RedisRepository) CheckHealth(ctx context.Context) error {
 	_, err := r.client.Ping(ctx).Result()
@@ -67,5 +163,22 @@ func (r *RedisRepository) WriteProcessedMessage(ctx context.Context, message map
 }
 
 func (r *RedisRepository) AcknowledgeMessage(ctx context.Context, messageID string) error {
-	return r.client.XAck(ctx, "trade_signals_stream", "signal_group", messageID).Err()
+	return r.client.XAck(ctx, signalStream, signalGroup, messageID).Err()
+}
+
+func (r *RedisRepository) SignalSeen(ctx context.Context, signalID string) (bool, error) {
+	count, err := r.client.Exists(ctx, seenSignalPrefix+signalID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check signal: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *RedisRepository) MarkSignalSeen(ctx context.Context, signalID string) error {
+	if err := r.client.Set(ctx, seenSignalPrefix+signalID, 1, seenSignalTTL).Err(); err != nil {
+		return fmt.Errorf("failed to mark signal seen: %w", err)
+	}
+
+	return nil
 }
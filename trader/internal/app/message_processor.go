@@ -3,20 +3,64 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
 	"github.com/mkaganm/algo-trade/trader/internal/ports"
 )
 
+// An order whose placement status is unknown is looked up this many times,
+// the exchange may take a moment to list it.
+const (
+	orderLookupAttempts = 3
+	orderLookupDelay    = time.Second
+)
+
+// pendingRetryIdle is how long a message whose trade failed temporarily stays
+// pending before it is retried.
+const pendingRetryIdle = time.Minute
+
 type MessageProcessor struct {
-	redisRepo ports.RedisRepository
+	redisRepo   ports.RedisRepository
+	exchange    ports.Exchange
+	portfolio   *PortfolioManager
+	minStrength float64
+	quoteAmount float64
 }
 
-func NewMessageProcessor(redisRepo ports.RedisRepository) *MessageProcessor {
-	return &MessageProcessor{redisRepo: redisRepo}
+// NewMessageProcessor creates a processor that ignores signals weaker than
+// minStrength and trades up to quoteAmount of the quote asset per signal on
+// exchange, keeping portfolio up to date. Without an exchange the orders are
+// only logged and portfolio may be nil.
+func NewMessageProcessor(
+	redisRepo ports.RedisRepository,
+	exchange ports.Exchange,
+	portfolio *PortfolioManager,
+	minStrength, quoteAmount float64,
+) *MessageProcessor {
+	return &MessageProcessor{
+		redisRepo:   redisRepo,
+		exchange:    exchange,
+		portfolio:   portfolio,
+		minStrength: minStrength,
+		quoteAmount: quoteAmount,
+	}
 }
 
+// Recover handles the messages a previous run read but did not acknowledge.
+func (mp *MessageProcessor) Recover(ctx context.Context) {
+	mp.claim(ctx, 0)
+}
+
+// ProcessMessages handles the messages whose trade failed temporarily and then
+// the new ones. A message is only acknowledged once its signal was traded or
+// cannot be traded at all.
 func (mp *MessageProcessor) ProcessMessages(ctx context.Context) {
+	mp.claim(ctx, pendingRetryIdle)
+
 	messages, err := mp.redisRepo.ReadMessages(ctx)
 	if err != nil {
 		log.Printf("Error reading messages: %v", err)
@@ -24,8 +68,32 @@ func (mp *MessageProcessor) ProcessMessages(ctx context.Context) {
 		return
 	}
 
-	msg := messages[0]
+	for _, msg := range messages {
+		mp.handle(ctx, msg, false)
+	}
+}
+
+// claim handles the messages left unacknowledged for at least minIdle.
+func (mp *MessageProcessor) claim(ctx context.Context, minIdle time.Duration) {
+	messages, err := mp.redisRepo.ClaimMessages(ctx, minIdle)
+	if err != nil {
+		log.Printf("Error claiming pending messages: %v", err)
+
+		return
+	}
 
+	if len(messages) > 0 {
+		log.Printf("Retrying %d pending messages", len(messages))
+	}
+
+	for _, msg := range messages {
+		mp.handle(ctx, msg, true)
+	}
+}
+
+// handle trades the signal of msg. A claimed message may have been traded by
+// the attempt that left it pending, so its order is looked up first.
+func (mp *MessageProcessor) handle(ctx context.Context, msg map[string]interface{}, claimed bool) {
 	id, ok := msg["id"].(string)
 	if !ok {
 		log.Printf("Message missing 'id' or invalid type: %v", msg)
@@ -33,13 +101,57 @@ func (mp *MessageProcessor) ProcessMessages(ctx context.Context) {
 		return
 	}
 
-	timestamp, ok := msg["time"].(string)
-	if !ok {
-		log.Printf("Message missing 'time' or invalid type: %v", msg)
+	signal, err := domain.ParseTradeSignal(msg)
+	if err != nil {
+		log.Printf("Error parsing signal: %v", err)
+		mp.acknowledge(ctx, id, msg)
 
 		return
 	}
 
+	// Signals are delivered at least once, a redelivered signal was already traded
+	if signal.SignalID != "" {
+		seen, err := mp.redisRepo.SignalSeen(ctx, signal.SignalID)
+		if err != nil {
+			log.Printf("Error checking signal %s for duplicates: %v", signal.SignalID, err)
+
+			return
+		}
+
+		if seen {
+			log.Printf("Skipping duplicate signal %s", signal.SignalID)
+			mp.acknowledge(ctx, id, msg)
+
+			return
+		}
+	}
+
+	traded := false
+	if claimed {
+		traded, err = mp.recoverOrder(ctx, signal)
+	}
+
+	if err == nil && !traded {
+		err = mp.tradeProcess(ctx, signal)
+	}
+
+	if err != nil {
+		log.Printf("Leaving message %s pending to retry: %v", id, err)
+
+		return
+	}
+
+	if signal.SignalID != "" {
+		if err := mp.redisRepo.MarkSignalSeen(ctx, signal.SignalID); err != nil {
+			log.Printf("Error marking signal %s seen: %v", signal.SignalID, err)
+		}
+	}
+
+	mp.acknowledge(ctx, id, msg)
+}
+
+// acknowledge records msg as processed and removes it from the pending entries.
+func (mp *MessageProcessor) acknowledge(ctx context.Context, id string, msg map[string]interface{}) {
 	processedData, err := json.Marshal(msg)
 	if err != nil {
 		log.Printf("Error marshalling message: %v", err)
@@ -47,6 +159,8 @@ func (mp *MessageProcessor) ProcessMessages(ctx context.Context) {
 		return
 	}
 
+	timestamp, _ := msg["time"].(string)
+
 	err = mp.redisRepo.WriteProcessedMessage(ctx, map[string]interface{}{
 		"original_id":   id,
 		"original_data": processedData,
@@ -59,29 +173,190 @@ func (mp *MessageProcessor) ProcessMessages(ctx context.Context) {
 		return
 	}
 
-	err = mp.redisRepo.AcknowledgeMessage(ctx, id)
-	if err != nil {
+	if err := mp.redisRepo.AcknowledgeMessage(ctx, id); err != nil {
 		log.Printf("Error acknowledging message: %v", err)
+	}
+}
 
-		return
+// recoverOrder records the order an earlier attempt placed for signal and
+// reports whether there was one.
+func (mp *MessageProcessor) recoverOrder(ctx context.Context, signal domain.TradeSignal) (bool, error) {
+	if mp.exchange == nil || signal.SignalID == "" || !mp.portfolio.Trades(signal.Symbol) {
+		return false, nil
 	}
 
-	mp.tradeProcess(msg)
+	order, err := mp.exchange.GetOrderByClientID(ctx, signal.Symbol, signal.SignalID)
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to look up order %s: %w", signal.SignalID, err)
+	}
+
+	log.Printf("Recovered %s order %d for %s: %s", order.Side, order.ID, order.Symbol, order.Status)
+
+	mp.record(ctx, order, true)
+
+	return true, nil
 }
 
 // tradeProcess processes the trade signal and executes the corresponding action.
-// It can be extended to include more complex logic, such as placing orders or updating positions.
-// For now, it simply logs the action taken based on the signal.
-// You can implement the actual trading logic here, such as placing orders through an exchange API.
-func (mp *MessageProcessor) tradeProcess(msg map[string]interface{}) {
-	switch msg["signal"] {
-	case "BUY":
-		log.Println("Executing BUY order")
-	case "SELL":
-		log.Println("Executing SELL order")
-	case "NEUTRAL":
+// Signals weaker than the minimum strength are ignored. A buy signal opens a
+// position for the quote amount scaled by its strength unless one is open
+// already, a sell signal closes the open position. It only fails when the
+// order may succeed on a retry.
+func (mp *MessageProcessor) tradeProcess(ctx context.Context, signal domain.TradeSignal) error {
+	if mp.exchange != nil && signal.Price > 0 {
+		if err := mp.portfolio.Mark(ctx, signal.Symbol, signal.Price, signal.Time); err != nil {
+			log.Printf("Failed to mark %s position: %v", signal.Symbol, err)
+		}
+	}
+
+	if signal.Action != domain.Neutral && signal.Strength < mp.minStrength {
+		log.Printf("Ignoring weak %s signal of %s on %s (strength %.2f < %.2f)",
+			signal.Action, signal.StrategyID, signal.Symbol, signal.Strength, mp.minStrength)
+
+		return nil
+	}
+
+	switch signal.Action {
+	case domain.Buy, domain.Sell:
+		return mp.placeOrder(ctx, signal)
+	case domain.Neutral:
 		log.Println("Holding position")
 	default:
 		log.Println("Unknown signal received")
 	}
+
+	return nil
+}
+
+// placeOrder sends a market order for signal. The signal ID is the client
+// order ID, so the order can be traced back to the signal. Only errors that
+// may pass on a retry are returned, the others are logged.
+func (mp *MessageProcessor) placeOrder(ctx context.Context, signal domain.TradeSignal) error {
+	if mp.exchange == nil {
+		log.Printf("Executing %s order for %s at %.2f, position scale %.2f (no exchange configured)",
+			signal.Action, signal.Symbol, signal.Price, signal.Strength)
+
+		return nil
+	}
+
+	if !mp.portfolio.Trades(signal.Symbol) {
+		log.Printf("Skipping %s, it is not quoted in the portfolio asset", signal.Symbol)
+
+		return nil
+	}
+
+	request := domain.OrderRequest{
+		Symbol:        signal.Symbol,
+		Side:          signal.Action,
+		Type:          domain.OrderTypeMarket,
+		ClientOrderID: signal.SignalID,
+	}
+
+	position, open := mp.portfolio.Holding(signal.Symbol)
+
+	switch {
+	case signal.Action == domain.Buy && open:
+		log.Printf("Already holding %g %s, not adding to the position", position.Quantity, signal.Symbol)
+
+		return nil
+	case signal.Action == domain.Buy:
+		request.QuoteQuantity = min(mp.quoteAmount*signal.Strength, mp.portfolio.Cash())
+		if request.QuoteQuantity <= 0 {
+			log.Printf("No cash left to buy %s", signal.Symbol)
+
+			return nil
+		}
+	case !open:
+		log.Printf("No %s position to sell", signal.Symbol)
+
+		return nil
+	default:
+		request.Quantity = position.Quantity
+	}
+
+	order, err := mp.exchange.PlaceOrder(ctx, request)
+
+	lookedUp := errors.Is(err, domain.ErrOrderStatusUnknown) && request.ClientOrderID != ""
+	if lookedUp {
+		log.Printf("Status of %s order %s for %s unknown, looking it up: %v",
+			signal.Action, request.ClientOrderID, signal.Symbol, err)
+
+		order, err = mp.lookupOrder(ctx, request)
+	}
+
+	// Without a client order ID a retry could not find the order it may have placed
+	if retryable(err) && request.ClientOrderID != "" {
+		return fmt.Errorf("failed to place %s order for %s: %w", signal.Action, signal.Symbol, err)
+	}
+
+	if err != nil {
+		log.Printf("Failed to place %s order for %s: %v", signal.Action, signal.Symbol, err)
+
+		return nil
+	}
+
+	log.Printf("Placed %s order %d for %s: %s, %g executed at %.2f",
+		order.Side, order.ID, order.Symbol, order.Status, order.ExecutedQuantity, order.AveragePrice())
+
+	mp.record(ctx, order, lookedUp)
+
+	return nil
+}
+
+// record books order in the portfolio. An order that was looked up carries no
+// fills, so the portfolio is synced with the balances to account for its fee.
+func (mp *MessageProcessor) record(ctx context.Context, order domain.Order, lookedUp bool) {
+	if err := mp.portfolio.Record(ctx, order); err != nil {
+		log.Printf("Failed to record order %d in the portfolio: %v", order.ID, err)
+
+		return
+	}
+
+	if !lookedUp {
+		return
+	}
+
+	if err := mp.portfolio.Sync(ctx, order.Symbol); err != nil {
+		log.Printf("Failed to sync the %s position with the balances: %v", order.Symbol, err)
+	}
+}
+
+// lookupOrder finds the order placed for request by its client order ID after
+// the placement response was lost.
+func (mp *MessageProcessor) lookupOrder(ctx context.Context, request domain.OrderRequest) (domain.Order, error) {
+	var err error
+
+	for attempt := range orderLookupAttempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return domain.Order{}, ctx.Err()
+			case <-time.After(orderLookupDelay):
+			}
+		}
+
+		var order domain.Order
+
+		order, err = mp.exchange.GetOrderByClientID(ctx, request.Symbol, request.ClientOrderID)
+		if err == nil {
+			return order, nil
+		}
+	}
+
+	return domain.Order{}, fmt.Errorf("%w: order %s not found after %d lookups: %w",
+		domain.ErrOrderStatusUnknown, request.ClientOrderID, orderLookupAttempts, err)
+}
+
+// retryable reports whether an order that failed with err may pass later, or
+// may have been placed after all.
+func retryable(err error) bool {
+	return errors.Is(err, domain.ErrOrderStatusUnknown) ||
+		errors.Is(err, domain.ErrRateLimited) ||
+		errors.Is(err, domain.ErrExchangeUnavailable) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
 }
@@ -0,0 +1,233 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+	"github.com/mkaganm/algo-trade/trader/internal/ports"
+)
+
+// balanceTolerance is the difference between a position and the exchange
+// balance that is left alone, the precision of exchange quantities.
+const balanceTolerance = 1e-8
+
+// PortfolioManager keeps the portfolio up to date with the orders of the
+// trader and saves it after every change.
+type PortfolioManager struct {
+	repo       ports.PortfolioRepository
+	exchange   ports.Exchange
+	quoteAsset string
+	dustValue  float64
+
+	mu        sync.Mutex
+	portfolio domain.Portfolio
+}
+
+// NewPortfolioManager creates a manager for a portfolio in quoteAsset whose
+// positions worth less than dustValue count as flat.
+func NewPortfolioManager(
+	repo ports.PortfolioRepository,
+	exchange ports.Exchange,
+	quoteAsset string,
+	dustValue float64,
+) *PortfolioManager {
+	return &PortfolioManager{
+		repo:       repo,
+		exchange:   exchange,
+		quoteAsset: quoteAsset,
+		dustValue:  dustValue,
+		portfolio:  domain.NewPortfolio(quoteAsset),
+	}
+}
+
+// Reconcile loads the saved portfolio and aligns it with the exchange
+// balances, which win on any difference: the cash is the quote balance and
+// the positions the trader opened follow the balance of their base asset.
+// Other assets, such as fee tokens or foreign holdings, are left alone.
+func (m *PortfolioManager) Reconcile(ctx context.Context) error {
+	portfolio, found, err := m.repo.LoadPortfolio(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !found || portfolio.QuoteAsset != m.quoteAsset {
+		log.Printf("Starting a new %s portfolio", m.quoteAsset)
+
+		portfolio = domain.NewPortfolio(m.quoteAsset)
+	}
+
+	if portfolio.Positions == nil {
+		portfolio.Positions = make(map[string]domain.Position)
+	}
+
+	balances, err := m.exchange.Balances(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balances: %w", err)
+	}
+
+	held := totals(balances)
+
+	if cash := held[m.quoteAsset]; math.Abs(cash-portfolio.Cash) > balanceTolerance {
+		log.Printf("Reconciled %s cash from %g to %g", m.quoteAsset, portfolio.Cash, cash)
+
+		portfolio.Cash = cash
+	}
+
+	delete(held, m.quoteAsset)
+
+	for symbol, position := range portfolio.Positions {
+		base, _, _ := domain.SplitSymbol(symbol)
+		amount := held[base]
+
+		delete(held, base)
+
+		if math.Abs(amount-position.Quantity) > balanceTolerance {
+			log.Printf("Reconciled %s position from %g to %g", symbol, position.Quantity, amount)
+
+			position.Quantity = amount
+			portfolio.Positions[symbol] = position
+		}
+	}
+
+	for asset, amount := range held {
+		if amount > balanceTolerance {
+			log.Printf("Ignoring %g %s held on the exchange without a position", amount, asset)
+		}
+	}
+
+	portfolio.UpdatedAt = time.Now().UTC()
+
+	m.mu.Lock()
+	m.portfolio = portfolio
+	m.mu.Unlock()
+
+	return m.repo.SavePortfolio(ctx, portfolio)
+}
+
+// Sync aligns the position of symbol and the cash with the exchange balances.
+// Orders looked up after their placement response was lost carry no fills,
+// so the fee they paid is only known from the balances.
+func (m *PortfolioManager) Sync(ctx context.Context, symbol string) error {
+	base, _, ok := domain.SplitSymbol(symbol)
+	if !ok {
+		return nil
+	}
+
+	balances, err := m.exchange.Balances(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balances: %w", err)
+	}
+
+	held := totals(balances)
+
+	m.mu.Lock()
+
+	if position, ok := m.portfolio.Positions[symbol]; ok && math.Abs(held[base]-position.Quantity) > balanceTolerance {
+		log.Printf("Synced %s position from %g to %g", symbol, position.Quantity, held[base])
+
+		position.Quantity = held[base]
+		m.portfolio.Positions[symbol] = position
+	}
+
+	if cash := held[m.quoteAsset]; math.Abs(cash-m.portfolio.Cash) > balanceTolerance {
+		log.Printf("Synced %s cash from %g to %g", m.quoteAsset, m.portfolio.Cash, cash)
+
+		m.portfolio.Cash = cash
+	}
+
+	portfolio := m.snapshot()
+
+	m.mu.Unlock()
+
+	return m.repo.SavePortfolio(ctx, portfolio)
+}
+
+// Trades reports whether symbol is quoted in the asset of the portfolio.
+func (m *PortfolioManager) Trades(symbol string) bool {
+	_, quote, ok := domain.SplitSymbol(symbol)
+
+	return ok && quote == m.quoteAsset
+}
+
+// Holding returns the position of symbol and whether it is open.
+func (m *PortfolioManager) Holding(symbol string) (domain.Position, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	position := m.portfolio.Positions[symbol]
+
+	return position, position.Open(m.dustValue)
+}
+
+// Cash returns the cash in the quote asset.
+func (m *PortfolioManager) Cash() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.portfolio.Cash
+}
+
+// Record books the executed part of order and saves the portfolio.
+func (m *PortfolioManager) Record(ctx context.Context, order domain.Order) error {
+	m.mu.Lock()
+
+	err := m.portfolio.Apply(order)
+	portfolio := m.snapshot()
+
+	m.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return m.repo.SavePortfolio(ctx, portfolio)
+}
+
+// Mark values the position of symbol at price and saves the portfolio.
+func (m *PortfolioManager) Mark(ctx context.Context, symbol string, price float64, at time.Time) error {
+	m.mu.Lock()
+
+	if _, ok := m.portfolio.Positions[symbol]; !ok {
+		m.mu.Unlock()
+
+		return nil
+	}
+
+	m.portfolio.Mark(symbol, price, at)
+	portfolio := m.snapshot()
+
+	m.mu.Unlock()
+
+	return m.repo.SavePortfolio(ctx, portfolio)
+}
+
+// Portfolio returns a copy of the portfolio.
+func (m *PortfolioManager) Portfolio() domain.Portfolio {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.snapshot()
+}
+
+// totals returns the free and locked amount of every asset.
+func totals(balances []domain.Balance) map[string]float64 {
+	held := make(map[string]float64, len(balances))
+	for _, balance := range balances {
+		held[balance.Asset] = balance.Free + balance.Locked
+	}
+
+	return held
+}
+
+func (m *PortfolioManager) snapshot() domain.Portfolio {
+	portfolio := m.portfolio
+	portfolio.Positions = maps.Clone(m.portfolio.Positions)
+
+	return portfolio
+}
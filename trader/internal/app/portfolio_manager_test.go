@@ -0,0 +1,345 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryPortfolioRepository struct {
+	portfolio *domain.Portfolio
+}
+
+func (r *memoryPortfolioRepository) LoadPortfolio(context.Context) (domain.Portfolio, bool, error) {
+	if r.portfolio == nil {
+		return domain.Portfolio{}, false, nil
+	}
+
+	return *r.portfolio, true, nil
+}
+
+func (r *memoryPortfolioRepository) SavePortfolio(_ context.Context, portfolio domain.Portfolio) error {
+	r.portfolio = &portfolio
+
+	return nil
+}
+
+// stubExchange fills market orders at a fixed price and keeps the balances,
+// charging fee of the bought quantity in the base asset. Like the exchange it
+// only reports the fee in placement responses. With unknownStatus set the
+// placement responses are lost, with placeErr set the orders fail.
+type stubExchange struct {
+	balances      []domain.Balance
+	price         float64
+	fee           float64
+	placed        []domain.OrderRequest
+	orders        []domain.Order
+	unknownStatus bool
+	placeErr      error
+}
+
+func (e *stubExchange) PlaceOrder(_ context.Context, request domain.OrderRequest) (domain.Order, error) {
+	if e.placeErr != nil {
+		return domain.Order{}, e.placeErr
+	}
+
+	e.placed = append(e.placed, request)
+
+	quantity := request.Quantity
+	if quantity == 0 {
+		quantity = request.QuoteQuantity / e.price
+	}
+
+	order := domain.Order{
+		ID:               int64(len(e.placed)),
+		Symbol:           request.Symbol,
+		Side:             request.Side,
+		Type:             request.Type,
+		Status:           domain.OrderStatusFilled,
+		Quantity:         quantity,
+		ExecutedQuantity: quantity,
+		ClientOrderID:    request.ClientOrderID,
+		QuoteQuantity:    quantity * e.price,
+	}
+	e.orders = append(e.orders, order)
+
+	base, quote, _ := domain.SplitSymbol(request.Symbol)
+
+	switch request.Side {
+	case domain.Buy:
+		order.Commission, order.CommissionAsset = quantity*e.fee, base
+		e.credit(base, quantity-order.Commission)
+		e.credit(quote, -order.QuoteQuantity)
+	case domain.Sell:
+		e.credit(base, -quantity)
+		e.credit(quote, order.QuoteQuantity)
+	}
+
+	if e.unknownStatus {
+		return domain.Order{}, domain.ErrOrderStatusUnknown
+	}
+
+	return order, nil
+}
+
+func (e *stubExchange) credit(asset string, amount float64) {
+	for i := range e.balances {
+		if e.balances[i].Asset == asset {
+			e.balances[i].Free += amount
+
+			return
+		}
+	}
+
+	e.balances = append(e.balances, domain.Balance{Asset: asset, Free: amount})
+}
+
+func (e *stubExchange) CancelOrder(context.Context, string, int64) (domain.Order, error) {
+	return domain.Order{}, domain.ErrOrderNotFound
+}
+
+func (e *stubExchange) GetOrder(context.Context, string, int64) (domain.Order, error) {
+	return domain.Order{}, domain.ErrOrderNotFound
+}
+
+func (e *stubExchange) GetOrderByClientID(_ context.Context, symbol, clientOrderID string) (domain.Order, error) {
+	for _, order := range e.orders {
+		if order.Symbol == symbol && order.ClientOrderID == clientOrderID {
+			return order, nil
+		}
+	}
+
+	return domain.Order{}, domain.ErrOrderNotFound
+}
+
+func (e *stubExchange) OpenOrders(context.Context, string) ([]domain.Order, error) {
+	return nil, nil
+}
+
+func (e *stubExchange) Balances(context.Context) ([]domain.Balance, error) {
+	return e.balances, nil
+}
+
+// stubRedisRepository delivers the stream messages once and keeps them pending
+// until they are acknowledged.
+type stubRedisRepository struct {
+	stream    []map[string]interface{}
+	delivered []map[string]interface{}
+	acked     map[string]bool
+	seen      map[string]bool
+}
+
+func (r *stubRedisRepository) ReadMessages(context.Context) ([]map[string]interface{}, error) {
+	messages := r.stream
+	r.stream = nil
+	r.delivered = append(r.delivered, messages...)
+
+	return messages, nil
+}
+
+func (r *stubRedisRepository) ClaimMessages(context.Context, time.Duration) ([]map[string]interface{}, error) {
+	var pending []map[string]interface{}
+
+	for _, message := range r.delivered {
+		if id, _ := message["id"].(string); !r.acked[id] {
+			pending = append(pending, message)
+		}
+	}
+
+	return pending, nil
+}
+
+func (r *stubRedisRepository) WriteProcessedMessage(context.Context, map[string]interface{}) error {
+	return nil
+}
+
+func (r *stubRedisRepository) AcknowledgeMessage(_ context.Context, messageID string) error {
+	r.acked[messageID] = true
+
+	return nil
+}
+
+func (r *stubRedisRepository) SignalSeen(_ context.Context, signalID string) (bool, error) {
+	return r.seen[signalID], nil
+}
+
+func (r *stubRedisRepository) MarkSignalSeen(_ context.Context, signalID string) error {
+	r.seen[signalID] = true
+
+	return nil
+}
+
+func signalMessage(id, signalID, action, symbol string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       id,
+		"signalId": signalID,
+		"signal":   action,
+		"symbol":   symbol,
+		"price":    "100",
+	}
+}
+
+func TestPortfolioManagerReconcilesWithBalances(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryPortfolioRepository{portfolio: &domain.Portfolio{
+		QuoteAsset: "USDT",
+		Cash:       500,
+		Positions: map[string]domain.Position{
+			"BTCUSDT": {Symbol: "BTCUSDT", Quantity: 1, AverageEntryPrice: 100, RealizedPnL: 5},
+		},
+	}}
+	exchange := &stubExchange{balances: []domain.Balance{
+		{Asset: "USDT", Free: 550, Locked: 50},
+		{Asset: "BTC", Free: 0.5},
+		{Asset: "ETH", Free: 2},
+		{Asset: "BNB", Free: 0.1},
+	}}
+	manager := NewPortfolioManager(repo, exchange, "USDT", 1)
+
+	require.NoError(t, manager.Reconcile(ctx))
+
+	assert.InDelta(t, 600, manager.Cash(), 1e-9)
+
+	// The balance wins over the saved quantity, the history is kept
+	btc, open := manager.Holding("BTCUSDT")
+	assert.True(t, open)
+	assert.Equal(t, domain.Position{Symbol: "BTCUSDT", Quantity: 0.5, AverageEntryPrice: 100, RealizedPnL: 5}, btc)
+
+	// Assets held without a position, like fee tokens, are not traded
+	require.NoError(t, manager.Mark(ctx, "ETHUSDT", 50, time.Now()))
+
+	_, open = manager.Holding("ETHUSDT")
+	assert.False(t, open)
+	assert.NotContains(t, manager.Portfolio().Positions, "BNBUSDT")
+	assert.Equal(t, manager.Portfolio(), *repo.portfolio)
+}
+
+func TestMessageProcessorDoesNotPyramid(t *testing.T) {
+	ctx := context.Background()
+	exchange := &stubExchange{balances: []domain.Balance{{Asset: "USDT", Free: 1000}}, price: 100}
+	manager := NewPortfolioManager(&memoryPortfolioRepository{}, exchange, "USDT", 1)
+	require.NoError(t, manager.Reconcile(ctx))
+
+	processor := NewMessageProcessor(nil, exchange, manager, 0, 300)
+
+	for _, action := range []string{domain.Buy, domain.Buy, domain.Sell, domain.Sell} {
+		require.NoError(t, processor.tradeProcess(ctx, domain.TradeSignal{
+			Action:   action,
+			Strength: 1,
+			Symbol:   "BTCUSDT",
+			Price:    100,
+			Time:     time.Now(),
+		}))
+	}
+
+	// The second buy is skipped while the position is open, the second sell while it is flat
+	require.Len(t, exchange.placed, 2)
+	assert.InDelta(t, 300, exchange.placed[0].QuoteQuantity, 1e-9)
+	assert.Equal(t, domain.Sell, exchange.placed[1].Side)
+	assert.InDelta(t, 3, exchange.placed[1].Quantity, 1e-9)
+
+	_, open := manager.Holding("BTCUSDT")
+	assert.False(t, open)
+	assert.InDelta(t, 1000, manager.Cash(), 1e-9)
+}
+
+func TestMessageProcessorReconcilesOrdersOfUnknownStatus(t *testing.T) {
+	ctx := context.Background()
+	exchange := &stubExchange{balances: []domain.Balance{{Asset: "USDT", Free: 1000}}, price: 100}
+	manager := NewPortfolioManager(&memoryPortfolioRepository{}, exchange, "USDT", 1)
+	require.NoError(t, manager.Reconcile(ctx))
+
+	exchange.unknownStatus, exchange.fee = true, 0.001
+	processor := NewMessageProcessor(nil, exchange, manager, 0, 300)
+
+	require.NoError(t, processor.tradeProcess(ctx, domain.TradeSignal{
+		SignalID: "signal-1",
+		Action:   domain.Buy,
+		Strength: 1,
+		Symbol:   "BTCUSDT",
+		Price:    100,
+		Time:     time.Now(),
+	}))
+
+	// The order was placed although its response was lost, so the portfolio books
+	// it net of the fee the lookup does not report
+	require.Len(t, exchange.placed, 1)
+
+	btc, open := manager.Holding("BTCUSDT")
+	assert.True(t, open)
+	assert.InDelta(t, 2.997, btc.Quantity, 1e-9)
+	assert.InDelta(t, 700, manager.Cash(), 1e-9)
+
+	// The whole position can be sold
+	exchange.unknownStatus = false
+
+	require.NoError(t, processor.tradeProcess(ctx, domain.TradeSignal{
+		SignalID: "signal-2",
+		Action:   domain.Sell,
+		Strength: 1,
+		Symbol:   "BTCUSDT",
+		Price:    100,
+		Time:     time.Now(),
+	}))
+	require.Len(t, exchange.placed, 2)
+	assert.InDelta(t, 2.997, exchange.placed[1].Quantity, 1e-9)
+}
+
+func TestMessageProcessorAcknowledgesTradedSignals(t *testing.T) {
+	ctx := context.Background()
+	exchange := &stubExchange{balances: []domain.Balance{{Asset: "USDT", Free: 1000}}, price: 100}
+	manager := NewPortfolioManager(&memoryPortfolioRepository{}, exchange, "USDT", 1)
+	require.NoError(t, manager.Reconcile(ctx))
+
+	redisRepo := &stubRedisRepository{
+		stream: []map[string]interface{}{
+			signalMessage("1-0", "signal-1", domain.Buy, "BTCUSDT"),
+			signalMessage("2-0", "signal-2", domain.Buy, "ETHUSDT"),
+			{"id": "3-0", "price": "invalid"},
+		},
+		acked: map[string]bool{},
+		seen:  map[string]bool{},
+	}
+	processor := NewMessageProcessor(redisRepo, exchange, manager, 0, 300)
+
+	// Every message of the batch is handled, the failed trades stay pending
+	exchange.placeErr = domain.ErrExchangeUnavailable
+
+	processor.ProcessMessages(ctx)
+	assert.Equal(t, map[string]bool{"3-0": true}, redisRepo.acked)
+	assert.Empty(t, redisRepo.seen)
+
+	// The first attempt placed the BTCUSDT order after all, so only ETHUSDT is ordered on recovery
+	exchange.placeErr = nil
+	exchange.balances = []domain.Balance{{Asset: "USDT", Free: 700}, {Asset: "BTC", Free: 3}}
+	exchange.orders = append(exchange.orders, domain.Order{
+		ID:               1,
+		Symbol:           "BTCUSDT",
+		Side:             domain.Buy,
+		Status:           domain.OrderStatusFilled,
+		ExecutedQuantity: 3,
+		QuoteQuantity:    300,
+		ClientOrderID:    "signal-1",
+	})
+
+	processor.Recover(ctx)
+	require.Len(t, exchange.placed, 1)
+	assert.Equal(t, "ETHUSDT", exchange.placed[0].Symbol)
+	assert.Equal(t, map[string]bool{"1-0": true, "2-0": true, "3-0": true}, redisRepo.acked)
+	assert.Equal(t, map[string]bool{"signal-1": true, "signal-2": true}, redisRepo.seen)
+
+	btc, open := manager.Holding("BTCUSDT")
+	assert.True(t, open)
+	assert.InDelta(t, 3, btc.Quantity, 1e-9)
+
+	// A redelivered signal is acknowledged without trading it again
+	redisRepo.stream = []map[string]interface{}{signalMessage("4-0", "signal-2", domain.Buy, "ETHUSDT")}
+
+	processor.ProcessMessages(ctx)
+	assert.Len(t, exchange.placed, 1)
+	assert.True(t, redisRepo.acked["4-0"])
+}
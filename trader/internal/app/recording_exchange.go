@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"log"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+	"github.com/mkaganm/algo-trade/trader/internal/ports"
+)
+
+// RecordingExchange records the orders it places on an exchange under a
+// trading mode, so that live runs can be compared with paper runs. Only
+// placement responses carry the fills and their fees.
+type RecordingExchange struct {
+	ports.Exchange
+
+	orders ports.OrderRepository
+	mode   string
+}
+
+func NewRecordingExchange(exchange ports.Exchange, orders ports.OrderRepository, mode string) *RecordingExchange {
+	return &RecordingExchange{
+		Exchange: exchange,
+		orders:   orders,
+		mode:     mode,
+	}
+}
+
+func (e *RecordingExchange) PlaceOrder(ctx context.Context, request domain.OrderRequest) (domain.Order, error) {
+	order, err := e.Exchange.PlaceOrder(ctx, request)
+	if err == nil {
+		e.record(ctx, order)
+	}
+
+	return order, err
+}
+
+// GetOrderByClientID records the order it finds too, as it is only looked up
+// when the placement response was lost.
+func (e *RecordingExchange) GetOrderByClientID(
+	ctx context.Context,
+	symbol, clientOrderID string,
+) (domain.Order, error) {
+	order, err := e.Exchange.GetOrderByClientID(ctx, symbol, clientOrderID)
+	if err == nil {
+		e.record(ctx, order)
+	}
+
+	return order, err
+}
+
+// record saves the order, a failure is logged as the order stands anyway.
+func (e *RecordingExchange) record(ctx context.Context, order domain.Order) {
+	if err := e.orders.SaveOrder(ctx, e.mode, order); err != nil {
+		log.Printf("Failed to record %s order %d: %v", e.mode, order.ID, err)
+	}
+}
@@ -3,15 +3,67 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+)
+
+const (
+	defaultOrderQuoteAmount = 100.0
+	defaultRecvWindow       = 5 * time.Second
+	defaultBinanceBaseURL   = "https://api.binance.com"
+	defaultPaperFee         = 0.001
+	defaultPaperLatency     = 100 * time.Millisecond
+	defaultPaperMaxBookAge  = 10 * time.Second
+	defaultPaperMatch       = time.Second
+	defaultPaperBalances    = "USDT=10000"
+	defaultDustValue        = 1.0
 )
 
 type Config struct {
 	RedisAddr string
 	AppPort   string
+	// MinSignalStrength is the strength below which signals are ignored.
+	MinSignalStrength float64
+	// OrderQuoteAmount is the quote asset amount a full strength signal trades.
+	OrderQuoteAmount float64
+	// Exchange selects the exchange adapter, orders are only logged without one.
+	Exchange          string
+	BinanceBaseURL    string
+	BinanceAPIKey     string
+	BinanceAPISecret  string
+	BinanceRecvWindow time.Duration
+
+	// MongoURI is optional for live trading, the orders are recorded when set.
+	MongoURI         string
+	DatabaseName     string
+	SnapshotColName  string
+	OrderColName     string
+	PaperBalances    []domain.Balance
+	PaperMakerFee    float64
+	PaperTakerFee    float64
+	PaperLatency     time.Duration
+	PaperMaxBookAge  time.Duration
+	PaperMatchPeriod time.Duration
+
+	// QuoteAsset is the asset the portfolio holds its cash in, only symbols
+	// quoted in it are traded.
+	QuoteAsset string
+	// DustValue is the quote value below which a position counts as flat.
+	DustValue float64
 }
 
+// Exchange adapters.
+const (
+	// ExchangeBinance trades on the Binance Spot REST API.
+	ExchangeBinance = "binance"
+	// ExchangePaper simulates the fills against the order book of the collector.
+	ExchangePaper = "paper"
+)
+
 func LoadConfig() *Config {
 	// Load environment variables from .env file
 	err := godotenv.Load()
@@ -21,7 +73,92 @@ func LoadConfig() *Config {
 
 	// Retrieve configuration values
 	return &Config{
-		RedisAddr: os.Getenv("REDIS_ADDR"),
-		AppPort:   os.Getenv("APP_PORT"),
+		RedisAddr:         os.Getenv("REDIS_ADDR"),
+		AppPort:           os.Getenv("APP_PORT"),
+		MinSignalStrength: getFloat("MIN_SIGNAL_STRENGTH", 0),
+		OrderQuoteAmount:  getFloat("ORDER_QUOTE_AMOUNT", defaultOrderQuoteAmount),
+		Exchange:          os.Getenv("EXCHANGE"),
+		BinanceBaseURL:    getEnv("BINANCE_BASE_URL", defaultBinanceBaseURL),
+		BinanceAPIKey:     os.Getenv("BINANCE_API_KEY"),
+		BinanceAPISecret:  os.Getenv("BINANCE_API_SECRET"),
+		BinanceRecvWindow: getDuration("BINANCE_RECV_WINDOW", defaultRecvWindow),
+		MongoURI:          os.Getenv("MONGO_URI"),
+		DatabaseName:      getEnv("DATABASE_NAME", "btc_data"),
+		SnapshotColName:   getEnv("BOOK_SNAPSHOT_COL_NAME", "depth_snapshots"),
+		OrderColName:      getEnv("ORDERS_COL_NAME", "orders"),
+		PaperBalances:     parseBalances(getEnv("PAPER_BALANCES", defaultPaperBalances)),
+		PaperMakerFee:     getFloat("PAPER_MAKER_FEE", defaultPaperFee),
+		PaperTakerFee:     getFloat("PAPER_TAKER_FEE", defaultPaperFee),
+		PaperLatency:      getDuration("PAPER_LATENCY", defaultPaperLatency),
+		PaperMaxBookAge:   getDuration("PAPER_MAX_BOOK_AGE", defaultPaperMaxBookAge),
+		PaperMatchPeriod:  getDuration("PAPER_MATCH_INTERVAL", defaultPaperMatch),
+		QuoteAsset:        strings.ToUpper(getEnv("PORTFOLIO_QUOTE_ASSET", "USDT")),
+		DustValue:         getFloat("PORTFOLIO_DUST_VALUE", defaultDustValue),
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+
+	return defaultValue
+}
+
+func getFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid %s value, using default: %v", key, err)
+
+		return defaultValue
+	}
+
+	return parsed
+}
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s value, using default: %v", key, err)
+
+		return defaultValue
+	}
+
+	return parsed
+}
+
+// parseBalances parses "ASSET=amount" pairs separated by commas, skipping
+// invalid ones.
+func parseBalances(value string) []domain.Balance {
+	var balances []domain.Balance
+
+	for _, pair := range strings.Split(value, ",") {
+		asset, amount, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			log.Printf("Invalid PAPER_BALANCES entry %q, skipping", pair)
+
+			continue
+		}
+
+		free, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+		if err != nil || free < 0 {
+			log.Printf("Invalid PAPER_BALANCES amount %q, skipping", pair)
+
+			continue
+		}
+
+		balances = append(balances, domain.Balance{Asset: strings.ToUpper(strings.TrimSpace(asset)), Free: free})
+	}
+
+	return balances
 }
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// PriceLevel is a single aggregated price level of an order book side.
+type PriceLevel struct {
+	Price    float64 `bson:"price"    json:"price"`
+	Quantity float64 `bson:"quantity" json:"quantity"`
+}
+
+// BookSnapshot is a top-N view of the local order book the collector
+// reconstructs from the depth stream, best levels first.
+type BookSnapshot struct {
+	Symbol       string       `bson:"symbol"`
+	LastUpdateID int64        `bson:"lastUpdateId"`
+	Bids         []PriceLevel `bson:"bids"`
+	Asks         []PriceLevel `bson:"asks"`
+	Timestamp    time.Time    `bson:"timestamp"`
+}
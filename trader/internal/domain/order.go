@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Order types, time in force and statuses as named by the exchange.
+const (
+	OrderTypeMarket = "MARKET"
+	OrderTypeLimit  = "LIMIT"
+
+	TimeInForceGTC = "GTC"
+
+	OrderStatusNew             = "NEW"
+	OrderStatusPartiallyFilled = "PARTIALLY_FILLED"
+	OrderStatusFilled          = "FILLED"
+	OrderStatusCanceled        = "CANCELED"
+	OrderStatusRejected        = "REJECTED"
+	OrderStatusExpired         = "EXPIRED"
+)
+
+// Trading modes orders are recorded under.
+const (
+	ModeLive  = "live"
+	ModePaper = "paper"
+)
+
+// Exchange errors, adapters wrap them with the details of the exchange.
+var (
+	ErrInvalidOrder        = errors.New("invalid order")
+	ErrOrderRejected       = errors.New("order rejected")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrInvalidTimestamp    = errors.New("request timestamp outside the receive window")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrExchangeUnavailable = errors.New("exchange unavailable")
+	// ErrOrderStatusUnknown is returned when an order request may have reached
+	// the exchange without a response, the order has to be looked up by its
+	// client order ID.
+	ErrOrderStatusUnknown = errors.New("order status unknown")
+)
+
+// OrderRequest is a new order. Market orders take either Quantity in the base
+// asset or QuoteQuantity in the quote asset; limit orders take Quantity,
+// Price and TimeInForce.
+type OrderRequest struct {
+	Symbol        string
+	Side          string
+	Type          string
+	Quantity      float64
+	QuoteQuantity float64
+	Price         float64
+	TimeInForce   string
+	ClientOrderID string
+}
+
+// Order is the state of an order on the exchange. QuoteQuantity is the quote
+// amount executed so far and Commission the fee paid on it in
+// CommissionAsset, known only for orders filled on placement or simulated.
+type Order struct {
+	ID               int64     `bson:"orderId"          json:"orderId"`
+	ClientOrderID    string    `bson:"clientOrderId"    json:"clientOrderId"`
+	Symbol           string    `bson:"symbol"           json:"symbol"`
+	Side             string    `bson:"side"             json:"side"`
+	Type             string    `bson:"type"             json:"type"`
+	Status           string    `bson:"status"           json:"status"`
+	Price            float64   `bson:"price"            json:"price"`
+	Quantity         float64   `bson:"quantity"         json:"quantity"`
+	ExecutedQuantity float64   `bson:"executedQuantity" json:"executedQuantity"`
+	QuoteQuantity    float64   `bson:"quoteQuantity"    json:"quoteQuantity"`
+	Commission       float64   `bson:"commission"       json:"commission"`
+	CommissionAsset  string    `bson:"commissionAsset"  json:"commissionAsset"`
+	Time             time.Time `bson:"time"             json:"time"`
+}
+
+// Open reports whether the order can still be filled or canceled.
+func (o Order) Open() bool {
+	return o.Status == OrderStatusNew || o.Status == OrderStatusPartiallyFilled
+}
+
+// AveragePrice is the average execution price, 0 before the first fill.
+func (o Order) AveragePrice() float64 {
+	if o.ExecutedQuantity == 0 {
+		return 0
+	}
+
+	return o.QuoteQuantity / o.ExecutedQuantity
+}
+
+// Balance is the free and locked (in open orders) amount of an asset.
+type Balance struct {
+	Asset  string
+	Free   float64
+	Locked float64
+}
+
+// quoteAssets are the quote assets symbols are split on, longest match first.
+var quoteAssets = []string{"FDUSD", "USDT", "USDC", "BUSD", "TUSD", "EUR", "TRY", "BTC", "ETH", "BNB"}
+
+// SplitSymbol splits symbol into its base and quote asset.
+func SplitSymbol(symbol string) (base, quote string, ok bool) {
+	for _, quote := range quoteAssets {
+		if base, found := strings.CutSuffix(symbol, quote); found && base != "" {
+			return base, quote, true
+		}
+	}
+
+	return "", "", false
+}
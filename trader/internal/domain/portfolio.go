@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrForeignQuoteAsset is returned for orders on symbols quoted in another
+// asset than the portfolio.
+var ErrForeignQuoteAsset = errors.New("symbol not quoted in the portfolio asset")
+
+// Position is the holding of one symbol. AverageEntryPrice includes the fees
+// paid to open it, RealizedPnL adds up the closed parts net of fees.
+type Position struct {
+	Symbol            string    `json:"symbol"`
+	Quantity          float64   `json:"quantity"`
+	AverageEntryPrice float64   `json:"averageEntryPrice"`
+	RealizedPnL       float64   `json:"realizedPnl"`
+	LastPrice         float64   `json:"lastPrice"`
+	MarkedAt          time.Time `json:"markedAt"`
+}
+
+// UnrealizedPnL is the profit of the open quantity marked to the last price.
+func (p Position) UnrealizedPnL() float64 {
+	if p.LastPrice == 0 {
+		return 0
+	}
+
+	return p.Quantity * (p.LastPrice - p.AverageEntryPrice)
+}
+
+// Value is the open quantity at the last price, at the entry price before
+// the first mark.
+func (p Position) Value() float64 {
+	if p.LastPrice == 0 {
+		return p.Quantity * p.AverageEntryPrice
+	}
+
+	return p.Quantity * p.LastPrice
+}
+
+// Open reports whether the position is worth at least dustValue, smaller
+// remainders of fees and lot sizes count as flat.
+func (p Position) Open(dustValue float64) bool {
+	return p.Quantity > 0 && p.Value() >= dustValue
+}
+
+// Portfolio is the cash in the quote asset and the positions per symbol.
+type Portfolio struct {
+	QuoteAsset string              `json:"quoteAsset"`
+	Cash       float64             `json:"cash"`
+	Positions  map[string]Position `json:"positions"`
+	UpdatedAt  time.Time           `json:"updatedAt"`
+}
+
+func NewPortfolio(quoteAsset string) Portfolio {
+	return Portfolio{QuoteAsset: quoteAsset, Positions: make(map[string]Position)}
+}
+
+// Apply books the executed part of an order. Buying raises the average entry
+// price by the cost, selling realizes the difference to it.
+func (p *Portfolio) Apply(order Order) error {
+	base, quote, ok := SplitSymbol(order.Symbol)
+	if !ok || quote != p.QuoteAsset {
+		return fmt.Errorf("%w: %s", ErrForeignQuoteAsset, order.Symbol)
+	}
+
+	if order.ExecutedQuantity == 0 {
+		return nil
+	}
+
+	position := p.Positions[order.Symbol]
+	position.Symbol = order.Symbol
+
+	quantity, proceeds := order.ExecutedQuantity, order.QuoteQuantity
+
+	switch order.CommissionAsset {
+	case base:
+		quantity -= order.Commission
+	case quote:
+		proceeds -= order.Commission
+	}
+
+	switch order.Side {
+	case Buy:
+		cost := position.Quantity*position.AverageEntryPrice + order.QuoteQuantity
+		position.Quantity += quantity
+		position.AverageEntryPrice = cost / position.Quantity
+		p.Cash -= order.QuoteQuantity
+	case Sell:
+		position.RealizedPnL += proceeds - order.ExecutedQuantity*position.AverageEntryPrice
+		position.Quantity = max(position.Quantity-order.ExecutedQuantity, 0)
+		p.Cash += proceeds
+	}
+
+	position.LastPrice = order.AveragePrice()
+	position.MarkedAt = order.Time
+	p.Positions[order.Symbol] = position
+	p.UpdatedAt = order.Time
+
+	return nil
+}
+
+// Mark values the position of symbol at price. Holdings reconciled without an
+// entry price enter at their first mark.
+func (p *Portfolio) Mark(symbol string, price float64, at time.Time) {
+	position, ok := p.Positions[symbol]
+	if !ok || at.Before(position.MarkedAt) {
+		return
+	}
+
+	if position.AverageEntryPrice == 0 && position.Quantity > 0 {
+		position.AverageEntryPrice = price
+	}
+
+	position.LastPrice = price
+	position.MarkedAt = at
+	p.Positions[symbol] = position
+}
+
+// RealizedPnL is the realized profit of all positions.
+func (p *Portfolio) RealizedPnL() float64 {
+	var pnl float64
+
+	for _, position := range p.Positions {
+		pnl += position.RealizedPnL
+	}
+
+	return pnl
+}
+
+// UnrealizedPnL is the unrealized profit of all positions.
+func (p *Portfolio) UnrealizedPnL() float64 {
+	var pnl float64
+
+	for _, position := range p.Positions {
+		pnl += position.UnrealizedPnL()
+	}
+
+	return pnl
+}
+
+// Equity is the cash plus the open positions marked to their last price.
+func (p *Portfolio) Equity() float64 {
+	equity := p.Cash
+
+	for _, position := range p.Positions {
+		equity += position.Value()
+	}
+
+	return equity
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortfolioTracksEntryPriceAndPnL(t *testing.T) {
+	portfolio := NewPortfolio("USDT")
+	portfolio.Cash = 1000
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	fill := func(side string, quantity, price, commission float64, asset string) {
+		t.Helper()
+
+		require.NoError(t, portfolio.Apply(Order{
+			Symbol:           "BTCUSDT",
+			Side:             side,
+			ExecutedQuantity: quantity,
+			QuoteQuantity:    quantity * price,
+			Commission:       commission,
+			CommissionAsset:  asset,
+			Time:             at,
+		}))
+	}
+
+	// The base asset fee lowers the quantity and so raises the entry price
+	fill(Buy, 2, 100, 0.01, "BTC")
+	fill(Buy, 1.02, 130, 0.01, "BTC")
+
+	position := portfolio.Positions["BTCUSDT"]
+	assert.InDelta(t, 3, position.Quantity, 1e-9)
+	assert.InDelta(t, 332.6/3, position.AverageEntryPrice, 1e-9)
+	assert.InDelta(t, 1000-332.6, portfolio.Cash, 1e-9)
+
+	portfolio.Mark("BTCUSDT", 120, at.Add(time.Minute))
+	assert.InDelta(t, 3*(120-332.6/3), portfolio.UnrealizedPnL(), 1e-9)
+
+	// Selling realizes the difference to the entry price net of the quote fee
+	fill(Sell, 2, 120, 0.24, "USDT")
+
+	position = portfolio.Positions["BTCUSDT"]
+	assert.InDelta(t, 1, position.Quantity, 1e-9)
+	assert.InDelta(t, 240-0.24-2*332.6/3, portfolio.RealizedPnL(), 1e-9)
+	assert.InDelta(t, 1000-332.6+240-0.24, portfolio.Cash, 1e-9)
+	assert.InDelta(t, portfolio.Cash+120, portfolio.Equity(), 1e-9)
+	assert.True(t, position.Open(1))
+
+	require.ErrorIs(t, portfolio.Apply(Order{Symbol: "ETHBTC", Side: Buy}), ErrForeignQuoteAsset)
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Buy     = "BUY"
+	Sell    = "SELL"
+	Neutral = "NEUTRAL"
+)
+
+var ErrInvalidSignal = errors.New("invalid signal")
+
+// indicatorFieldPrefix marks the stream fields holding indicator values.
+const indicatorFieldPrefix = "ind_"
+
+// TradeSignal is a signal published by the processor. ID is the stream
+// message, SignalID identifies the signal across redeliveries. Strength is a
+// normalized [0, 1] confidence, Price the close of the bar the signal was
+// generated on and Indicators the values that justified it.
+type TradeSignal struct {
+	ID         string
+	SignalID   string
+	Action     string
+	Strength   float64
+	Symbol     string
+	Timeframe  string
+	Price      float64
+	Strategy   string
+	StrategyID string
+	Indicators map[string]float64
+	Time       time.Time
+}
+
+// ParseTradeSignal reads a signal from the fields of a stream message. Signals
+// published without a strength are treated as full strength.
+func ParseTradeSignal(values map[string]interface{}) (TradeSignal, error) {
+	signal := TradeSignal{
+		ID:         stringField(values, "id"),
+		SignalID:   stringField(values, "signalId"),
+		Action:     stringField(values, "signal"),
+		Symbol:     stringField(values, "symbol"),
+		Timeframe:  stringField(values, "timeframe"),
+		Strategy:   stringField(values, "strategy"),
+		StrategyID: stringField(values, "strategyId"),
+		Strength:   1,
+		Indicators: make(map[string]float64),
+	}
+
+	var err error
+
+	if value := stringField(values, "strength"); value != "" {
+		if signal.Strength, err = strconv.ParseFloat(value, 64); err != nil {
+			return TradeSignal{}, fmt.Errorf("%w: strength %q", ErrInvalidSignal, value)
+		}
+	}
+
+	if value := stringField(values, "price"); value != "" {
+		if signal.Price, err = strconv.ParseFloat(value, 64); err != nil {
+			return TradeSignal{}, fmt.Errorf("%w: price %q", ErrInvalidSignal, value)
+		}
+	}
+
+	if value := stringField(values, "time"); value != "" {
+		if signal.Time, err = time.Parse(time.RFC3339, value); err != nil {
+			return TradeSignal{}, fmt.Errorf("%w: time %q", ErrInvalidSignal, value)
+		}
+	}
+
+	for field := range values {
+		name, ok := strings.CutPrefix(field, indicatorFieldPrefix)
+		if !ok {
+			continue
+		}
+
+		if value, err := strconv.ParseFloat(stringField(values, field), 64); err == nil {
+			signal.Indicators[name] = value
+		}
+	}
+
+	return signal, nil
+}
+
+func stringField(values map[string]interface{}, name string) string {
+	value, _ := values[name].(string)
+
+	return value
+}
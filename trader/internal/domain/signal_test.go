@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTradeSignal(t *testing.T) {
+	signal, err := ParseTradeSignal(map[string]interface{}{
+		"id":           "1-0",
+		"signalId":     "9f86d081884c7d659a2feaa0c55ad015",
+		"signal":       "BUY",
+		"strength":     "0.25",
+		"symbol":       "BTCUSDT",
+		"timeframe":    "1m",
+		"price":        "64000.5",
+		"strategy":     "sma_crossover",
+		"strategyId":   "sma_crossover(long=20,short=5)",
+		"time":         "2024-01-02T03:04:05Z",
+		"ind_shortSMA": "64010",
+		"ind_longSMA":  "63990",
+		"unknown":      "1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, TradeSignal{
+		ID:         "1-0",
+		SignalID:   "9f86d081884c7d659a2feaa0c55ad015",
+		Action:     Buy,
+		Strength:   0.25,
+		Symbol:     "BTCUSDT",
+		Timeframe:  "1m",
+		Price:      64000.5,
+		Strategy:   "sma_crossover",
+		StrategyID: "sma_crossover(long=20,short=5)",
+		Indicators: map[string]float64{"shortSMA": 64010, "longSMA": 63990},
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}, signal)
+
+	// Signals without a strength are full strength.
+	signal, err = ParseTradeSignal(map[string]interface{}{"signal": "SELL"})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, signal.Strength, 0)
+
+	_, err = ParseTradeSignal(map[string]interface{}{"signal": "SELL", "strength": "strong"})
+	assert.ErrorIs(t, err, ErrInvalidSignal)
+}
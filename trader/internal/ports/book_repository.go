@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+)
+
+// BookRepository reads the order book snapshots stored by the collector.
+type BookRepository interface {
+	// LatestSnapshot returns the newest snapshot of symbol, found is false
+	// when there is none.
+	LatestSnapshot(ctx context.Context, symbol string) (snapshot domain.BookSnapshot, found bool, err error)
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+)
+
+// Exchange is the secondary port for order execution. Errors wrap the
+// exchange errors of the domain package.
+type Exchange interface {
+	PlaceOrder(ctx context.Context, request domain.OrderRequest) (domain.Order, error)
+	CancelOrder(ctx context.Context, symbol string, orderID int64) (domain.Order, error)
+	GetOrder(ctx context.Context, symbol string, orderID int64) (domain.Order, error)
+	// GetOrderByClientID looks an order up by the client order ID it was placed with.
+	GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (domain.Order, error)
+	OpenOrders(ctx context.Context, symbol string) ([]domain.Order, error)
+	Balances(ctx context.Context) ([]domain.Balance, error)
+}
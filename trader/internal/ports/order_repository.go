@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+)
+
+// OrderRepository keeps the latest state of every order per trading mode, so
+// that paper and live runs can be compared.
+type OrderRepository interface {
+	SaveOrder(ctx context.Context, mode string, order domain.Order) error
+	// Orders returns the latest state of every order of mode, oldest first.
+	Orders(ctx context.Context, mode string) ([]domain.Order, error)
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/mkaganm/algo-trade/trader/internal/domain"
+)
+
+// PortfolioRepository persists the portfolio across restarts.
+type PortfolioRepository interface {
+	// LoadPortfolio returns the saved portfolio, found is false when there is none.
+	LoadPortfolio(ctx context.Context) (portfolio domain.Portfolio, found bool, err error)
+	SavePortfolio(ctx context.Context, portfolio domain.Portfolio) error
+}
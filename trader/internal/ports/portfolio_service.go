@@ -0,0 +1,8 @@
+package ports
+
+import "github.com/mkaganm/algo-trade/trader/internal/domain"
+
+// PortfolioService exposes the portfolio of the trader.
+type PortfolioService interface {
+	Portfolio() domain.Portfolio
+}
@@ -1,9 +1,18 @@
 package ports
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type RedisRepository interface {
 	ReadMessages(ctx context.Context) ([]map[string]interface{}, error)
+	// ClaimMessages takes over the messages read but left unacknowledged for
+	// at least minIdle.
+	ClaimMessages(ctx context.Context, minIdle time.Duration) ([]map[string]interface{}, error)
 	WriteProcessedMessage(ctx context.Context, message map[string]interface{}) error
 	AcknowledgeMessage(ctx context.Context, messageID string) error
+	// SignalSeen reports whether signalID was traded already.
+	SignalSeen(ctx context.Context, signalID string) (bool, error)
+	MarkSignalSeen(ctx context.Context, signalID string) error
 }